@@ -0,0 +1,296 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/smarkuck/nes/nes"
+	"github.com/smarkuck/nes/nes/cartridge"
+	"github.com/smarkuck/nes/nes/console"
+	"github.com/smarkuck/nes/nes/mapper"
+	"github.com/smarkuck/nes/nes/nsf"
+	"github.com/smarkuck/nes/nes/patch"
+	"github.com/smarkuck/nes/nes/ppu"
+	"github.com/smarkuck/nes/nes/save"
+	"github.com/smarkuck/nes/nes/screenshot"
+)
+
+const (
+	autoRegion          = "auto"
+	startTrack          = 0
+	wavExtension        = ".wav"
+	unknownRegionFormat = "unknown region %q"
+)
+
+var regions = map[string]nes.Region{
+	"ntsc":  nes.NTSC,
+	"pal":   nes.PAL,
+	"dendy": nes.Dendy,
+}
+
+var nsfExtensions = []string{".nsf", ".nsfe"}
+
+var errMissingROM = errors.New("missing ROM file")
+
+type config struct {
+	rom        string
+	frames     int
+	region     string
+	palette    string
+	database   string
+	screenshot string
+	isFiltered bool
+	autosave   time.Duration
+	noLimit    bool
+	options    screenshot.Options
+	track      int
+	wav        string
+	audio      nsf.Options
+}
+
+func main() {
+	if err := run(parseFlags()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func parseFlags() config {
+	c := config{
+		options: screenshot.DefaultOptions(),
+		audio:   nsf.DefaultOptions(),
+	}
+	flag.IntVar(&c.frames, "frames", 60, "frames to run")
+	flag.StringVar(&c.region, "region", autoRegion,
+		"auto, ntsc, pal or dendy")
+	flag.StringVar(&c.palette, "palette", "", ".pal file")
+	flag.StringVar(&c.database, "database", "",
+		"nes20db.xml correcting bad headers")
+	flag.StringVar(&c.screenshot, "screenshot", "",
+		"save last frame to .png or .ppm file")
+	flag.IntVar(&c.options.Scale, "scale", 1, "screenshot scale")
+	flag.BoolVar(&c.options.Crop, "crop", false,
+		"crop 8 overscan lines at top and bottom")
+	flag.BoolVar(&c.isFiltered, "filter", false,
+		"apply NTSC composite filter, palette is not used")
+	flag.DurationVar(&c.autosave, "autosave", 0,
+		"battery save interval, 0 saves only on exit")
+	flag.BoolVar(&c.noLimit, "nospritelimit", false,
+		"draw all sprites on scanline to remove flicker")
+	flag.IntVar(&c.track, "track", startTrack,
+		"NSF track from 1, 0 plays start track")
+	flag.DurationVar(&c.audio.Length, "length", c.audio.Length,
+		"NSF track length without NSFe duration")
+	flag.DurationVar(&c.audio.Fade, "fade", c.audio.Fade,
+		"NSF fade out without NSFe duration")
+	flag.StringVar(&c.wav, "wav", "",
+		"NSF output, .wav next to input by default")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(),
+			"usage: nes [flags] rom|nsf")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	c.rom = flag.Arg(0)
+	return c
+}
+
+// run emulates given number of frames without display,
+// battery save is stored on exit even if run fails
+func run(c config) error {
+	if c.rom == "" {
+		return errMissingROM
+	}
+	if isNSF(c.rom) {
+		return playNSF(c)
+	}
+	n, err := newConsole(c)
+	if err != nil {
+		return err
+	}
+	err = emulate(c, n)
+	if closeErr := n.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func emulate(c config, n console.Console) error {
+	p, err := loadPalette(c.palette)
+	if err != nil {
+		return err
+	}
+	if c.screenshot != "" {
+		c.options.Format, err = screenshot.GetFormat(c.screenshot)
+		if err != nil {
+			return err
+		}
+	}
+	for i := 0; i < c.frames; i++ {
+		if err := n.StepFrame(); err != nil {
+			return err
+		}
+	}
+	if c.screenshot == "" {
+		return nil
+	}
+	return saveScreenshot(c, n.GetFrame(), p)
+}
+
+// save file is kept next to ROM with .sav extension,
+// patch with the same name as ROM is applied
+func newConsole(c config) (console.Console, error) {
+	data, err := patch.LoadFile(c.rom)
+	if err != nil {
+		return nil, err
+	}
+	cart, err := loadCartridge(data, c.database)
+	if err != nil {
+		return nil, err
+	}
+	region, err := getRegion(c.region, cart.Region)
+	if err != nil {
+		return nil, err
+	}
+	m, err := mapper.New(cart)
+	if err != nil {
+		return nil, err
+	}
+	return console.NewMapperConsole(m, console.Options{
+		Region:        region,
+		Storage:       save.NewFileStorage(filepath.Dir(c.rom)),
+		SaveName:      getSaveName(c.rom),
+		Autosave:      c.autosave,
+		NoSpriteLimit: c.noLimit,
+	})
+}
+
+// header fixes from database are reported on stderr
+func loadCartridge(data []byte,
+	database string) (*cartridge.Cartridge, error) {
+	d, err := loadDatabase(database)
+	if err != nil {
+		return nil, err
+	}
+	cart, fixes, err := cartridge.LoadWithDatabase(data, d)
+	for _, fix := range fixes {
+		fmt.Fprintln(os.Stderr, fix)
+	}
+	return cart, err
+}
+
+func loadDatabase(name string) (*cartridge.Database, error) {
+	if name == "" {
+		return cartridge.DefaultDatabase, nil
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return cartridge.LoadDatabase(file)
+}
+
+func getRegion(name string,
+	auto nes.Region) (nes.Region, error) {
+	if name == autoRegion {
+		return auto, nil
+	}
+	region, ok := regions[name]
+	if !ok {
+		return 0, fmt.Errorf(unknownRegionFormat, name)
+	}
+	return region, nil
+}
+
+func isNSF(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, e := range nsfExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// player mode renders one track to WAV file
+func playNSF(c config) error {
+	data, err := os.ReadFile(c.rom)
+	if err != nil {
+		return err
+	}
+	n, err := nsf.Load(data)
+	if err != nil {
+		return err
+	}
+	if n.Region, err = getRegion(c.region, n.Region); err != nil {
+		return err
+	}
+	song := n.StartSong
+	if c.track != startTrack {
+		song = c.track - 1
+	}
+	name := c.wav
+	if name == "" {
+		name = strings.TrimSuffix(c.rom, filepath.Ext(c.rom)) +
+			wavExtension
+	}
+	return writeWAV(name, n, song, c.audio)
+}
+
+func writeWAV(name string, n *nsf.NSF, song int,
+	o nsf.Options) error {
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := nsf.WriteWAV(file, n, song, o); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func getSaveName(rom string) string {
+	name := filepath.Base(rom)
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+func loadPalette(name string) (*ppu.Palette, error) {
+	if name == "" {
+		return ppu.DefaultPalette(), nil
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return ppu.LoadPalette(data)
+}
+
+func saveScreenshot(c config, f *ppu.Frame,
+	p *ppu.Palette) error {
+	file, err := os.Create(c.screenshot)
+	if err != nil {
+		return err
+	}
+	if err := writeScreenshot(file, c, f, p); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func writeScreenshot(w io.Writer, c config, f *ppu.Frame,
+	p *ppu.Palette) error {
+	if !c.isFiltered {
+		return screenshot.Write(w, f, p, c.options)
+	}
+	filtered := ppu.NewFilter(ppu.DefaultFilter).Apply(f)
+	return screenshot.WriteFiltered(w, filtered, c.options)
+}
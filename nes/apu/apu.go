@@ -0,0 +1,235 @@
+package apu
+
+import "github.com/smarkuck/nes/nes"
+
+const (
+	pulse1Addr   = 0x4000
+	pulse2Addr   = 0x4004
+	triangleAddr = 0x4008
+	noiseAddr    = 0x400c
+	dmcAddr      = 0x4010
+	statusAddr   = 0x4015
+	frameAddr    = 0x4017
+	registerMask = 0x03
+
+	pulse1Bit   = 0x01
+	pulse2Bit   = 0x02
+	triangleBit = 0x04
+	noiseBit    = 0x08
+	dmcBit      = 0x10
+	frameIRQBit = 0x40
+	dmcIRQBit   = 0x80
+
+	fiveStepMode = 0x80
+	irqInhibit   = 0x40
+)
+
+var (
+	ntscNoisePeriods = []uint16{
+		4, 8, 16, 32, 64, 96, 128, 160,
+		202, 254, 380, 508, 762, 1016, 2034, 4068,
+	}
+	ntscDMCRates = []uint16{
+		428, 380, 340, 320, 286, 254, 226, 214,
+		190, 160, 142, 128, 106, 84, 72, 54,
+	}
+	// frame sequencer steps in CPU cycles, last step
+	// restarts the sequence
+	ntscFourStep = []int{7457, 14913, 22371, 29829}
+	ntscFiveStep = []int{7457, 14913, 22371, 37281}
+
+	palNoisePeriods = []uint16{
+		4, 8, 14, 30, 60, 88, 118, 148,
+		188, 236, 354, 472, 708, 944, 1890, 3778,
+	}
+	palDMCRates = []uint16{
+		398, 354, 316, 298, 276, 236, 210, 198,
+		176, 148, 132, 118, 98, 78, 66, 50,
+	}
+	palFourStep = []int{8313, 16627, 24939, 33253}
+	palFiveStep = []int{8313, 16627, 24939, 41565}
+)
+
+type periodTables struct {
+	noise    []uint16
+	dmc      []uint16
+	fourStep []int
+	fiveStep []int
+}
+
+// Dendy APU is clocked like NTSC one, so it shares
+// its tables
+var regionTables = map[nes.Region]periodTables{
+	nes.NTSC: {ntscNoisePeriods, ntscDMCRates,
+		ntscFourStep, ntscFiveStep},
+	nes.PAL: {palNoisePeriods, palDMCRates,
+		palFourStep, palFiveStep},
+	nes.Dendy: {ntscNoisePeriods, ntscDMCRates,
+		ntscFourStep, ntscFiveStep},
+}
+
+type APU interface {
+	nes.Bus
+	nes.IRQSource
+	Tick()
+	GetChannels() Channels
+}
+
+type apu struct {
+	pulse1       pulse
+	pulse2       pulse
+	triangle     triangle
+	noise        noise
+	dmc          dmc
+	fourStep     []int
+	fiveStep     []int
+	frameCycle   int
+	isFiveStep   bool
+	isIRQInhibit bool
+	isFrameIRQ   bool
+	isOddCycle   bool
+}
+
+// NewAPU takes memory used by DMC to fetch samples
+func NewAPU(memory nes.Bus, region nes.Region) APU {
+	t := regionTables[region]
+	return &apu{
+		pulse1:   pulse{isFirst: true},
+		noise:    newNoise(t.noise),
+		dmc:      newDMC(memory, t.dmc),
+		fourStep: t.fourStep,
+		fiveStep: t.fiveStep,
+	}
+}
+
+func (a *apu) Read(addr uint16) byte {
+	if addr != statusAddr {
+		return 0
+	}
+	value := a.getStatus()
+	a.isFrameIRQ = false
+	return value
+}
+
+func (a *apu) getStatus() byte {
+	return getFlag(a.pulse1.length > 0, pulse1Bit) |
+		getFlag(a.pulse2.length > 0, pulse2Bit) |
+		getFlag(a.triangle.length > 0, triangleBit) |
+		getFlag(a.noise.length > 0, noiseBit) |
+		getFlag(a.dmc.remaining > 0, dmcBit) |
+		getFlag(a.isFrameIRQ, frameIRQBit) |
+		getFlag(a.dmc.isIRQ, dmcIRQBit)
+}
+
+func getFlag(isSet bool, bit byte) byte {
+	if isSet {
+		return bit
+	}
+	return 0
+}
+
+func (a *apu) Write(addr uint16, value byte) {
+	register := addr & registerMask
+	switch {
+	case addr < pulse2Addr:
+		a.pulse1.write(register, value)
+	case addr < triangleAddr:
+		a.pulse2.write(register, value)
+	case addr < noiseAddr:
+		a.triangle.write(register, value)
+	case addr < dmcAddr:
+		a.noise.write(register, value)
+	case addr < dmcAddr+4:
+		a.dmc.write(register, value)
+	case addr == statusAddr:
+		a.writeStatus(value)
+	case addr == frameAddr:
+		a.writeFrameCounter(value)
+	}
+}
+
+func (a *apu) writeStatus(value byte) {
+	a.pulse1.setEnabled(value&pulse1Bit != 0)
+	a.pulse2.setEnabled(value&pulse2Bit != 0)
+	a.triangle.setEnabled(value&triangleBit != 0)
+	a.noise.setEnabled(value&noiseBit != 0)
+	a.dmc.setEnabled(value&dmcBit != 0)
+}
+
+// five step mode clocks all units immediately
+func (a *apu) writeFrameCounter(value byte) {
+	a.isFiveStep = value&fiveStepMode != 0
+	a.isIRQInhibit = value&irqInhibit != 0
+	if a.isIRQInhibit {
+		a.isFrameIRQ = false
+	}
+	a.frameCycle = 0
+	if a.isFiveStep {
+		a.clockQuarterFrame()
+		a.clockHalfFrame()
+	}
+}
+
+func (a *apu) Tick() {
+	a.clockFrameCounter()
+	if a.isOddCycle {
+		a.pulse1.clockTimer()
+		a.pulse2.clockTimer()
+	}
+	a.isOddCycle = !a.isOddCycle
+	a.triangle.clockTimer()
+	a.noise.clockTimer()
+	a.dmc.clockTimer()
+}
+
+func (a *apu) clockFrameCounter() {
+	a.frameCycle++
+	steps := a.fourStep
+	if a.isFiveStep {
+		steps = a.fiveStep
+	}
+	switch a.frameCycle {
+	case steps[0], steps[2]:
+		a.clockQuarterFrame()
+	case steps[1]:
+		a.clockQuarterFrame()
+		a.clockHalfFrame()
+	case steps[3]:
+		a.clockQuarterFrame()
+		a.clockHalfFrame()
+		a.frameCycle = 0
+		if !a.isFiveStep && !a.isIRQInhibit {
+			a.isFrameIRQ = true
+		}
+	}
+}
+
+func (a *apu) clockQuarterFrame() {
+	a.pulse1.envelope.clock()
+	a.pulse2.envelope.clock()
+	a.triangle.clockLinear()
+	a.noise.envelope.clock()
+}
+
+func (a *apu) clockHalfFrame() {
+	a.pulse1.lengthCounter.clock()
+	a.pulse2.lengthCounter.clock()
+	a.triangle.lengthCounter.clock()
+	a.noise.lengthCounter.clock()
+	a.pulse1.clockSweep()
+	a.pulse2.clockSweep()
+}
+
+func (a *apu) IsIRQ() bool {
+	return a.isFrameIRQ || a.dmc.isIRQ
+}
+
+func (a *apu) GetChannels() Channels {
+	return Channels{
+		Pulse1:   a.pulse1.getOutput(),
+		Pulse2:   a.pulse2.getOutput(),
+		Triangle: a.triangle.getOutput(),
+		Noise:    a.noise.getOutput(),
+		DMC:      a.dmc.getOutput(),
+	}
+}
@@ -0,0 +1,151 @@
+package apu_test
+
+import (
+	"github.com/smarkuck/nes/nes"
+	. "github.com/smarkuck/nes/nes/apu"
+	. "github.com/smarkuck/unittest"
+)
+
+const (
+	quarterFrame   = 7457
+	halfFrame      = 14913
+	fourStepFrame  = 29829
+	fiveStepFrame  = 37281
+	invalidIRQText = "invalid IRQ"
+
+	invalidStatusText   = "invalid status"
+	invalidChannelsText = "invalid channels"
+)
+
+type memoryBus [0x10000]byte
+
+func (m *memoryBus) Read(addr uint16) byte {
+	return m[addr]
+}
+
+func (m *memoryBus) Write(addr uint16, value byte) {
+	m[addr] = value
+}
+
+func Test_APU_ReportActiveLengthCounters(t *T) {
+	a := NewAPU(new(memoryBus), nes.NTSC)
+	a.Write(0x4015, 0x0f)
+
+	a.Write(0x4003, 0x08)
+	a.Write(0x4007, 0x08)
+	a.Write(0x400b, 0x08)
+	a.Write(0x400f, 0x08)
+
+	ExpectEq(t, a.Read(0x4015), 0x0f, invalidStatusText)
+
+	a.Write(0x4015, 0x05)
+	ExpectEq(t, a.Read(0x4015), 0x05, invalidStatusText)
+}
+
+func Test_APU_IgnoreLengthWhenChannelDisabled(t *T) {
+	a := NewAPU(new(memoryBus), nes.NTSC)
+
+	a.Write(0x4003, 0x08)
+
+	ExpectEq(t, a.Read(0x4015), 0x00, invalidStatusText)
+}
+
+func Test_APU_ClockLengthCounterEveryHalfFrame(t *T) {
+	a := NewAPU(new(memoryBus), nes.NTSC)
+	a.Write(0x4015, 0x01)
+	a.Write(0x4003, 0x18)
+
+	tick(a, halfFrame-1)
+	ExpectEq(t, a.Read(0x4015)&0x01, 0x01, invalidStatusText)
+	tick(a, fourStepFrame-halfFrame+1)
+	ExpectEq(t, a.Read(0x4015)&0x01, 0x00, invalidStatusText)
+}
+
+func Test_APU_HaltLengthCounter(t *T) {
+	a := NewAPU(new(memoryBus), nes.NTSC)
+	a.Write(0x4015, 0x01)
+	a.Write(0x4000, 0x20)
+	a.Write(0x4003, 0x18)
+
+	tick(a, 2*fourStepFrame)
+
+	ExpectEq(t, a.Read(0x4015)&0x01, 0x01, invalidStatusText)
+}
+
+func Test_APU_FrameIRQ(t *T) {
+	a := NewAPU(new(memoryBus), nes.NTSC)
+
+	tick(a, fourStepFrame-1)
+	ExpectFalse(t, a.IsIRQ(), invalidIRQText)
+	tick(a, 1)
+	ExpectTrue(t, a.IsIRQ(), invalidIRQText)
+
+	ExpectEq(t, a.Read(0x4015), 0x40, invalidStatusText)
+	ExpectFalse(t, a.IsIRQ(), invalidIRQText)
+}
+
+func Test_APU_FrameIRQ_Region(t *T) {
+	tests := []struct {
+		region nes.Region
+		frame  int
+	}{
+		{nes.NTSC, fourStepFrame},
+		{nes.PAL, 33253},
+		{nes.Dendy, fourStepFrame},
+	}
+
+	for _, test := range tests {
+		t.Run(test.region.String(), func(t *T) {
+			a := NewAPU(new(memoryBus), test.region)
+
+			tick(a, test.frame-1)
+			ExpectFalse(t, a.IsIRQ(), invalidIRQText)
+			tick(a, 1)
+			ExpectTrue(t, a.IsIRQ(), invalidIRQText)
+		})
+	}
+}
+
+func Test_APU_NoFrameIRQ(t *T) {
+	tests := map[string]byte{
+		"inhibited": 0x40,
+		"five step": 0x80,
+	}
+
+	for name, value := range tests {
+		t.Run(name, func(t *T) {
+			a := NewAPU(new(memoryBus), nes.NTSC)
+			a.Write(0x4017, value)
+
+			tick(a, fiveStepFrame)
+
+			ExpectFalse(t, a.IsIRQ(), invalidIRQText)
+		})
+	}
+}
+
+func Test_APU_InhibitClearsFrameIRQ(t *T) {
+	a := NewAPU(new(memoryBus), nes.NTSC)
+	tick(a, fourStepFrame)
+
+	a.Write(0x4017, 0x40)
+
+	ExpectFalse(t, a.IsIRQ(), invalidIRQText)
+}
+
+func Test_APU_FiveStepModeClocksImmediately(t *T) {
+	a := NewAPU(new(memoryBus), nes.NTSC)
+	a.Write(0x4015, 0x01)
+	a.Write(0x4003, 0x18)
+
+	a.Write(0x4017, 0x80)
+	a.Write(0x4017, 0x80)
+
+	ExpectEq(t, a.Read(0x4015)&0x01, 0x00, invalidStatusText)
+}
+
+func tick(a APU, cycles int) {
+	for i := 0; i < cycles; i++ {
+		a.Tick()
+	}
+}
@@ -0,0 +1,124 @@
+package apu
+
+import "github.com/smarkuck/nes/nes"
+
+const (
+	dmcIRQEnabled = 0x80
+	dmcLoop       = 0x40
+	dmcRate       = 0x0f
+	dmcLevelMask  = 0x7f
+	dmcMaxLevel   = 125
+
+	sampleBase       = 0xc000
+	sampleAddrUnit   = 64
+	sampleLengthUnit = 16
+	sampleWrapAddr   = 0x8000
+)
+
+type dmc struct {
+	memory        nes.Bus
+	rates         []uint16
+	rate          uint16
+	timer         uint16
+	level         byte
+	sampleAddr    uint16
+	sampleLength  uint16
+	address       uint16
+	remaining     uint16
+	buffer        byte
+	shift         byte
+	bitsRemaining byte
+	isBufferEmpty bool
+	isSilent      bool
+	isLooped      bool
+	isIRQEnabled  bool
+	isIRQ         bool
+}
+
+func newDMC(memory nes.Bus, rates []uint16) dmc {
+	return dmc{memory: memory, rates: rates, rate: rates[0],
+		bitsRemaining: 8, isBufferEmpty: true, isSilent: true}
+}
+
+func (d *dmc) write(register uint16, value byte) {
+	switch register {
+	case 0:
+		d.isIRQEnabled = value&dmcIRQEnabled != 0
+		d.isLooped = value&dmcLoop != 0
+		d.rate = d.rates[value&dmcRate]
+		if !d.isIRQEnabled {
+			d.isIRQ = false
+		}
+	case 1:
+		d.level = value & dmcLevelMask
+	case 2:
+		d.sampleAddr = sampleBase + uint16(value)*sampleAddrUnit
+	case 3:
+		d.sampleLength = uint16(value)*sampleLengthUnit + 1
+	}
+}
+
+func (d *dmc) setEnabled(isEnabled bool) {
+	d.isIRQ = false
+	if !isEnabled {
+		d.remaining = 0
+	} else if d.remaining == 0 {
+		d.restart()
+	}
+}
+
+func (d *dmc) restart() {
+	d.address = d.sampleAddr
+	d.remaining = d.sampleLength
+}
+
+func (d *dmc) clockTimer() {
+	d.fillBuffer()
+	if d.timer > 0 {
+		d.timer--
+		return
+	}
+	d.timer = d.rate - 1
+	d.clockOutput()
+}
+
+func (d *dmc) fillBuffer() {
+	if !d.isBufferEmpty || d.remaining == 0 {
+		return
+	}
+	d.buffer = d.memory.Read(d.address)
+	d.isBufferEmpty = false
+	if d.address++; d.address == 0 {
+		d.address = sampleWrapAddr
+	}
+	if d.remaining--; d.remaining > 0 {
+		return
+	}
+	if d.isLooped {
+		d.restart()
+	} else if d.isIRQEnabled {
+		d.isIRQ = true
+	}
+}
+
+func (d *dmc) clockOutput() {
+	if !d.isSilent {
+		if d.shift&0x01 != 0 && d.level <= dmcMaxLevel {
+			d.level += 2
+		} else if d.shift&0x01 == 0 && d.level >= 2 {
+			d.level -= 2
+		}
+	}
+	d.shift >>= 1
+	if d.bitsRemaining--; d.bitsRemaining > 0 {
+		return
+	}
+	d.bitsRemaining = 8
+	d.isSilent = d.isBufferEmpty
+	d.shift = d.buffer
+	d.isBufferEmpty = true
+}
+
+func (d *dmc) getOutput() byte {
+	return d.level
+}
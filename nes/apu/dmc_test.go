@@ -0,0 +1,81 @@
+package apu_test
+
+import (
+	"github.com/smarkuck/nes/nes"
+	. "github.com/smarkuck/nes/nes/apu"
+	. "github.com/smarkuck/unittest"
+)
+
+func Test_DMC_LoadLevelDirectly(t *T) {
+	a := NewAPU(new(memoryBus), nes.NTSC)
+
+	a.Write(0x4011, 0xc5)
+
+	ExpectEq(t, a.GetChannels().DMC, 0x45, invalidChannelsText)
+}
+
+func Test_DMC_PlaySample(t *T) {
+	tests := map[string]struct {
+		sample byte
+		level  byte
+	}{
+		"up":   {0xff, 80},
+		"down": {0x00, 48},
+		"mix":  {0x0f, 64},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *T) {
+			m := new(memoryBus)
+			m[0xc040] = test.sample
+			a := newDMCAPU(m, 0x0f)
+
+			tick(a, 1000)
+
+			ExpectEq(t, a.GetChannels().DMC, test.level,
+				invalidChannelsText)
+			ExpectEq(t, a.Read(0x4015), 0x00, invalidStatusText)
+		})
+	}
+}
+
+func Test_DMC_ClampLevel(t *T) {
+	m := new(memoryBus)
+	m[0xc040] = 0xff
+	a := newDMCAPU(m, 0x0f)
+	a.Write(0x4011, 0x7e)
+
+	tick(a, 1000)
+
+	ExpectEq(t, a.GetChannels().DMC, 0x7e, invalidChannelsText)
+}
+
+func Test_DMC_IRQAtSampleEnd(t *T) {
+	a := newDMCAPU(new(memoryBus), 0x8f)
+	ExpectEq(t, a.Read(0x4015), 0x10, invalidStatusText)
+
+	tick(a, 1)
+	ExpectTrue(t, a.IsIRQ(), invalidIRQText)
+	ExpectEq(t, a.Read(0x4015), 0x80, invalidStatusText)
+
+	a.Write(0x4015, 0x00)
+	ExpectFalse(t, a.IsIRQ(), invalidIRQText)
+}
+
+func Test_DMC_LoopSample(t *T) {
+	a := newDMCAPU(new(memoryBus), 0x4f)
+
+	tick(a, 1000)
+
+	ExpectEq(t, a.Read(0x4015), 0x10, invalidStatusText)
+}
+
+func newDMCAPU(m *memoryBus, control byte) APU {
+	a := NewAPU(m, nes.NTSC)
+	a.Write(0x4010, control)
+	a.Write(0x4011, 0x40)
+	a.Write(0x4012, 0x01)
+	a.Write(0x4013, 0x00)
+	a.Write(0x4015, 0x10)
+	return a
+}
@@ -0,0 +1,79 @@
+package apu
+
+const (
+	lengthHalt     = 0x20
+	constantVolume = 0x10
+	volumeMask     = 0x0f
+	lengthShift    = 3
+	maxDecay       = 15
+)
+
+var lengthTable = [...]byte{
+	10, 254, 20, 2, 40, 4, 80, 6, 160, 8, 60, 10, 14, 12, 26, 14,
+	12, 16, 24, 18, 48, 20, 96, 22, 192, 24, 72, 26, 16, 28, 32, 30,
+}
+
+type lengthCounter struct {
+	length    byte
+	isHalted  bool
+	isEnabled bool
+}
+
+func (l *lengthCounter) load(value byte) {
+	if l.isEnabled {
+		l.length = lengthTable[value>>lengthShift]
+	}
+}
+
+func (l *lengthCounter) setEnabled(isEnabled bool) {
+	l.isEnabled = isEnabled
+	if !isEnabled {
+		l.length = 0
+	}
+}
+
+func (l *lengthCounter) clock() {
+	if !l.isHalted && l.length > 0 {
+		l.length--
+	}
+}
+
+type envelope struct {
+	volume     byte
+	decay      byte
+	divider    byte
+	isConstant bool
+	isLooped   bool
+	isStarted  bool
+}
+
+func (e *envelope) write(value byte) {
+	e.volume = value & volumeMask
+	e.isConstant = value&constantVolume != 0
+	e.isLooped = value&lengthHalt != 0
+}
+
+func (e *envelope) clock() {
+	switch {
+	case e.isStarted:
+		e.isStarted = false
+		e.decay = maxDecay
+		e.divider = e.volume
+	case e.divider > 0:
+		e.divider--
+	default:
+		e.divider = e.volume
+		if e.decay > 0 {
+			e.decay--
+		} else if e.isLooped {
+			e.decay = maxDecay
+		}
+	}
+}
+
+func (e *envelope) getVolume() byte {
+	if e.isConstant {
+		return e.volume
+	}
+	return e.decay
+}
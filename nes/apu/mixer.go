@@ -0,0 +1,73 @@
+package apu
+
+import "github.com/smarkuck/nes/nes"
+
+const (
+	pulseNumerator   = 95.88
+	pulseDenominator = 8128
+	tndNumerator     = 159.79
+	tndDenominator   = 1
+	triangleWeight   = 8227
+	noiseWeight      = 12241
+	dmcWeight        = 22638
+	mixOffset        = 100
+)
+
+type Channels struct {
+	Pulse1   byte
+	Pulse2   byte
+	Triangle byte
+	Noise    byte
+	DMC      byte
+}
+
+type Mixer interface {
+	AddSource(nes.AudioSource)
+	Mix(Channels) float32
+}
+
+type mixer struct {
+	sources []nes.AudioSource
+}
+
+func NewMixer() Mixer {
+	return new(mixer)
+}
+
+func (m *mixer) AddSource(s nes.AudioSource) {
+	m.sources = append(m.sources, s)
+}
+
+// expansion audio from cartridge is added linearly
+// to nonlinear mix of internal channels
+func (m *mixer) Mix(c Channels) float32 {
+	output := mixPulses(c) + mixTND(c)
+	for _, s := range m.sources {
+		output += s.GetAudioOutput()
+	}
+	return output
+}
+
+func mixPulses(c Channels) float32 {
+	return MixPulses(c.Pulse1, c.Pulse2)
+}
+
+// MixPulses is nonlinear mix of two pulse outputs, used
+// also by expansion chips with pulse channels
+func MixPulses(pulse1, pulse2 byte) float32 {
+	sum := float32(pulse1) + float32(pulse2)
+	if sum == 0 {
+		return 0
+	}
+	return pulseNumerator / (pulseDenominator/sum + mixOffset)
+}
+
+func mixTND(c Channels) float32 {
+	sum := float32(c.Triangle)/triangleWeight +
+		float32(c.Noise)/noiseWeight +
+		float32(c.DMC)/dmcWeight
+	if sum == 0 {
+		return 0
+	}
+	return tndNumerator / (tndDenominator/sum + mixOffset)
+}
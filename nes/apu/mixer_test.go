@@ -0,0 +1,58 @@
+package apu_test
+
+import (
+	. "github.com/smarkuck/nes/nes/apu"
+	. "github.com/smarkuck/unittest"
+)
+
+const invalidOutputText = "invalid mixer output"
+
+type audioSource float32
+
+func (a audioSource) GetAudioOutput() float32 {
+	return float32(a)
+}
+
+func expectOutputNear(t *T, actual, expected float32) {
+	t.Helper()
+	diff := actual - expected
+	ExpectTrue(t, diff < 0.0001 && diff > -0.0001,
+		invalidOutputText)
+}
+
+func Test_Mixer_WithSilentChannels_OutputZero(t *T) {
+	ExpectEq(t, NewMixer().Mix(Channels{}), 0, invalidOutputText)
+}
+
+func Test_Mixer_MixChannels(t *T) {
+	tests := []struct {
+		name     string
+		channels Channels
+		output   float32
+	}{
+		{"Pulse", Channels{Pulse1: 15}, 0.14938},
+		{"BothPulses", Channels{Pulse1: 15, Pulse2: 15}, 0.25848},
+		{"Triangle", Channels{Triangle: 15}, 0.24641},
+		{"Noise", Channels{Noise: 15}, 0.17443},
+		{"DMC", Channels{DMC: 127}, 0.57426},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			output := NewMixer().Mix(test.channels)
+			expectOutputNear(t, output, test.output)
+		})
+	}
+}
+
+func Test_MixPulses(t *T) {
+	expectOutputNear(t, MixPulses(15, 15), 0.25848)
+}
+
+func Test_Mixer_AddExpansionAudio(t *T) {
+	m := NewMixer()
+	m.AddSource(audioSource(0.25))
+	m.AddSource(audioSource(0.5))
+
+	ExpectEq(t, m.Mix(Channels{}), 0.75, invalidOutputText)
+}
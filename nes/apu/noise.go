@@ -0,0 +1,58 @@
+package apu
+
+const (
+	noiseMode   = 0x80
+	noisePeriod = 0x0f
+	modeTap     = 6
+	normalTap   = 1
+	feedbackBit = 14
+)
+
+type noise struct {
+	envelope
+	lengthCounter
+	periods []uint16
+	period  uint16
+	timer   uint16
+	shift   uint16
+	isShort bool
+}
+
+func newNoise(periods []uint16) noise {
+	return noise{periods: periods, shift: 1}
+}
+
+func (n *noise) write(register uint16, value byte) {
+	switch register {
+	case 0:
+		n.isHalted = value&lengthHalt != 0
+		n.envelope.write(value)
+	case 2:
+		n.isShort = value&noiseMode != 0
+		n.period = n.periods[value&noisePeriod]
+	case 3:
+		n.load(value)
+		n.isStarted = true
+	}
+}
+
+func (n *noise) clockTimer() {
+	if n.timer > 0 {
+		n.timer--
+		return
+	}
+	n.timer = n.period - 1
+	tap := uint16(normalTap)
+	if n.isShort {
+		tap = modeTap
+	}
+	feedback := (n.shift ^ n.shift>>tap) & 0x01
+	n.shift = n.shift>>1 | feedback<<feedbackBit
+}
+
+func (n *noise) getOutput() byte {
+	if n.shift&0x01 != 0 || n.length == 0 {
+		return 0
+	}
+	return n.getVolume()
+}
@@ -0,0 +1,76 @@
+package apu_test
+
+import (
+	"github.com/smarkuck/nes/nes"
+	. "github.com/smarkuck/nes/nes/apu"
+	. "github.com/smarkuck/unittest"
+)
+
+func Test_Noise_OutputShiftRegister(t *T) {
+	a := newNoiseAPU(0x00)
+
+	ExpectEq(t, a.GetChannels().Noise, 0, invalidChannelsText)
+	tick(a, 1)
+	ExpectEq(t, a.GetChannels().Noise, 15, invalidChannelsText)
+}
+
+func Test_Noise_ShortModeRepeatsEarlier(t *T) {
+	tests := map[string]struct {
+		mode   byte
+		period int
+	}{
+		"long":  {0x00, 32767},
+		"short": {0x80, 93},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *T) {
+			a := newNoiseAPU(test.mode)
+
+			ExpectEq(t, sequencePeriod(a, 70000), test.period)
+		})
+	}
+}
+
+func Test_Noise_SilentWithoutLength(t *T) {
+	a := NewAPU(new(memoryBus), nes.NTSC)
+	a.Write(0x400c, 0x3f)
+
+	tick(a, 10)
+
+	ExpectEq(t, a.GetChannels().Noise, 0, invalidChannelsText)
+}
+
+func newNoiseAPU(mode byte) APU {
+	a := NewAPU(new(memoryBus), nes.NTSC)
+	a.Write(0x4015, 0x08)
+	a.Write(0x400c, 0x3f)
+	a.Write(0x400e, mode)
+	a.Write(0x400f, 0x08)
+	return a
+}
+
+// finds shortest period of output sampled every timer step
+func sequencePeriod(a APU, steps int) int {
+	const period = 4
+	output := make([]byte, steps)
+	for i := range output {
+		tick(a, period)
+		output[i] = a.GetChannels().Noise
+	}
+	for p := 1; p < steps/2; p++ {
+		if isPeriodic(output, p) {
+			return p
+		}
+	}
+	return 0
+}
+
+func isPeriodic(output []byte, p int) bool {
+	for i := p; i < len(output); i++ {
+		if output[i] != output[i-p] {
+			return false
+		}
+	}
+	return true
+}
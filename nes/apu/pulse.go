@@ -0,0 +1,165 @@
+package apu
+
+const (
+	dutyShift     = 6
+	sweepEnabled  = 0x80
+	sweepPeriod   = 0x70
+	sweepNegate   = 0x08
+	sweepShift    = 0x07
+	periodShift   = 4
+	timerHiMask   = 0x07
+	minPulsePer   = 8
+	maxPulsePer   = 0x7ff
+	sequenceSteps = 8
+)
+
+var dutyTable = [4][sequenceSteps]byte{
+	{0, 1, 0, 0, 0, 0, 0, 0},
+	{0, 1, 1, 0, 0, 0, 0, 0},
+	{0, 1, 1, 1, 1, 0, 0, 0},
+	{1, 0, 0, 1, 1, 1, 1, 1},
+}
+
+type pulse struct {
+	envelope
+	lengthCounter
+	duty         byte
+	step         byte
+	period       uint16
+	timer        uint16
+	sweepDivider byte
+	sweepPeriod  byte
+	sweepShift   byte
+	isSweeping   bool
+	isNegated    bool
+	isReloaded   bool
+	// first pulse negates with one's complement
+	isFirst bool
+	// expansion pulses have no sweep unit and never mute
+	isExpansion bool
+}
+
+// Pulse is channel of expansion chips like MMC5, its
+// envelope and length counter are clocked by the chip
+type Pulse interface {
+	Write(register uint16, value byte)
+	SetEnabled(isEnabled bool)
+	ClockTimer()
+	ClockQuarterFrame()
+	ClockHalfFrame()
+	IsPlaying() bool
+	GetOutput() byte
+}
+
+type expansionPulse struct {
+	pulse
+}
+
+func NewPulse() Pulse {
+	return &expansionPulse{pulse{isExpansion: true}}
+}
+
+func (p *expansionPulse) Write(register uint16, value byte) {
+	p.write(register, value)
+}
+
+func (p *expansionPulse) SetEnabled(isEnabled bool) {
+	p.setEnabled(isEnabled)
+}
+
+func (p *expansionPulse) ClockTimer() {
+	p.clockTimer()
+}
+
+func (p *expansionPulse) ClockQuarterFrame() {
+	p.envelope.clock()
+}
+
+func (p *expansionPulse) ClockHalfFrame() {
+	p.lengthCounter.clock()
+}
+
+func (p *expansionPulse) IsPlaying() bool {
+	return p.length > 0
+}
+
+func (p *expansionPulse) GetOutput() byte {
+	return p.getOutput()
+}
+
+func (p *pulse) write(register uint16, value byte) {
+	switch register {
+	case 0:
+		p.duty = value >> dutyShift
+		p.isHalted = value&lengthHalt != 0
+		p.envelope.write(value)
+	case 1:
+		if p.isExpansion {
+			return
+		}
+		p.isSweeping = value&sweepEnabled != 0
+		p.sweepPeriod = value & sweepPeriod >> periodShift
+		p.isNegated = value&sweepNegate != 0
+		p.sweepShift = value & sweepShift
+		p.isReloaded = true
+	case 2:
+		p.period = p.period&0x0700 | uint16(value)
+	case 3:
+		p.period = p.period&0x00ff | uint16(value&timerHiMask)<<8
+		p.load(value)
+		p.step = 0
+		p.isStarted = true
+	}
+}
+
+func (p *pulse) clockTimer() {
+	if p.timer > 0 {
+		p.timer--
+		return
+	}
+	p.timer = p.period
+	p.step = (p.step + 1) % sequenceSteps
+}
+
+func (p *pulse) clockSweep() {
+	if p.sweepDivider == 0 && p.isSweeping &&
+		p.sweepShift > 0 && !p.isMuted() {
+		p.period = p.getTargetPeriod()
+	}
+	if p.sweepDivider == 0 || p.isReloaded {
+		p.sweepDivider = p.sweepPeriod
+		p.isReloaded = false
+	} else {
+		p.sweepDivider--
+	}
+}
+
+func (p *pulse) getTargetPeriod() uint16 {
+	change := p.period >> p.sweepShift
+	if !p.isNegated {
+		return p.period + change
+	}
+	if p.isFirst {
+		change++
+	}
+	if change > p.period {
+		return 0
+	}
+	return p.period - change
+}
+
+func (p *pulse) isMuted() bool {
+	if p.isExpansion {
+		return false
+	}
+	return p.period < minPulsePer ||
+		p.getTargetPeriod() > maxPulsePer
+}
+
+func (p *pulse) getOutput() byte {
+	if p.isMuted() || p.length == 0 ||
+		dutyTable[p.duty][p.step] == 0 {
+		return 0
+	}
+	return p.getVolume()
+}
@@ -0,0 +1,156 @@
+package apu_test
+
+import (
+	"github.com/smarkuck/nes/nes"
+	. "github.com/smarkuck/nes/nes/apu"
+	. "github.com/smarkuck/unittest"
+)
+
+func Test_Pulse_OutputDutyCycle(t *T) {
+	a := newPulseAPU(0x30|0x0f, 0x10, 0x08)
+
+	ExpectEq(t, a.GetChannels().Pulse1, 0, invalidChannelsText)
+	tick(a, 2)
+	ExpectEq(t, a.GetChannels().Pulse1, 15, invalidChannelsText)
+	tick(a, 33)
+	ExpectEq(t, a.GetChannels().Pulse1, 15, invalidChannelsText)
+	tick(a, 1)
+	ExpectEq(t, a.GetChannels().Pulse1, 0, invalidChannelsText)
+}
+
+func Test_Pulse_SecondChannel(t *T) {
+	a := NewAPU(new(memoryBus), nes.NTSC)
+	a.Write(0x4015, 0x02)
+	a.Write(0x4004, 0xb7)
+	a.Write(0x4006, 0x10)
+	a.Write(0x4007, 0x08)
+
+	tick(a, 2)
+
+	ExpectEq(t, a.GetChannels().Pulse2, 7, invalidChannelsText)
+}
+
+func Test_Pulse_MuteOnInvalidPeriod(t *T) {
+	tests := map[string]struct {
+		sweep, periodLo, periodHi byte
+	}{
+		"too low":      {0x00, 0x07, 0x08},
+		"sweep target": {0x01, 0x00, 0x0e},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *T) {
+			a := newPulseAPU(0xff, test.periodLo, test.periodHi)
+			a.Write(0x4001, test.sweep)
+
+			for i := 0; i < 32; i++ {
+				a.Tick()
+				ExpectEq(t, a.GetChannels().Pulse1, 0,
+					invalidChannelsText)
+			}
+		})
+	}
+}
+
+func Test_Pulse_SweepChangesPeriod(t *T) {
+	tests := map[string]struct {
+		sweep  byte
+		period int
+	}{
+		"increase":      {0x81, 0x180},
+		"pulse1 negate": {0x89, 0x7f},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *T) {
+			a := newPulseAPU(0xbf, 0x00, 0x09)
+			a.Write(0x4001, test.sweep)
+			tick(a, halfFrame)
+
+			ExpectEq(t, ticksPerStep(a), 2*(test.period+1))
+		})
+	}
+}
+
+func Test_Pulse_DecayEnvelope(t *T) {
+	a := newPulseAPU(0x80, 0x08, 0x08)
+
+	tick(a, quarterFrame)
+	ExpectEq(t, maxPulseOutput(a), 15, invalidChannelsText)
+	tick(a, halfFrame-quarterFrame)
+	ExpectEq(t, maxPulseOutput(a), 14, invalidChannelsText)
+}
+
+func Test_Pulse_LoopEnvelope(t *T) {
+	a := newPulseAPU(0xa0, 0x08, 0x08)
+
+	tick(a, 4*fourStepFrame+quarterFrame)
+
+	ExpectEq(t, maxPulseOutput(a), 15, invalidChannelsText)
+}
+
+func newPulseAPU(control, periodLo, periodHi byte) APU {
+	a := NewAPU(new(memoryBus), nes.NTSC)
+	a.Write(0x4015, 0x01)
+	a.Write(0x4000, control)
+	a.Write(0x4002, periodLo)
+	a.Write(0x4003, periodHi)
+	return a
+}
+
+// duty 50% changes output every four steps
+func ticksPerStep(a APU) int {
+	output := a.GetChannels().Pulse1
+	for output == a.GetChannels().Pulse1 {
+		a.Tick()
+	}
+	ticks := 0
+	for output = a.GetChannels().Pulse1; output ==
+		a.GetChannels().Pulse1; ticks++ {
+		a.Tick()
+	}
+	return ticks / 4
+}
+
+// one full duty cycle with period 8 takes 144 cycles
+func maxPulseOutput(a APU) byte {
+	var output byte
+	for i := 0; i < 144; i++ {
+		if p := a.GetChannels().Pulse1; p > output {
+			output = p
+		}
+		a.Tick()
+	}
+	return output
+}
+
+// period would mute APU pulse by sweep target overflow
+func Test_Pulse_ExpansionIgnoresSweep(t *T) {
+	p := NewPulse()
+	p.SetEnabled(true)
+	p.Write(0, 0xbf)
+	p.Write(1, 0x81)
+	p.Write(2, 0x00)
+	p.Write(3, 0x0e)
+	p.ClockHalfFrame()
+
+	output := byte(0)
+	for i := 0; i < 0x1000; i++ {
+		p.ClockTimer()
+		output |= p.GetOutput()
+	}
+	ExpectEq(t, output, 15, invalidChannelsText)
+	ExpectTrue(t, p.IsPlaying(), invalidChannelsText)
+}
+
+func Test_Pulse_ExpansionLengthCounter(t *T) {
+	p := NewPulse()
+	p.SetEnabled(true)
+	p.Write(0, 0x9f)
+	p.Write(3, 0x18)
+
+	p.ClockHalfFrame()
+	ExpectTrue(t, p.IsPlaying(), invalidChannelsText)
+	p.ClockHalfFrame()
+	ExpectFalse(t, p.IsPlaying(), invalidChannelsText)
+}
@@ -0,0 +1,63 @@
+package apu
+
+const (
+	linearControl = 0x80
+	linearMask    = 0x7f
+	triangleSteps = 32
+)
+
+type triangle struct {
+	lengthCounter
+	linear       byte
+	linearReload byte
+	period       uint16
+	timer        uint16
+	step         byte
+	isControlled bool
+	isReloaded   bool
+}
+
+func (t *triangle) write(register uint16, value byte) {
+	switch register {
+	case 0:
+		t.isControlled = value&linearControl != 0
+		t.isHalted = t.isControlled
+		t.linearReload = value & linearMask
+	case 2:
+		t.period = t.period&0x0700 | uint16(value)
+	case 3:
+		t.period = t.period&0x00ff | uint16(value&timerHiMask)<<8
+		t.load(value)
+		t.isReloaded = true
+	}
+}
+
+func (t *triangle) clockTimer() {
+	if t.timer > 0 {
+		t.timer--
+		return
+	}
+	t.timer = t.period
+	if t.linear > 0 && t.length > 0 {
+		t.step = (t.step + 1) % triangleSteps
+	}
+}
+
+func (t *triangle) clockLinear() {
+	if t.isReloaded {
+		t.linear = t.linearReload
+	} else if t.linear > 0 {
+		t.linear--
+	}
+	if !t.isControlled {
+		t.isReloaded = false
+	}
+}
+
+// sequence goes down from 15 to 0 and back up
+func (t *triangle) getOutput() byte {
+	if t.step < triangleSteps/2 {
+		return 15 - t.step
+	}
+	return t.step - triangleSteps/2
+}
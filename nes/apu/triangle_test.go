@@ -0,0 +1,50 @@
+package apu_test
+
+import (
+	"github.com/smarkuck/nes/nes"
+	. "github.com/smarkuck/nes/nes/apu"
+	. "github.com/smarkuck/unittest"
+)
+
+func Test_Triangle_WaitForLinearCounter(t *T) {
+	a := newTriangleAPU(0x81)
+
+	tick(a, quarterFrame-1)
+	ExpectEq(t, a.GetChannels().Triangle, 15, invalidChannelsText)
+	tick(a, 1)
+	ExpectEq(t, a.GetChannels().Triangle, 14, invalidChannelsText)
+}
+
+func Test_Triangle_OutputSequence(t *T) {
+	a := newTriangleAPU(0x81)
+	tick(a, quarterFrame)
+	expected := []byte{13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1,
+		0, 0, 1, 2}
+
+	for _, e := range expected {
+		a.Tick()
+		ExpectEq(t, a.GetChannels().Triangle, e,
+			invalidChannelsText)
+	}
+}
+
+func Test_Triangle_StopWhenLinearCounterExpires(t *T) {
+	a := newTriangleAPU(0x01)
+	tick(a, quarterFrame)
+
+	tick(a, halfFrame-quarterFrame)
+	output := a.GetChannels().Triangle
+	tick(a, 10)
+
+	ExpectEq(t, a.GetChannels().Triangle, output,
+		invalidChannelsText)
+}
+
+func newTriangleAPU(control byte) APU {
+	a := NewAPU(new(memoryBus), nes.NTSC)
+	a.Write(0x4015, 0x04)
+	a.Write(0x4008, control)
+	a.Write(0x400a, 0x00)
+	a.Write(0x400b, 0x08)
+	return a
+}
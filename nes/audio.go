@@ -0,0 +1,5 @@
+package nes
+
+type AudioSource interface {
+	GetAudioOutput() float32
+}
@@ -0,0 +1,49 @@
+package cartridge
+
+import "github.com/smarkuck/nes/nes"
+
+const (
+	PRGBankSize = 0x4000
+	CHRBankSize = 0x2000
+
+	prgRAMUnit = 0x2000
+)
+
+type Mirroring uint8
+
+const (
+	Horizontal Mirroring = iota
+	Vertical
+	SingleScreenA
+	SingleScreenB
+	FourScreen
+	MapperControlled
+)
+
+var mirroringNames = [...]string{
+	"horizontal",
+	"vertical",
+	"single screen A",
+	"single screen B",
+	"four screen",
+	"mapper controlled",
+}
+
+func (m Mirroring) String() string {
+	if int(m) < len(mirroringNames) {
+		return mirroringNames[m]
+	}
+	return "unknown"
+}
+
+type Cartridge struct {
+	PRG        []byte
+	CHR        []byte
+	PRGRAMSize int
+	CHRRAMSize int
+	Mapper     uint16
+	SubMapper  uint8
+	Mirroring
+	Battery bool
+	Region  nes.Region
+}
@@ -0,0 +1,214 @@
+package cartridge
+
+import (
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/smarkuck/nes/nes"
+)
+
+const (
+	invalidDatabaseFormat  = "invalid ROM database: %w"
+	unknownMirroringFormat = "unknown mirroring %q"
+	unknownRegionFormat    = "unknown region %d"
+	correctionFormat       = "%s: %s -> %s"
+)
+
+// embedded file is a curated subset of nes20db.xml, full
+// database can be loaded with LoadDatabase
+//
+//go:embed nes20db.xml
+var embeddedDatabase string
+
+var DefaultDatabase = mustLoadDatabase(embeddedDatabase)
+
+var xmlMirrorings = map[string]Mirroring{
+	"H": Horizontal,
+	"V": Vertical,
+	"1": SingleScreenA,
+	"4": FourScreen,
+}
+
+type Game struct {
+	Mapper     uint16
+	SubMapper  uint8
+	Mirroring  Mirroring
+	Battery    bool
+	PRGRAMSize int
+	CHRRAMSize int
+	Region     nes.Region
+}
+
+type Correction struct {
+	Field  string
+	Header string
+	Fixed  string
+}
+
+func (c Correction) String() string {
+	return fmt.Sprintf(correctionFormat, c.Field, c.Header, c.Fixed)
+}
+
+type Database struct {
+	bySHA1  map[string]Game
+	byCRC32 map[uint32]Game
+}
+
+type xmlDatabase struct {
+	Games []xmlGame `xml:"game"`
+}
+
+type xmlGame struct {
+	ROM      xmlROM     `xml:"rom"`
+	PRGRAM   xmlSize    `xml:"prgram"`
+	PRGNVRAM xmlSize    `xml:"prgnvram"`
+	CHRRAM   xmlSize    `xml:"chrram"`
+	CHRNVRAM xmlSize    `xml:"chrnvram"`
+	Console  xmlConsole `xml:"console"`
+	PCB      xmlPCB     `xml:"pcb"`
+}
+
+type xmlROM struct {
+	CRC32 string `xml:"crc32,attr"`
+	SHA1  string `xml:"sha1,attr"`
+}
+
+type xmlSize struct {
+	Size int `xml:"size,attr"`
+}
+
+type xmlConsole struct {
+	Region int `xml:"region,attr"`
+}
+
+type xmlPCB struct {
+	Mapper    uint16 `xml:"mapper,attr"`
+	SubMapper uint8  `xml:"submapper,attr"`
+	Mirroring string `xml:"mirroring,attr"`
+	Battery   int    `xml:"battery,attr"`
+}
+
+func mustLoadDatabase(data string) *Database {
+	d, err := LoadDatabase(strings.NewReader(data))
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// LoadDatabase reads NES 2.0 XML database, games are
+// keyed by hash of PRG-ROM followed by CHR-ROM
+func LoadDatabase(r io.Reader) (*Database, error) {
+	var x xmlDatabase
+	if err := xml.NewDecoder(r).Decode(&x); err != nil {
+		return nil, fmt.Errorf(invalidDatabaseFormat, err)
+	}
+	d := &Database{
+		bySHA1:  map[string]Game{},
+		byCRC32: map[uint32]Game{},
+	}
+	for _, g := range x.Games {
+		if err := d.add(g); err != nil {
+			return nil, fmt.Errorf(invalidDatabaseFormat, err)
+		}
+	}
+	return d, nil
+}
+
+func (d *Database) add(x xmlGame) error {
+	mirroring, ok := xmlMirrorings[x.PCB.Mirroring]
+	if !ok {
+		return fmt.Errorf(unknownMirroringFormat,
+			x.PCB.Mirroring)
+	}
+	region := x.Console.Region
+	if region < 0 || region >= len(timingRegions) {
+		return fmt.Errorf(unknownRegionFormat, region)
+	}
+	g := Game{
+		Mapper:     x.PCB.Mapper,
+		SubMapper:  x.PCB.SubMapper,
+		Mirroring:  mirroring,
+		Battery:    x.PCB.Battery != 0,
+		PRGRAMSize: x.PRGRAM.Size + x.PRGNVRAM.Size,
+		CHRRAMSize: x.CHRRAM.Size + x.CHRNVRAM.Size,
+		Region:     timingRegions[region],
+	}
+	if x.ROM.SHA1 != "" {
+		d.bySHA1[strings.ToLower(x.ROM.SHA1)] = g
+	}
+	if x.ROM.CRC32 == "" {
+		return nil
+	}
+	crc, err := strconv.ParseUint(x.ROM.CRC32, 16, 32)
+	if err != nil {
+		return err
+	}
+	d.byCRC32[uint32(crc)] = g
+	return nil
+}
+
+// Find looks game up by SHA-1, CRC32 is used only when
+// SHA-1 is not known because it can collide
+func (d *Database) Find(c *Cartridge) (Game, bool) {
+	sha, crc := sha1.New(), crc32.NewIEEE()
+	for _, h := range []io.Writer{sha, crc} {
+		h.Write(c.PRG)
+		h.Write(c.CHR)
+	}
+	key := hex.EncodeToString(sha.Sum(nil))
+	if g, ok := d.bySHA1[key]; ok {
+		return g, true
+	}
+	g, ok := d.byCRC32[crc.Sum32()]
+	return g, ok
+}
+
+// Correct overrides header fields with database values
+// and reports every changed field
+func (d *Database) Correct(c *Cartridge) []Correction {
+	g, ok := d.Find(c)
+	if !ok {
+		return nil
+	}
+	var fixes []Correction
+	fixes = correct(fixes, "mapper", &c.Mapper, g.Mapper)
+	fixes = correct(fixes, "submapper", &c.SubMapper, g.SubMapper)
+	fixes = correct(fixes, "mirroring", &c.Mirroring, g.Mirroring)
+	fixes = correct(fixes, "battery", &c.Battery, g.Battery)
+	fixes = correct(fixes, "PRG-RAM size",
+		&c.PRGRAMSize, g.PRGRAMSize)
+	fixes = correct(fixes, "CHR-RAM size",
+		&c.CHRRAMSize, g.CHRRAMSize)
+	return correct(fixes, "region", &c.Region, g.Region)
+}
+
+func correct[V comparable](fixes []Correction,
+	field string, value *V, fixed V) []Correction {
+	if *value == fixed {
+		return fixes
+	}
+	fixes = append(fixes, Correction{
+		Field:  field,
+		Header: fmt.Sprint(*value),
+		Fixed:  fmt.Sprint(fixed),
+	})
+	*value = fixed
+	return fixes
+}
+
+func LoadWithDatabase(data []byte,
+	d *Database) (*Cartridge, []Correction, error) {
+	c, err := Load(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, d.Correct(c), nil
+}
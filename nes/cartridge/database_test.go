@@ -0,0 +1,171 @@
+package cartridge_test
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"strings"
+
+	"github.com/smarkuck/nes/nes"
+	. "github.com/smarkuck/nes/nes/cartridge"
+	. "github.com/smarkuck/unittest"
+)
+
+const (
+	gameFormat = `<game>
+	<rom size="49152" %s/>
+	<prgrom size="32768"/>
+	<chrrom size="16384"/>
+	<prgnvram size="8192"/>
+	<console type="0" region="1"/>
+	<pcb mapper="4" submapper="1" mirroring="V" battery="1"/>
+</game>`
+
+	invalidCorrectionsText = "invalid corrections"
+)
+
+func newTestROM() []byte {
+	return newROM([]byte{2, 2, 0x10}, 2*PRGBankSize,
+		2*CHRBankSize)
+}
+
+func getHashes(data []byte) (string, string) {
+	rom := data[16:]
+	sha := sha1.Sum(rom)
+	return strings.ToUpper(hex.EncodeToString(sha[:])),
+		fmt.Sprintf("%08X", crc32.ChecksumIEEE(rom))
+}
+
+func newDatabase(t *T, hashes string) *Database {
+	xml := "<nes20db>" + fmt.Sprintf(gameFormat, hashes) +
+		"</nes20db>"
+	d, err := LoadDatabase(strings.NewReader(xml))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func Test_Database_CorrectHeader(t *T) {
+	data := newTestROM()
+	sha, crc := getHashes(data)
+	tests := []struct {
+		name   string
+		hashes string
+	}{
+		{"SHA1", `sha1="` + sha + `"`},
+		{"CRC32", `crc32="` + crc + `"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			d := newDatabase(t, test.hashes)
+			c, fixes, err := LoadWithDatabase(data, d)
+			ExpectTrue(t, err == nil, invalidErrorText)
+			ExpectDeepEq(t, fixes, []Correction{
+				{"mapper", "1", "4"},
+				{"submapper", "0", "1"},
+				{"mirroring", "horizontal", "vertical"},
+				{"battery", "false", "true"},
+				{"PRG-RAM size", "0", "8192"},
+				{"region", "NTSC", "PAL"},
+			}, invalidCorrectionsText)
+			ExpectEq(t, c.Mapper, 4, invalidCartridgeText)
+			ExpectEq(t, c.Mirroring, Vertical, invalidCartridgeText)
+			ExpectTrue(t, c.Battery, invalidCartridgeText)
+			ExpectEq(t, c.Region, nes.PAL, invalidCartridgeText)
+		})
+	}
+}
+
+func Test_Database_WithCorrectHeader_ReportNothing(t *T) {
+	data := newROM([]byte{2, 2, 0x43, 0x08, 0x10, 0, 0x70,
+		0, 1}, 2*PRGBankSize, 2*CHRBankSize)
+	sha, _ := getHashes(data)
+	d := newDatabase(t, `sha1="`+sha+`"`)
+
+	_, fixes, _ := LoadWithDatabase(data, d)
+
+	ExpectEq(t, len(fixes), 0, invalidCorrectionsText)
+}
+
+func Test_Database_UnknownGame_ReportNothing(t *T) {
+	d := newDatabase(t, `crc32="00000000"`)
+
+	c, fixes, _ := LoadWithDatabase(newTestROM(), d)
+
+	ExpectEq(t, len(fixes), 0, invalidCorrectionsText)
+	ExpectEq(t, c.Mapper, 1, invalidCartridgeText)
+}
+
+func Test_Database_InvalidXML_ReturnError(t *T) {
+	_, err := LoadDatabase(strings.NewReader("<nes20db>"))
+
+	ExpectTrue(t, strings.HasPrefix(err.Error(),
+		"invalid ROM database: "), invalidErrorText)
+}
+
+func Test_Database_InvalidGame_ReturnError(t *T) {
+	tests := []struct {
+		name string
+		game string
+		err  string
+	}{
+		{
+			"UnknownMirroring",
+			`<game><pcb mirroring="X"/></game>`,
+			`unknown mirroring "X"`,
+		},
+		{
+			"UnknownRegion",
+			`<game><console region="4"/><pcb mirroring="H"/></game>`,
+			`unknown region 4`,
+		},
+		{
+			"InvalidCRC32",
+			`<game><rom crc32="XYZ"/><pcb mirroring="H"/></game>`,
+			`strconv.ParseUint: parsing "XYZ": invalid syntax`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			_, err := LoadDatabase(strings.NewReader(
+				"<nes20db>" + test.game + "</nes20db>"))
+			ExpectTrue(t, err != nil, invalidErrorText)
+			ExpectEq(t, err.Error(), "invalid ROM database: "+
+				test.err, invalidErrorText)
+		})
+	}
+}
+
+func Test_Database_DefaultDatabaseIsLoaded(t *T) {
+	ExpectTrue(t, DefaultDatabase != nil, invalidErrorText)
+}
+
+// dump is not distributed with the repository, test runs
+// when it is copied to testdata
+func Test_Database_DefaultDatabase_CorrectKnownDump(t *T) {
+	data, err := os.ReadFile("testdata/Super Mario Bros. (World).nes")
+	if err != nil {
+		t.Skip("Super Mario Bros. dump not found")
+	}
+	copy(data[6:8], []byte{0x10, 0})
+
+	c, fixes, err := LoadWithDatabase(data, DefaultDatabase)
+
+	ExpectTrue(t, err == nil, invalidErrorText)
+	ExpectDeepEq(t, fixes, []Correction{
+		{"mapper", "1", "0"},
+		{"mirroring", "horizontal", "vertical"},
+	}, invalidCorrectionsText)
+	ExpectEq(t, c.Mapper, 0, invalidCartridgeText)
+}
+
+func Test_Correction_String(t *T) {
+	c := Correction{"mapper", "1", "4"}
+
+	ExpectEq(t, c.String(), "mapper: 1 -> 4")
+}
@@ -0,0 +1,141 @@
+package cartridge
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/smarkuck/nes/nes"
+)
+
+const (
+	headerSize  = 16
+	trainerSize = 512
+	headerMagic = "NES\x1a"
+
+	flagsVertical   = 0x01
+	flagsBattery    = 0x02
+	flagsTrainer    = 0x04
+	flagsFourScreen = 0x08
+	flagsNES20      = 0x0c
+	flagsNES20Value = 0x08
+
+	timingMask = 0x03
+
+	exponentNotation = 0x0f
+	ramShiftBase     = 64
+
+	truncatedFileFormat = "truncated ROM file: " +
+		"expected %d bytes, got %d"
+)
+
+// multi-region games run as NTSC
+var timingRegions = [...]nes.Region{
+	nes.NTSC, nes.PAL, nes.NTSC, nes.Dendy,
+}
+
+var errInvalidHeader = errors.New("invalid iNES header")
+
+type header [headerSize]byte
+
+// Load reads iNES, NES 2.0 or UNIF file
+func Load(data []byte) (*Cartridge, error) {
+	if bytes.HasPrefix(data, []byte(unifMagic)) {
+		return loadUNIF(data)
+	}
+	if len(data) < headerSize ||
+		string(data[:len(headerMagic)]) != headerMagic {
+		return nil, errInvalidHeader
+	}
+	var h header
+	copy(h[:], data)
+	c := h.parse()
+	offset, prgSize, chrSize := headerSize, h.getPRGSize(),
+		h.getCHRSize()
+	if h[6]&flagsTrainer != 0 {
+		offset += trainerSize
+	}
+	if expected := offset + prgSize + chrSize; len(data) < expected {
+		return nil, fmt.Errorf(truncatedFileFormat,
+			expected, len(data))
+	}
+	c.PRG = data[offset : offset+prgSize]
+	c.CHR = data[offset+prgSize : offset+prgSize+chrSize]
+	return c, nil
+}
+
+func (h *header) parse() *Cartridge {
+	c := &Cartridge{
+		Mapper:    uint16(h[6]>>4 | h[7]&0xf0),
+		Mirroring: h.getMirroring(),
+		Battery:   h[6]&flagsBattery != 0,
+	}
+	switch {
+	case h.isNES20():
+		c.Mapper |= uint16(h[8]&0x0f) << 8
+		c.SubMapper = h[8] >> 4
+		c.PRGRAMSize = getRAMSize(h[10]) + getRAMSize(h[10]>>4)
+		c.CHRRAMSize = getRAMSize(h[11]) + getRAMSize(h[11]>>4)
+		c.Region = h.getRegion()
+	case h.isArchaic():
+		c.Mapper &= 0x0f
+	default:
+		c.PRGRAMSize = int(h[8]) * prgRAMUnit
+	}
+	return c
+}
+
+func (h *header) isNES20() bool {
+	return h[7]&flagsNES20 == flagsNES20Value
+}
+
+// old dumping tools wrote their name into the end of
+// header, upper mapper nibble is garbage then
+func (h *header) isArchaic() bool {
+	return h[7]&flagsNES20 != 0 ||
+		h[12]|h[13]|h[14]|h[15] != 0
+}
+
+func (h *header) getMirroring() Mirroring {
+	switch {
+	case h[6]&flagsFourScreen != 0:
+		return FourScreen
+	case h[6]&flagsVertical != 0:
+		return Vertical
+	}
+	return Horizontal
+}
+
+func (h *header) getRegion() nes.Region {
+	return timingRegions[h[12]&timingMask]
+}
+
+func (h *header) getPRGSize() int {
+	if !h.isNES20() {
+		return int(h[4]) * PRGBankSize
+	}
+	return getROMSize(h[4], h[9]&0x0f, PRGBankSize)
+}
+
+func (h *header) getCHRSize() int {
+	if !h.isNES20() {
+		return int(h[5]) * CHRBankSize
+	}
+	return getROMSize(h[5], h[9]>>4, CHRBankSize)
+}
+
+// with MSB nibble $F size is 2^E * (MM*2+1)
+// where LSB is EEEEEEMM
+func getROMSize(lsb, msb byte, unit int) int {
+	if msb != exponentNotation {
+		return (int(msb)<<8 | int(lsb)) * unit
+	}
+	return (1 << (lsb >> 2)) * (int(lsb&0x03)*2 + 1)
+}
+
+func getRAMSize(shift byte) int {
+	if shift&0x0f == 0 {
+		return 0
+	}
+	return ramShiftBase << (shift & 0x0f)
+}
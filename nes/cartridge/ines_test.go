@@ -0,0 +1,152 @@
+package cartridge_test
+
+import (
+	"github.com/smarkuck/nes/nes"
+	. "github.com/smarkuck/nes/nes/cartridge"
+	. "github.com/smarkuck/unittest"
+)
+
+const (
+	invalidCartridgeText = "invalid cartridge"
+	invalidErrorText     = "invalid error message"
+)
+
+func newROM(header []byte, prgSize, chrSize int) []byte {
+	data := append([]byte("NES\x1a"), header...)
+	data = append(data, make([]byte, 16-len(data))...)
+	for i := 0; i < prgSize+chrSize; i++ {
+		data = append(data, byte(i/PRGBankSize))
+	}
+	return data
+}
+
+func loadROM(t *T, data []byte) *Cartridge {
+	c, err := Load(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func Test_Load_INES(t *T) {
+	data := newROM([]byte{2, 1, 0x13, 0x40, 1},
+		2*PRGBankSize, CHRBankSize)
+
+	c := loadROM(t, data)
+
+	ExpectEq(t, len(c.PRG), 2*PRGBankSize, invalidCartridgeText)
+	ExpectEq(t, len(c.CHR), CHRBankSize, invalidCartridgeText)
+	ExpectEq(t, c.CHR[0], 2, invalidCartridgeText)
+	ExpectEq(t, c.Mapper, 0x41, invalidCartridgeText)
+	ExpectEq(t, c.Mirroring, Vertical, invalidCartridgeText)
+	ExpectTrue(t, c.Battery, invalidCartridgeText)
+	ExpectEq(t, c.PRGRAMSize, 0x2000, invalidCartridgeText)
+}
+
+func Test_Load_Mirroring(t *T) {
+	tests := []struct {
+		name      string
+		flags     byte
+		mirroring Mirroring
+	}{
+		{"Horizontal", 0x00, Horizontal},
+		{"Vertical", 0x01, Vertical},
+		{"FourScreen", 0x09, FourScreen},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			c := loadROM(t, newROM([]byte{1, 0, test.flags},
+				PRGBankSize, 0))
+			ExpectEq(t, c.Mirroring, test.mirroring,
+				invalidCartridgeText)
+		})
+	}
+}
+
+func Test_Load_SkipTrainer(t *T) {
+	data := newROM([]byte{1, 0, 0x04}, 0, 0)
+	data = append(data, make([]byte, 512)...)
+	data = append(data, make([]byte, PRGBankSize)...)
+	data[16+512] = 0x42
+
+	c := loadROM(t, data)
+
+	ExpectEq(t, c.PRG[0], 0x42, invalidCartridgeText)
+}
+
+func Test_Load_ArchaicHeader_IgnoreUpperMapperNibble(t *T) {
+	data := newROM([]byte{1, 0, 0x10, 0x44, 0, 0, 0, 0,
+		'D', 'i', 's', 'k'}, PRGBankSize, 0)
+
+	c := loadROM(t, data)
+
+	ExpectEq(t, c.Mapper, 1, invalidCartridgeText)
+}
+
+func Test_Load_NES20(t *T) {
+	data := newROM([]byte{2, 0, 0x52, 0x18, 0x51, 0x00,
+		0x70, 0x07}, 2*PRGBankSize, 0)
+
+	c := loadROM(t, data)
+
+	ExpectEq(t, c.Mapper, 0x115, invalidCartridgeText)
+	ExpectEq(t, c.SubMapper, 5, invalidCartridgeText)
+	ExpectEq(t, c.PRGRAMSize, 0x2000, invalidCartridgeText)
+	ExpectEq(t, c.CHRRAMSize, 0x2000, invalidCartridgeText)
+	ExpectTrue(t, c.Battery, invalidCartridgeText)
+}
+
+func Test_Load_NES20_ExponentSize(t *T) {
+	data := newROM([]byte{0x39, 0, 0, 0x08, 0, 0x0f},
+		3*0x4000, 0)
+
+	c := loadROM(t, data)
+
+	ExpectEq(t, len(c.PRG), 3*0x4000, invalidCartridgeText)
+}
+
+func Test_Load_NES20_Region(t *T) {
+	tests := []struct {
+		name   string
+		timing byte
+		region nes.Region
+	}{
+		{"NTSC", 0, nes.NTSC},
+		{"PAL", 1, nes.PAL},
+		{"MultiRegion", 2, nes.NTSC},
+		{"Dendy", 3, nes.Dendy},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			data := newROM([]byte{1, 0, 0, 0x08, 0, 0, 0, 0,
+				test.timing}, PRGBankSize, 0)
+
+			c := loadROM(t, data)
+
+			ExpectEq(t, c.Region, test.region,
+				invalidCartridgeText)
+		})
+	}
+}
+
+func Test_Load_Errors(t *T) {
+	tests := []struct {
+		name string
+		data []byte
+		text string
+	}{
+		{"Short", []byte("NES"), "invalid iNES header"},
+		{"Magic", make([]byte, 32), "invalid iNES header"},
+		{"Truncated", newROM([]byte{2, 1}, PRGBankSize, 0),
+			"truncated ROM file: expected 40976 bytes, got 16400"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			_, err := Load(test.data)
+			ExpectEq(t, err.Error(), test.text, invalidErrorText)
+		})
+	}
+}
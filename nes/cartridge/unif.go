@@ -0,0 +1,152 @@
+package cartridge
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	unifMagic      = "UNIF"
+	unifHeaderSize = 32
+	chunkHeadSize  = 8
+	romChunks      = "0123456789ABCDEF"
+
+	truncatedChunkFormat = "truncated UNIF chunk %q"
+	unknownBoardFormat   = "unknown UNIF board %q"
+	invalidMirrorFormat  = "invalid UNIF mirroring %d"
+)
+
+var (
+	errInvalidUNIFHeader = errors.New("invalid UNIF header")
+	errMissingBoard      = errors.New("missing UNIF board name")
+	errMissingPRG        = errors.New("missing UNIF PRG data")
+)
+
+// licensed boards are the same in US and Japan, other
+// prefixes name different boards and are kept
+var boardPrefixes = []string{"NES-", "HVC-"}
+
+type board struct {
+	mapper    uint16
+	subMapper uint8
+}
+
+var boards = map[string]board{
+	"NROM": {0, 0}, "NROM-128": {0, 0}, "NROM-256": {0, 0},
+	"RROM": {0, 0}, "RROM-128": {0, 0},
+	"SAROM": {1, 0}, "SBROM": {1, 0}, "SCROM": {1, 0},
+	"SEROM": {1, 0}, "SFROM": {1, 0}, "SGROM": {1, 0},
+	"SHROM": {1, 0}, "SJROM": {1, 0}, "SKROM": {1, 0},
+	"SLROM": {1, 0}, "SL1ROM": {1, 0}, "SNROM": {1, 0},
+	"SOROM": {1, 0}, "SUROM": {1, 0}, "SXROM": {1, 0},
+	"UNROM": {2, 0}, "UOROM": {2, 0},
+	"CNROM": {3, 0},
+	"TBROM": {4, 0}, "TEROM": {4, 0}, "TFROM": {4, 0},
+	"TGROM": {4, 0}, "TKROM": {4, 0}, "TLROM": {4, 0},
+	"TL1ROM": {4, 0}, "TR1ROM": {4, 0}, "TSROM": {4, 0},
+	"TVROM": {4, 0}, "B4": {4, 0},
+	"EKROM": {5, 0}, "ELROM": {5, 0}, "ETROM": {5, 0},
+	"EWROM": {5, 0},
+	"AMROM": {7, 0}, "ANROM": {7, 0}, "AOROM": {7, 0},
+	"PNROM": {9, 0}, "PEEOROM": {9, 0},
+	"FJROM": {10, 0}, "FKROM": {10, 0},
+	"GNROM": {66, 0}, "MHROM": {66, 0},
+	"BTR": {69, 0}, "JLROM": {69, 0}, "JSROM": {69, 0},
+	"BANDAI-FCG": {16, 4}, "BANDAI-LZ93D50": {16, 5},
+	"AVE-NINA-03": {79, 0}, "AVE-NINA-06": {79, 0},
+	"UNL-SA-016-1M": {146, 0}, "UNL-SA-72008": {133, 0},
+	"BMC-GK-192": {58, 0}, "BMC-NovelDiamond9999999in1": {201, 0},
+}
+
+var unifMirrorings = []Mirroring{
+	Horizontal, Vertical, SingleScreenA, SingleScreenB,
+	FourScreen, MapperControlled,
+}
+
+type unifChunks map[string][]byte
+
+func loadUNIF(data []byte) (*Cartridge, error) {
+	if len(data) < unifHeaderSize {
+		return nil, errInvalidUNIFHeader
+	}
+	chunks, err := readUNIFChunks(data[unifHeaderSize:])
+	if err != nil {
+		return nil, err
+	}
+	c := &Cartridge{
+		PRG:     chunks.join("PRG"),
+		CHR:     chunks.join("CHR"),
+		Battery: chunks["BATR"] != nil,
+	}
+	if len(c.PRG) == 0 {
+		return nil, errMissingPRG
+	}
+	if err := chunks.setBoard(c); err != nil {
+		return nil, err
+	}
+	return c, chunks.setMirroring(c)
+}
+
+func readUNIFChunks(data []byte) (unifChunks, error) {
+	chunks := make(unifChunks)
+	for len(data) >= chunkHeadSize {
+		id := string(data[:4])
+		size := int(binary.LittleEndian.Uint32(data[4:]))
+		data = data[chunkHeadSize:]
+		if size > len(data) {
+			return nil, fmt.Errorf(truncatedChunkFormat, id)
+		}
+		chunks[id] = data[:size]
+		data = data[size:]
+	}
+	return chunks, nil
+}
+
+// ROM is split into up to 16 chunks loaded in order
+func (u unifChunks) join(prefix string) []byte {
+	var rom []byte
+	for _, i := range romChunks {
+		rom = append(rom, u[prefix+string(i)]...)
+	}
+	return rom
+}
+
+func (u unifChunks) setBoard(c *Cartridge) error {
+	name, ok := u["MAPR"]
+	if !ok {
+		return errMissingBoard
+	}
+	if i := bytes.IndexByte(name, 0); i >= 0 {
+		name = name[:i]
+	}
+	b, ok := boards[trimBoardPrefix(string(name))]
+	if !ok {
+		return fmt.Errorf(unknownBoardFormat, name)
+	}
+	c.Mapper, c.SubMapper = b.mapper, b.subMapper
+	return nil
+}
+
+func trimBoardPrefix(name string) string {
+	for _, prefix := range boardPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return name[len(prefix):]
+		}
+	}
+	return name
+}
+
+func (u unifChunks) setMirroring(c *Cartridge) error {
+	mirr, ok := u["MIRR"]
+	if !ok || len(mirr) == 0 {
+		return nil
+	}
+	if int(mirr[0]) >= len(unifMirrorings) {
+		return fmt.Errorf(invalidMirrorFormat, mirr[0])
+	}
+	c.Mirroring = unifMirrorings[mirr[0]]
+	return nil
+}
@@ -0,0 +1,113 @@
+package cartridge_test
+
+import (
+	"encoding/binary"
+
+	. "github.com/smarkuck/nes/nes/cartridge"
+	. "github.com/smarkuck/unittest"
+)
+
+func newUNIF(chunks ...[]byte) []byte {
+	data := make([]byte, 32)
+	copy(data, "UNIF\x04")
+	for _, c := range chunks {
+		data = append(data, c...)
+	}
+	return data
+}
+
+func chunk(id string, data ...byte) []byte {
+	c := append([]byte(id), 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(c[4:], uint32(len(data)))
+	return append(c, data...)
+}
+
+func board(name string) []byte {
+	return chunk("MAPR", append([]byte(name), 0)...)
+}
+
+func Test_Load_UNIF(t *T) {
+	c := loadROM(t, newUNIF(
+		board("NES-TLROM"),
+		chunk("PRG1", 2, 3),
+		chunk("PRG0", 1),
+		chunk("CHR0", 4),
+		chunk("MIRR", 1),
+		chunk("BATR", 1),
+	))
+
+	ExpectDeepEq(t, c.PRG, []byte{1, 2, 3}, invalidCartridgeText)
+	ExpectDeepEq(t, c.CHR, []byte{4}, invalidCartridgeText)
+	ExpectEq(t, c.Mapper, 4, invalidCartridgeText)
+	ExpectEq(t, c.Mirroring, Vertical, invalidCartridgeText)
+	ExpectTrue(t, c.Battery, invalidCartridgeText)
+}
+
+func Test_Load_UNIFBoards(t *T) {
+	tests := []struct {
+		name      string
+		mapper    uint16
+		subMapper uint8
+	}{
+		{"NES-NROM-256", 0, 0},
+		{"HVC-SKROM", 1, 0},
+		{"UOROM", 2, 0},
+		{"CNROM", 3, 0},
+		{"NES-EKROM", 5, 0},
+		{"AOROM", 7, 0},
+		{"BANDAI-LZ93D50", 16, 5},
+		{"NES-BTR", 69, 0},
+		{"AVE-NINA-06", 79, 0},
+		{"UNL-SA-016-1M", 146, 0},
+		{"UNL-SA-72008", 133, 0},
+		{"BMC-GK-192", 58, 0},
+		{"BMC-NovelDiamond9999999in1", 201, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			c := loadROM(t, newUNIF(board(test.name),
+				chunk("PRG0", 0)))
+			ExpectEq(t, c.Mapper, test.mapper, invalidCartridgeText)
+			ExpectEq(t, c.SubMapper, test.subMapper,
+				invalidCartridgeText)
+		})
+	}
+}
+
+func Test_Load_UNIFWithoutOptionalChunks(t *T) {
+	c := loadROM(t, newUNIF(board("NES-NROM"), chunk("PRG0", 0)))
+
+	ExpectEq(t, len(c.CHR), 0, invalidCartridgeText)
+	ExpectEq(t, c.Mirroring, Horizontal, invalidCartridgeText)
+	ExpectFalse(t, c.Battery, invalidCartridgeText)
+}
+
+func Test_Load_WhenUNIFInvalid_ReturnError(t *T) {
+	prg := chunk("PRG0", 0)
+	tests := []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{"Header", []byte("UNIF"), "invalid UNIF header"},
+		{"NoBoard", newUNIF(prg), "missing UNIF board name"},
+		{"NoPRG", newUNIF(board("NROM")), "missing UNIF PRG data"},
+		{"UnknownBoard", newUNIF(board("UNL-XYZ"), prg),
+			`unknown UNIF board "UNL-XYZ"`},
+		{"UnlicensedAlias", newUNIF(board("UNL-UOROM"), prg),
+			`unknown UNIF board "UNL-UOROM"`},
+		{"Mirroring", newUNIF(board("NROM"), prg,
+			chunk("MIRR", 6)), "invalid UNIF mirroring 6"},
+		{"Truncated", newUNIF(board("NROM"), prg[:8]),
+			`truncated UNIF chunk "PRG0"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			_, err := Load(test.data)
+			ExpectTrue(t, err != nil, invalidErrorText)
+			ExpectEq(t, err.Error(), test.err, invalidErrorText)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package cheat
+
+import "github.com/smarkuck/nes/nes"
+
+type FreezeMode int
+
+const (
+	RewriteEveryFrame FreezeMode = iota
+	InterceptReads
+)
+
+type Freezer interface {
+	nes.Bus
+	Freeze(addr uint16, value byte)
+	Unfreeze(addr uint16)
+	GetFrozen() []PARCode
+	UpdateFrame()
+	ImportPAR(code string) error
+	ExportPAR() []string
+}
+
+type freezer struct {
+	nes.Bus
+	mode   FreezeMode
+	frozen []PARCode
+}
+
+func NewFreezer(b nes.Bus, m FreezeMode) Freezer {
+	return &freezer{Bus: b, mode: m}
+}
+
+func (f *freezer) Read(addr uint16) byte {
+	if f.mode == InterceptReads {
+		if i := f.find(addr); i >= 0 {
+			return f.frozen[i].Value
+		}
+	}
+	return f.Bus.Read(addr)
+}
+
+func (f *freezer) Freeze(addr uint16, value byte) {
+	if i := f.find(addr); i >= 0 {
+		f.frozen[i].Value = value
+		return
+	}
+	f.frozen = append(f.frozen, PARCode{addr, value})
+}
+
+func (f *freezer) Unfreeze(addr uint16) {
+	if i := f.find(addr); i >= 0 {
+		f.frozen = append(f.frozen[:i], f.frozen[i+1:]...)
+	}
+}
+
+func (f *freezer) GetFrozen() []PARCode {
+	return append([]PARCode(nil), f.frozen...)
+}
+
+func (f *freezer) UpdateFrame() {
+	if f.mode != RewriteEveryFrame {
+		return
+	}
+	for _, c := range f.frozen {
+		f.Bus.Write(c.Address, c.Value)
+	}
+}
+
+func (f *freezer) ImportPAR(code string) error {
+	c, err := DecodePAR(code)
+	if err == nil {
+		f.Freeze(c.Address, c.Value)
+	}
+	return err
+}
+
+func (f *freezer) ExportPAR() []string {
+	codes := make([]string, len(f.frozen))
+	for i, c := range f.frozen {
+		codes[i] = EncodePAR(c)
+	}
+	return codes
+}
+
+func (f *freezer) find(addr uint16) int {
+	for i, c := range f.frozen {
+		if c.Address == addr {
+			return i
+		}
+	}
+	return -1
+}
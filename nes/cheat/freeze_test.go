@@ -0,0 +1,60 @@
+package cheat_test
+
+import (
+	. "github.com/smarkuck/nes/nes/cheat"
+	. "github.com/smarkuck/unittest"
+)
+
+const invalidFrozenText = "invalid frozen codes"
+
+func Test_Freezer_RewriteValuesEveryFrame(t *T) {
+	m := new(memoryBus)
+	f := NewFreezer(m, RewriteEveryFrame)
+	f.Freeze(0x075a, 9)
+	m[0x075a] = 1
+
+	ExpectEq(t, f.Read(0x075a), 1, invalidReadText)
+
+	f.UpdateFrame()
+
+	ExpectEq(t, m[0x075a], 9, invalidReadText)
+}
+
+func Test_Freezer_InterceptReads(t *T) {
+	m := new(memoryBus)
+	f := NewFreezer(m, InterceptReads)
+	f.Freeze(0x075a, 9)
+
+	f.Write(0x075a, 1)
+	f.UpdateFrame()
+
+	ExpectEq(t, m[0x075a], 1, invalidReadText)
+	ExpectEq(t, f.Read(0x075a), 9, invalidReadText)
+	ExpectEq(t, f.Read(0x075b), 0, invalidReadText)
+}
+
+func Test_Freezer_ReplaceAndUnfreezeValue(t *T) {
+	f := NewFreezer(new(memoryBus), InterceptReads)
+	f.Freeze(0x10, 1)
+	f.Freeze(0x20, 2)
+
+	f.Freeze(0x10, 3)
+	ExpectDeepEq(t, f.GetFrozen(),
+		[]PARCode{{0x10, 3}, {0x20, 2}}, invalidFrozenText)
+
+	f.Unfreeze(0x10)
+	ExpectDeepEq(t, f.GetFrozen(), []PARCode{{0x20, 2}},
+		invalidFrozenText)
+	ExpectEq(t, f.Read(0x10), 0, invalidReadText)
+}
+
+func Test_Freezer_ImportAndExportPAR(t *T) {
+	f := NewFreezer(new(memoryBus), RewriteEveryFrame)
+
+	ExpectTrue(t, f.ImportPAR("00075a0f") == nil, invalidErrorText)
+	ExpectTrue(t, f.ImportPAR("0007") != nil, invalidErrorText)
+	f.Freeze(0x6000, 0xff)
+
+	ExpectDeepEq(t, f.ExportPAR(),
+		[]string{"00075A0F", "006000FF"}, invalidCodeText)
+}
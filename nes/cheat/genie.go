@@ -0,0 +1,102 @@
+package cheat
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	genieAlphabet    = "APZLGITYEOXUKSVN"
+	genieShortLength = 6
+	genieLongLength  = 8
+	genieBaseAddress = 0x8000
+
+	invalidLengthFormat = "invalid Game Genie code length: %d"
+	invalidLetterFormat = "invalid Game Genie letter %q " +
+		"in code %q"
+)
+
+type GenieCode struct {
+	Address    uint16
+	Value      byte
+	Compare    byte
+	HasCompare bool
+}
+
+func DecodeGenie(code string) (GenieCode, error) {
+	n, err := genieNibbles(code)
+	if err != nil {
+		return GenieCode{}, err
+	}
+	c := GenieCode{Address: genieAddress(n)}
+	if len(n) == genieShortLength {
+		c.Value = genieByte(n[1], n[0], n[5])
+		return c, nil
+	}
+	c.Value = genieByte(n[1], n[0], n[7])
+	c.Compare = genieByte(n[7], n[6], n[5])
+	c.HasCompare = true
+	return c, nil
+}
+
+func EncodeGenie(c GenieCode) string {
+	n := make([]byte, genieShortLength, genieLongLength)
+	addr := c.Address
+	n[0] = c.Value>>4&0x08 | c.Value&0x07
+	n[1] = c.Value>>4&0x07 | byte(addr>>4)&0x08
+	n[2] = byte(addr>>4) & 0x07
+	n[3] = byte(addr>>12)&0x07 | byte(addr)&0x08
+	n[4] = byte(addr)&0x07 | byte(addr>>8)&0x08
+	n[5] = byte(addr>>8) & 0x07
+	if c.HasCompare {
+		n[2] |= 0x08
+		n[5] |= c.Compare & 0x08
+		n = append(n, c.Compare>>4&0x08|c.Compare&0x07,
+			c.Compare>>4&0x07|c.Value&0x08)
+	} else {
+		n[5] |= c.Value & 0x08
+	}
+	return genieLetters(n)
+}
+
+func genieNibbles(code string) ([]byte, error) {
+	code = strings.ToUpper(code)
+	if len(code) != genieShortLength &&
+		len(code) != genieLongLength {
+		return nil, fmt.Errorf(invalidLengthFormat, len(code))
+	}
+	n := make([]byte, len(code))
+	for i, letter := range code {
+		index := strings.IndexRune(genieAlphabet, letter)
+		if index < 0 {
+			return nil, fmt.Errorf(invalidLetterFormat,
+				letter, code)
+		}
+		n[i] = byte(index)
+	}
+	return n, nil
+}
+
+func genieLetters(n []byte) string {
+	var b strings.Builder
+	for _, nibble := range n {
+		b.WriteByte(genieAlphabet[nibble])
+	}
+	return b.String()
+}
+
+func genieAddress(n []byte) uint16 {
+	return genieBaseAddress |
+		uint16(n[3]&0x07)<<12 |
+		uint16(n[5]&0x07)<<8 | uint16(n[4]&0x08)<<8 |
+		uint16(n[2]&0x07)<<4 | uint16(n[1]&0x08)<<4 |
+		uint16(n[4]&0x07) | uint16(n[3]&0x08)
+}
+
+// each byte is scattered over three letters, high bits are
+// taken from the first one and the top bit of low nibble
+// from the last one
+func genieByte(high, low, top byte) byte {
+	return (high&0x07)<<4 | (low&0x08)<<4 |
+		low&0x07 | top&0x08
+}
@@ -0,0 +1,83 @@
+package cheat
+
+import (
+	"strings"
+
+	"github.com/smarkuck/nes/nes"
+)
+
+type GenieBus interface {
+	nes.Bus
+	Add(code string) error
+	Remove(code string)
+	SetEnabled(code string, enabled bool)
+	GetCodes() []string
+}
+
+type genieEntry struct {
+	text    string
+	code    GenieCode
+	enabled bool
+}
+
+type genieBus struct {
+	nes.Bus
+	entries []*genieEntry
+}
+
+func NewGenieBus(b nes.Bus) GenieBus {
+	return &genieBus{Bus: b}
+}
+
+func (g *genieBus) Read(addr uint16) byte {
+	value := g.Bus.Read(addr)
+	for _, e := range g.entries {
+		if e.enabled && e.code.Address == addr &&
+			(!e.code.HasCompare || e.code.Compare == value) {
+			return e.code.Value
+		}
+	}
+	return value
+}
+
+func (g *genieBus) Add(code string) error {
+	c, err := DecodeGenie(code)
+	if err != nil {
+		return err
+	}
+	g.Remove(code)
+	g.entries = append(g.entries, &genieEntry{
+		text: strings.ToUpper(code), code: c, enabled: true,
+	})
+	return nil
+}
+
+func (g *genieBus) Remove(code string) {
+	for i, e := range g.entries {
+		if isSameGenieCode(e, code) {
+			g.entries = append(g.entries[:i], g.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+func (g *genieBus) SetEnabled(code string, enabled bool) {
+	for _, e := range g.entries {
+		if isSameGenieCode(e, code) {
+			e.enabled = enabled
+		}
+	}
+}
+
+func (g *genieBus) GetCodes() []string {
+	codes := make([]string, len(g.entries))
+	for i, e := range g.entries {
+		codes[i] = e.text
+	}
+	return codes
+}
+
+func isSameGenieCode(e *genieEntry, code string) bool {
+	c, err := DecodeGenie(code)
+	return err == nil && c == e.code
+}
@@ -0,0 +1,91 @@
+package cheat_test
+
+import (
+	. "github.com/smarkuck/nes/nes/cheat"
+	. "github.com/smarkuck/unittest"
+)
+
+const invalidReadText = "invalid read"
+
+type memoryBus [0x10000]byte
+
+func (m *memoryBus) Read(addr uint16) byte {
+	return m[addr]
+}
+
+func (m *memoryBus) Write(addr uint16, value byte) {
+	m[addr] = value
+}
+
+func Test_GenieBus_OverrideRead(t *T) {
+	g := NewGenieBus(new(memoryBus))
+
+	ExpectTrue(t, g.Add("GOSSIP") == nil, invalidErrorText)
+
+	ExpectEq(t, g.Read(0xd1dd), 0x14, invalidReadText)
+	ExpectEq(t, g.Read(0xd1de), 0x00, invalidReadText)
+}
+
+func Test_GenieBus_OverrideOnlyWhenCompareMatches(t *T) {
+	m := new(memoryBus)
+	g := NewGenieBus(m)
+	g.Add("ZEXPYGLA")
+
+	m[0x94a7] = 0x03
+	ExpectEq(t, g.Read(0x94a7), 0x02, invalidReadText)
+
+	m[0x94a7] = 0x04
+	ExpectEq(t, g.Read(0x94a7), 0x04, invalidReadText)
+}
+
+func Test_GenieBus_PassWritesThrough(t *T) {
+	m := new(memoryBus)
+	g := NewGenieBus(m)
+	g.Add("GOSSIP")
+
+	g.Write(0xd1dd, 0x55)
+
+	ExpectEq(t, m[0xd1dd], 0x55, invalidReadText)
+}
+
+func Test_GenieBus_ToggleCode(t *T) {
+	m := new(memoryBus)
+	m[0xd1dd] = 0x77
+	g := NewGenieBus(m)
+	g.Add("GOSSIP")
+
+	g.SetEnabled("gossip", false)
+	ExpectEq(t, g.Read(0xd1dd), 0x77, invalidReadText)
+
+	g.SetEnabled("GOSSIP", true)
+	ExpectEq(t, g.Read(0xd1dd), 0x14, invalidReadText)
+}
+
+func Test_GenieBus_RemoveCode(t *T) {
+	g := NewGenieBus(new(memoryBus))
+	g.Add("GOSSIP")
+	g.Add("ZEXPYGLA")
+
+	g.Remove("GOSSIP")
+
+	ExpectEq(t, g.Read(0xd1dd), 0x00, invalidReadText)
+	ExpectDeepEq(t, g.GetCodes(), []string{"ZEXPYGLA"},
+		invalidCodeText)
+}
+
+func Test_GenieBus_IgnoreDuplicatedCode(t *T) {
+	g := NewGenieBus(new(memoryBus))
+	g.Add("GOSSIP")
+
+	g.Add("gossip")
+
+	ExpectDeepEq(t, g.GetCodes(), []string{"GOSSIP"},
+		invalidCodeText)
+}
+
+func Test_GenieBus_WhenCodeInvalid_ReturnError(t *T) {
+	g := NewGenieBus(new(memoryBus))
+
+	ExpectTrue(t, g.Add("GOSSIB") != nil, invalidErrorText)
+	ExpectEq(t, len(g.GetCodes()), 0)
+}
@@ -0,0 +1,81 @@
+package cheat_test
+
+import (
+	. "github.com/smarkuck/nes/nes/cheat"
+	. "github.com/smarkuck/unittest"
+)
+
+const (
+	invalidErrorText = "invalid error"
+	invalidCodeText  = "invalid code"
+)
+
+var genieCodes = map[string]GenieCode{
+	"ZEXPYGLA": {0x94a7, 0x02, 0x03, true},
+	"SXIOPO":   {Address: 0x91d9, Value: 0xad},
+}
+
+func Test_Genie_DecodeCode(t *T) {
+	for text, code := range genieCodes {
+		t.Run(text, func(t *T) {
+			c, err := DecodeGenie(text)
+
+			ExpectTrue(t, err == nil, invalidErrorText)
+			ExpectEq(t, c, code, invalidCodeText)
+		})
+	}
+}
+
+func Test_Genie_EncodeCode(t *T) {
+	for text, code := range genieCodes {
+		t.Run(text, func(t *T) {
+			ExpectEq(t, EncodeGenie(code), text, invalidCodeText)
+		})
+	}
+}
+
+func Test_Genie_AcceptLowerCase(t *T) {
+	c, _ := DecodeGenie("sxiopo")
+
+	ExpectEq(t, c, genieCodes["SXIOPO"], invalidCodeText)
+}
+
+// length bit in third letter is not needed for 6 letter codes
+func Test_Genie_DecodeShortCodeWithLengthBit(t *T) {
+	c, _ := DecodeGenie("GOSSIP")
+
+	ExpectEq(t, c, GenieCode{Address: 0xd1dd, Value: 0x14},
+		invalidCodeText)
+}
+
+func Test_Genie_EncodeEveryAddressAndValue(t *T) {
+	for addr := 0x8000; addr <= 0xffff; addr += 0x0111 {
+		for value := 0; value <= 0xff; value += 0x11 {
+			code := GenieCode{uint16(addr), byte(value),
+				byte(value ^ 0xff), true}
+
+			c, _ := DecodeGenie(EncodeGenie(code))
+
+			ExpectEq(t, c, code, invalidCodeText)
+		}
+	}
+}
+
+func Test_Genie_WhenCodeInvalid_ReturnError(t *T) {
+	codes := map[string]string{
+		"too short":  "GOSSI",
+		"wrong size": "GOSSIPA",
+		"too long":   "GOSSIPAAA",
+		"bad letter": "GOSSIB",
+		"non ascii":  "GOSSIĄ",
+		"empty":      "",
+	}
+
+	for name, code := range codes {
+		t.Run(name, func(t *T) {
+			_, err := DecodeGenie(code)
+
+			ExpectTrue(t, err != nil, invalidErrorText)
+		})
+	}
+}
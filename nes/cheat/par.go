@@ -0,0 +1,33 @@
+package cheat
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const (
+	parLength = 8
+
+	invalidPARFormat = "invalid Pro Action Replay code: %q"
+)
+
+// PAR codes are written as 00AAAAVV hex digits
+type PARCode struct {
+	Address uint16
+	Value   byte
+}
+
+func DecodePAR(code string) (PARCode, error) {
+	if len(code) != parLength {
+		return PARCode{}, fmt.Errorf(invalidPARFormat, code)
+	}
+	n, err := strconv.ParseUint(code, 16, 32)
+	if err != nil || n>>24 != 0 {
+		return PARCode{}, fmt.Errorf(invalidPARFormat, code)
+	}
+	return PARCode{uint16(n >> 8), byte(n)}, nil
+}
+
+func EncodePAR(c PARCode) string {
+	return fmt.Sprintf("00%04X%02X", c.Address, c.Value)
+}
@@ -0,0 +1,36 @@
+package cheat_test
+
+import (
+	. "github.com/smarkuck/nes/nes/cheat"
+	. "github.com/smarkuck/unittest"
+)
+
+func Test_PAR_DecodeCode(t *T) {
+	c, err := DecodePAR("00075a0f")
+
+	ExpectTrue(t, err == nil, invalidErrorText)
+	ExpectEq(t, c, PARCode{0x075a, 0x0f}, invalidCodeText)
+}
+
+func Test_PAR_EncodeCode(t *T) {
+	ExpectEq(t, EncodePAR(PARCode{0x075a, 0x0f}), "00075A0F",
+		invalidCodeText)
+}
+
+func Test_PAR_WhenCodeInvalid_ReturnError(t *T) {
+	codes := map[string]string{
+		"too short":    "00075A0",
+		"too long":     "00075A0F0",
+		"not hex":      "00075G0F",
+		"address high": "01075A0F",
+		"sign":         "+0075A0F",
+	}
+
+	for name, code := range codes {
+		t.Run(name, func(t *T) {
+			_, err := DecodePAR(code)
+
+			ExpectTrue(t, err != nil, invalidErrorText)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package cheat
+
+import (
+	"fmt"
+
+	"github.com/smarkuck/nes/nes"
+)
+
+const unknownComparisonFormat = "unknown comparison: %d"
+
+type Comparison int
+
+const (
+	Equal Comparison = iota
+	Changed
+	Increased
+	Decreased
+)
+
+var comparisons = map[Comparison]func(prev, cur byte) bool{
+	Equal:     func(prev, cur byte) bool { return cur == prev },
+	Changed:   func(prev, cur byte) bool { return cur != prev },
+	Increased: func(prev, cur byte) bool { return cur > prev },
+	Decreased: func(prev, cur byte) bool { return cur < prev },
+}
+
+type Region struct {
+	Start, End uint16
+}
+
+var (
+	RAM    = Region{0x0000, 0x07ff}
+	PRGRAM = Region{0x6000, 0x7fff}
+)
+
+type Candidate struct {
+	Address uint16
+	Value   byte
+}
+
+type Search interface {
+	Reset()
+	Filter(Comparison)
+	FilterValue(value byte)
+	GetCandidates() []Candidate
+}
+
+type search struct {
+	bus        nes.Bus
+	regions    []Region
+	candidates []Candidate
+}
+
+// NewSearch snapshots RAM and PRG-RAM when no regions
+// are given
+func NewSearch(b nes.Bus, regions ...Region) Search {
+	if len(regions) == 0 {
+		regions = []Region{RAM, PRGRAM}
+	}
+	s := &search{bus: b, regions: regions}
+	s.Reset()
+	return s
+}
+
+func (s *search) Reset() {
+	s.candidates = s.candidates[:0]
+	for _, r := range s.regions {
+		for addr := int(r.Start); addr <= int(r.End); addr++ {
+			s.candidates = append(s.candidates,
+				Candidate{uint16(addr), s.bus.Read(uint16(addr))})
+		}
+	}
+}
+
+func (s *search) Filter(c Comparison) {
+	matches, ok := comparisons[c]
+	if !ok {
+		panic(fmt.Errorf(unknownComparisonFormat, c))
+	}
+	s.filter(matches)
+}
+
+func (s *search) FilterValue(value byte) {
+	s.filter(func(_, cur byte) bool { return cur == value })
+}
+
+func (s *search) filter(matches func(prev, cur byte) bool) {
+	kept := s.candidates[:0]
+	for _, c := range s.candidates {
+		value := s.bus.Read(c.Address)
+		if matches(c.Value, value) {
+			kept = append(kept, Candidate{c.Address, value})
+		}
+	}
+	s.candidates = kept
+}
+
+func (s *search) GetCandidates() []Candidate {
+	return append([]Candidate(nil), s.candidates...)
+}
@@ -0,0 +1,90 @@
+package cheat_test
+
+import (
+	. "github.com/smarkuck/nes/nes/cheat"
+	. "github.com/smarkuck/unittest"
+)
+
+const invalidCandidatesText = "invalid candidates"
+
+var testRegion = Region{Start: 0x10, End: 0x13}
+
+func Test_Search_SnapshotRAMAndPRGRAMByDefault(t *T) {
+	s := NewSearch(new(memoryBus))
+
+	c := s.GetCandidates()
+
+	ExpectEq(t, len(c), 0x800+0x2000)
+	ExpectEq(t, c[0x7ff].Address, 0x07ff)
+	ExpectEq(t, c[0x800].Address, 0x6000)
+	ExpectEq(t, c[len(c)-1].Address, 0x7fff)
+}
+
+func Test_Search_FilterByComparison(t *T) {
+	tests := map[string]struct {
+		Comparison
+		expected []Candidate
+	}{
+		"equal":     {Equal, []Candidate{{0x10, 5}, {0x13, 3}}},
+		"changed":   {Changed, []Candidate{{0x11, 7}, {0x12, 1}}},
+		"increased": {Increased, []Candidate{{0x11, 7}}},
+		"decreased": {Decreased, []Candidate{{0x12, 1}}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *T) {
+			m := new(memoryBus)
+			m[0x10], m[0x11], m[0x12], m[0x13] = 5, 6, 2, 3
+			s := NewSearch(m, testRegion)
+			m[0x11], m[0x12] = 7, 1
+
+			s.Filter(test.Comparison)
+
+			ExpectDeepEq(t, s.GetCandidates(), test.expected,
+				invalidCandidatesText)
+		})
+	}
+}
+
+func Test_Search_NarrowDownCandidates(t *T) {
+	m := new(memoryBus)
+	m[0x10], m[0x11], m[0x12] = 3, 3, 3
+	s := NewSearch(m, testRegion)
+
+	m[0x10], m[0x11] = 2, 2
+	s.Filter(Decreased)
+	m[0x10] = 1
+	s.Filter(Decreased)
+
+	ExpectDeepEq(t, s.GetCandidates(), []Candidate{{0x10, 1}},
+		invalidCandidatesText)
+}
+
+func Test_Search_FilterByValue(t *T) {
+	m := new(memoryBus)
+	s := NewSearch(m, testRegion, Region{0x6000, 0x6001})
+	m[0x12], m[0x6001] = 9, 9
+
+	s.FilterValue(9)
+
+	ExpectDeepEq(t, s.GetCandidates(),
+		[]Candidate{{0x12, 9}, {0x6001, 9}}, invalidCandidatesText)
+}
+
+func Test_Search_ResetCandidates(t *T) {
+	m := new(memoryBus)
+	s := NewSearch(m, testRegion)
+	s.FilterValue(1)
+
+	s.Reset()
+
+	ExpectEq(t, len(s.GetCandidates()), 4)
+}
+
+func Test_Search_WhenComparisonUnknown_Panic(t *T) {
+	s := NewSearch(new(memoryBus), testRegion)
+
+	defer ExpectPanicErrEq(t, "unknown comparison: 9")
+
+	s.Filter(Comparison(9))
+}
@@ -0,0 +1,101 @@
+package console
+
+import (
+	"github.com/smarkuck/nes/nes/apu"
+	"github.com/smarkuck/nes/nes/mapper"
+	"github.com/smarkuck/nes/nes/ppu"
+)
+
+const (
+	ramSize       = 0x0800
+	ppuAddr       = 0x2000
+	apuAddr       = 0x4000
+	oamDMAAddr    = 0x4014
+	ppuOAMData    = 0x2004
+	apuLastAddr   = 0x4017
+	cartridgeAddr = 0x4020
+	pageSize      = 0x100
+	ppuRegisters  = 0x2007
+	// extra alignment cycle on odd CPU cycles is omitted
+	oamDMACycles = 513
+)
+
+type bus struct {
+	ram       [ramSize]byte
+	ppu       ppu.PPU
+	apu       apu.APU
+	mapper    mapper.Mapper
+	observer  mapper.PPURegisterObserver
+	dmaCycles int
+	// called before every CPU access to sync the console
+	onAccess func()
+}
+
+func (b *bus) Read(addr uint16) byte {
+	b.onAccess()
+	return b.read(addr)
+}
+
+func (b *bus) read(addr uint16) byte {
+	switch {
+	case addr < ppuAddr:
+		return b.ram[addr%ramSize]
+	case addr < apuAddr:
+		return b.ppu.Read(addr)
+	case addr <= apuLastAddr:
+		return b.apu.Read(addr)
+	case addr >= cartridgeAddr:
+		return b.mapper.Read(addr)
+	}
+	return 0
+}
+
+func (b *bus) Write(addr uint16, value byte) {
+	b.onAccess()
+	b.write(addr, value)
+}
+
+func (b *bus) write(addr uint16, value byte) {
+	switch {
+	case addr < ppuAddr:
+		b.ram[addr%ramSize] = value
+	case addr < apuAddr:
+		b.writePPU(addr, value)
+	case addr == oamDMAAddr:
+		b.copyOAM(value)
+	case addr <= apuLastAddr:
+		b.apu.Write(addr, value)
+	case addr >= cartridgeAddr:
+		b.mapper.Write(addr, value)
+	}
+}
+
+// deviceBus is used by DMC, its fetches are not
+// CPU accesses
+type deviceBus struct {
+	*bus
+}
+
+func (d deviceBus) Read(addr uint16) byte {
+	return d.read(addr)
+}
+
+func (d deviceBus) Write(addr uint16, value byte) {
+	d.write(addr, value)
+}
+
+// mappers see PPU register writes on cartridge connector
+func (b *bus) writePPU(addr uint16, value byte) {
+	b.ppu.Write(addr, value)
+	if b.observer != nil {
+		b.observer.ObservePPURegister(addr&ppuRegisters, value)
+	}
+}
+
+func (b *bus) copyOAM(page byte) {
+	start := uint16(page) << 8
+	for i := uint16(0); i < pageSize; i++ {
+		b.ppu.Write(ppuOAMData, b.read(start+i))
+	}
+	b.dmaCycles = oamDMACycles
+}
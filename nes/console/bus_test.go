@@ -0,0 +1,103 @@
+package console_test
+
+import (
+	"github.com/smarkuck/nes/nes"
+	"github.com/smarkuck/nes/nes/cartridge"
+	. "github.com/smarkuck/nes/nes/console"
+	. "github.com/smarkuck/unittest"
+)
+
+const (
+	prg8KBBank = 0x2000
+
+	invalidOAMText   = "invalid OAM"
+	invalidPixelText = "invalid pixel"
+)
+
+// CPU waits in a loop
+var idleProgram = []byte{0x4c, 0x00, 0x80}
+
+func Test_Bus_MirrorRAM(t *T) {
+	b := newConsole(t, idleProgram, nes.NTSC).GetBus()
+	b.Write(0x1801, 0x12)
+
+	ExpectEq(t, b.Read(0x0001), 0x12, invalidMemoryText)
+	ExpectEq(t, b.Read(0x0801), 0x12, invalidMemoryText)
+}
+
+func Test_Bus_ReadCartridge(t *T) {
+	b := newConsole(t, idleProgram, nes.NTSC).GetBus()
+
+	ExpectEq(t, b.Read(0x8000), 0x4c, invalidMemoryText)
+	ExpectEq(t, b.Read(0xc000), 0x4c, invalidMemoryText)
+}
+
+func Test_Bus_CopyOAM(t *T) {
+	b := newConsole(t, idleProgram, nes.NTSC).GetBus()
+	for i := 0; i < 0x100; i++ {
+		b.Write(0x0200+uint16(i), byte(i))
+	}
+
+	b.Write(0x2003, 0x00)
+	b.Write(0x4014, 0x02)
+
+	for _, addr := range []byte{0x00, 0x01, 0x03, 0xff} {
+		b.Write(0x2003, addr)
+		ExpectEq(t, b.Read(0x2004), addr, invalidOAMText)
+	}
+}
+
+// MMC5 fetches background from CHR set B with 8x16
+// sprites, set B maps solid tiles and set A empty ones
+func newMMC5Program(ctrl byte) []byte {
+	return []byte{
+		0xa9, 0x00, 0x8d, 0x01, 0x51, // $E000 8 KB CHR mode
+		0xa9, 0x01, 0x8d, 0x2b, 0x51, //       set B bank 1
+		0xa9, 0x00, 0x8d, 0x27, 0x51, //       set A bank 0
+		0xa9, 0x3f, 0x8d, 0x06, 0x20, //       palette $3F03
+		0xa9, 0x03, 0x8d, 0x06, 0x20,
+		0xa9, 0x30, 0x8d, 0x07, 0x20,
+		0xa9, ctrl, 0x8d, 0x00, 0x20, //       PPUCTRL
+		0xa9, 0x0a, 0x8d, 0x01, 0x20, //       background on
+		0x4c, 0x2d, 0xe0,
+	}
+}
+
+func newMMC5Cartridge(program []byte) *cartridge.Cartridge {
+	prg := make([]byte, 2*prg8KBBank)
+	copy(prg[prg8KBBank:], program)
+	prg[0x3ffc], prg[0x3ffd] = 0x00, 0xe0
+	chr := make([]byte, 2*cartridge.CHRBankSize)
+	for i := cartridge.CHRBankSize; i < len(chr); i++ {
+		chr[i] = 0xff
+	}
+	return &cartridge.Cartridge{PRG: prg, CHR: chr, Mapper: 5}
+}
+
+func Test_Bus_MapperSeesPPURegisters(t *T) {
+	tests := []struct {
+		name  string
+		ctrl  byte
+		color uint16
+	}{
+		{"SmallSprites", 0x00, 0x0f},
+		{"LargeSprites", 0x20, 0x30},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			n, err := NewConsole(
+				newMMC5Cartridge(newMMC5Program(test.ctrl)))
+			ExpectTrue(t, err == nil, invalidErrorText)
+			n.GetBus().Write(0x2006, 0x3f)
+			n.GetBus().Write(0x2006, 0x00)
+			n.GetBus().Write(0x2007, 0x0f)
+			for i := 0; i < 3; i++ {
+				n.StepFrame()
+			}
+
+			ExpectEq(t, n.GetFrame()[100*256+100], test.color,
+				invalidPixelText)
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package console
+
+import (
+	"io"
+	"time"
+
+	"github.com/smarkuck/nes/nes"
+	"github.com/smarkuck/nes/nes/apu"
+	"github.com/smarkuck/nes/nes/cartridge"
+	"github.com/smarkuck/nes/nes/cpu"
+	"github.com/smarkuck/nes/nes/mapper"
+	"github.com/smarkuck/nes/nes/ppu"
+	"github.com/smarkuck/nes/nes/save"
+)
+
+// master clock in Hz and its dividers
+type clock struct {
+	master     float64
+	cpuDivider int
+	ppuDivider int
+}
+
+// PAL PPU makes 3.2 dots per CPU cycle, Dendy uses PAL
+// master clock with NTSC ratio
+var regionClocks = map[nes.Region]clock{
+	nes.NTSC:  {21477272, 12, 4},
+	nes.PAL:   {26601712, 16, 5},
+	nes.Dendy: {26601712, 15, 5},
+}
+
+type Console interface {
+	nes.AudioSource
+	Tick()
+	StepFrame() error
+	GetFrame() *ppu.Frame
+	GetRegion() nes.Region
+	GetBus() nes.Bus
+	Close() error
+}
+
+type console struct {
+	clock
+	bus      *bus
+	cpuBus   nes.Bus
+	cpu      cpu.CPU
+	mixer    apu.Mixer
+	region   nes.Region
+	ppuClock int
+	save     save.Manager
+	// devices run ahead of CPU within current instruction
+	aheadCycles int
+	accesses    int
+	isCPUCycle  bool
+}
+
+// NewConsole runs cartridge in region from its header
+func NewConsole(c *cartridge.Cartridge) (Console, error) {
+	return NewRegionConsole(c, c.Region)
+}
+
+// NewRegionConsole ignores region from cartridge header
+func NewRegionConsole(c *cartridge.Cartridge,
+	region nes.Region) (Console, error) {
+	m, err := mapper.New(c)
+	if err != nil {
+		return nil, err
+	}
+	return NewMapperConsole(m, Options{Region: region})
+}
+
+// Options WrapBus puts cheats or debuggers between CPU
+// and its bus, nil leaves the bus as is. Battery save is
+// kept in Storage under SaveName, nil Storage disables it.
+// NoSpriteLimit draws all sprites on scanline, overflow
+// flag is still set as on hardware
+type Options struct {
+	Region        nes.Region
+	WrapBus       func(nes.Bus) nes.Bus
+	Storage       save.Storage
+	SaveName      string
+	Autosave      time.Duration
+	NoSpriteLimit bool
+}
+
+// NewMapperConsole runs any mapper like FDS RAM adapter
+// or cartridge built by the caller, save is loaded now
+func NewMapperConsole(m mapper.Mapper,
+	o Options) (Console, error) {
+	b := &bus{mapper: m, onAccess: func() {}}
+	b.observer, _ = m.(mapper.PPURegisterObserver)
+	b.ppu = ppu.NewPPU(ppu.NewBus(m), o.Region)
+	b.ppu.SetSpriteLimit(!o.NoSpriteLimit)
+	b.apu = apu.NewAPU(deviceBus{b}, o.Region)
+	n := &console{
+		clock:  regionClocks[o.Region],
+		bus:    b,
+		cpuBus: b,
+		mixer:  apu.NewMixer(),
+		region: o.Region,
+	}
+	if o.WrapBus != nil {
+		n.cpuBus = o.WrapBus(b)
+	}
+	n.cpu = cpu.NewCPU6502(n.cpuBus)
+	b.onAccess = n.syncAccess
+	n.connect(m)
+	if err := n.openSave(m, o); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func (n *console) openSave(m mapper.Mapper, o Options) error {
+	p, ok := m.(mapper.SaveRAMProvider)
+	if !ok || o.Storage == nil {
+		return nil
+	}
+	var err error
+	n.save, err = save.Open(o.Storage, o.SaveName,
+		p.GetSaveRAM(), o.Autosave)
+	return err
+}
+
+func (n *console) connect(m mapper.Mapper) {
+	n.cpu.AddNMISource(n.bus.ppu)
+	n.cpu.AddIRQSource(n.bus.apu)
+	if s, ok := m.(nes.IRQSource); ok {
+		n.cpu.AddIRQSource(s)
+	}
+	if s, ok := m.(nes.AudioSource); ok {
+		n.mixer.AddSource(s)
+	}
+}
+
+// Tick runs one CPU cycle and PPU dots which fit in it
+func (n *console) Tick() {
+	if n.bus.dmaCycles > 0 {
+		n.bus.dmaCycles--
+		n.tickDevices()
+		return
+	}
+	n.accesses, n.isCPUCycle = 0, true
+	n.cpu.Tick()
+	n.isCPUCycle = false
+	if n.aheadCycles > 0 {
+		n.aheadCycles--
+		return
+	}
+	n.tickDevices()
+}
+
+// CPU executes whole instruction in its first cycle, but
+// real one accesses bus once per cycle. Devices are run
+// ahead to every access after the first, so registers
+// like PPUSTATUS are read at the right dot
+func (n *console) syncAccess() {
+	if !n.isCPUCycle {
+		return
+	}
+	if n.accesses > 0 {
+		n.tickDevices()
+		n.aheadCycles++
+		n.cpu.PollNMI()
+	}
+	n.accesses++
+}
+
+func (n *console) tickDevices() {
+	n.bus.apu.Tick()
+	n.bus.mapper.Tick()
+	for n.ppuClock += n.cpuDivider; n.ppuClock >= n.ppuDivider; {
+		n.ppuClock -= n.ppuDivider
+		n.bus.ppu.Tick()
+	}
+}
+
+// StepFrame autosaves after emulated time passes
+func (n *console) StepFrame() error {
+	frame, cycles := n.bus.ppu.GetFrameCount(), 0
+	for ; n.bus.ppu.GetFrameCount() == frame; cycles++ {
+		n.Tick()
+	}
+	if n.save == nil {
+		return nil
+	}
+	return n.save.Update(n.getDuration(cycles))
+}
+
+func (n *console) getDuration(cycles int) time.Duration {
+	seconds := float64(cycles*n.cpuDivider) / n.master
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// Close flushes battery save and closes mapper holding
+// its own save like FDS disk
+func (n *console) Close() error {
+	var err error
+	if n.save != nil {
+		err = n.save.Close()
+	}
+	if c, ok := n.bus.mapper.(io.Closer); ok {
+		if closeErr := c.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+func (n *console) GetFrame() *ppu.Frame {
+	return n.bus.ppu.GetFrame()
+}
+
+func (n *console) GetRegion() nes.Region {
+	return n.region
+}
+
+// GetBus gives CPU address space as CPU sees it,
+// including bus wrappers
+func (n *console) GetBus() nes.Bus {
+	return n.cpuBus
+}
+
+func (n *console) GetAudioOutput() float32 {
+	return n.mixer.Mix(n.bus.apu.GetChannels())
+}
@@ -0,0 +1,331 @@
+package console_test
+
+import (
+	"time"
+
+	"github.com/smarkuck/nes/nes"
+	"github.com/smarkuck/nes/nes/cartridge"
+	"github.com/smarkuck/nes/nes/cheat"
+	. "github.com/smarkuck/nes/nes/console"
+	"github.com/smarkuck/nes/nes/fds"
+	"github.com/smarkuck/nes/nes/mapper"
+	"github.com/smarkuck/nes/nes/ppu"
+	"github.com/smarkuck/nes/nes/save"
+	. "github.com/smarkuck/unittest"
+)
+
+const (
+	invalidCountText  = "invalid count"
+	invalidMemoryText = "invalid memory"
+	invalidErrorText  = "invalid error"
+)
+
+// counts loop iterations in $00-$01, every one takes
+// 8 cycles, every 256th 7 more
+var counterProgram = []byte{
+	0xe6, 0x00, //       $8000 INC $00
+	0xd0, 0xfc, //             BNE $8000
+	0xe6, 0x01, //             INC $01
+	0x4c, 0x00, 0x80, //       JMP $8000
+}
+
+// NMI handler counts frames in $00
+var nmiProgram = []byte{
+	0xa9, 0x80, //       $8000 LDA #$80
+	0x8d, 0x00, 0x20, //       STA $2000
+	0x4c, 0x05, 0x80, //       JMP $8005
+	0xe6, 0x00, //       $8008 INC $00
+	0x40, //                   RTI
+}
+
+func newCartridge(program []byte) *cartridge.Cartridge {
+	prg := make([]byte, cartridge.PRGBankSize)
+	copy(prg, program)
+	prg[0x3ffa], prg[0x3ffb] = 0x08, 0x80
+	prg[0x3ffc], prg[0x3ffd] = 0x00, 0x80
+	return &cartridge.Cartridge{PRG: prg}
+}
+
+func newConsole(t *T, program []byte, region nes.Region) Console {
+	n, err := NewRegionConsole(newCartridge(program), region)
+	ExpectTrue(t, err == nil, invalidErrorText)
+	return n
+}
+
+func getCounter(n Console) int {
+	b := n.GetBus()
+	return int(b.Read(0x01))<<8 | int(b.Read(0x00))
+}
+
+// frame has 341*262 dots on NTSC and 341*312 on PAL
+// and Dendy, counts are rounded
+func Test_Console_CPUCyclesPerFrame(t *T) {
+	tests := []struct {
+		region nes.Region
+		cycles float64
+	}{
+		{nes.NTSC, 341 * 262 / 3.0},
+		{nes.PAL, 341 * 312 / 3.2},
+		{nes.Dendy, 341 * 312 / 3.0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.region.String(), func(t *T) {
+			n := newConsole(t, counterProgram, test.region)
+			n.StepFrame()
+			start := getCounter(n)
+			n.StepFrame()
+
+			count := getCounter(n) - start
+			expected := int(test.cycles*256/(256*8+7) + 0.5)
+			ExpectTrue(t, count >= expected-1 &&
+				count <= expected+1, invalidCountText)
+		})
+	}
+}
+
+func Test_Console_GenerateNMI(t *T) {
+	n := newConsole(t, nmiProgram, nes.NTSC)
+
+	for i := 0; i < 3; i++ {
+		n.StepFrame()
+	}
+
+	ExpectEq(t, n.GetBus().Read(0x00), 3, invalidMemoryText)
+}
+
+func Test_Console_UseCartridgeRegion(t *T) {
+	c := newCartridge(nmiProgram)
+	c.Region = nes.Dendy
+	n, _ := NewConsole(c)
+
+	ExpectEq(t, n.GetRegion(), nes.Dendy, invalidCountText)
+}
+
+func Test_Console_WhenMapperUnsupported_ReturnError(t *T) {
+	c := newCartridge(nmiProgram)
+	c.Mapper = 1000
+	_, err := NewConsole(c)
+
+	ExpectTrue(t, err != nil, invalidErrorText)
+	ExpectEq(t, err.Error(), "unsupported mapper: 1000",
+		invalidErrorText)
+}
+
+func Test_Console_WrapCPUBus(t *T) {
+	var f cheat.Freezer
+	m, _ := mapper.New(newCartridge([]byte{
+		0xa5, 0x10, //       $8000 LDA $10
+		0x85, 0x00, //             STA $00
+		0x4c, 0x04, 0x80, //       JMP $8004
+	}))
+	n, _ := NewMapperConsole(m, Options{
+		WrapBus: func(b nes.Bus) nes.Bus {
+			f = cheat.NewFreezer(b, cheat.InterceptReads)
+			return f
+		},
+	})
+	f.Freeze(0x10, 0x42)
+
+	n.StepFrame()
+
+	ExpectEq(t, n.GetBus().Read(0x00), 0x42, invalidMemoryText)
+	ExpectEq(t, n.GetBus().Read(0x10), 0x42, invalidMemoryText)
+}
+
+// BIOS stub runs from $E000 and writes to PRG-RAM
+func Test_Console_RunFDS(t *T) {
+	bios := make([]byte, 0x2000)
+	copy(bios, []byte{
+		0xa9, 0x55, //       $E000 LDA #$55
+		0x8d, 0x00, 0x60, //       STA $6000
+		0x4c, 0x05, 0xe0, //       JMP $E005
+	})
+	bios[0x1ffc], bios[0x1ffd] = 0x00, 0xe0
+	d, _ := fds.LoadDisk(make([]byte, 65500), nil)
+	f, err := fds.New(bios, d)
+	ExpectTrue(t, err == nil, invalidErrorText)
+	n, _ := NewMapperConsole(f, Options{})
+
+	n.StepFrame()
+
+	ExpectEq(t, n.GetBus().Read(0x6000), 0x55, invalidMemoryText)
+}
+
+// program stores $99 in battery RAM
+func newSaveConsole(t *T, s save.Storage,
+	autosave time.Duration) Console {
+	c := newCartridge([]byte{
+		0xa9, 0x99, //       $8000 LDA #$99
+		0x8d, 0x00, 0x60, //       STA $6000
+		0x4c, 0x05, 0x80, //       JMP $8005
+	})
+	c.Battery, c.PRGRAMSize = true, 0x2000
+	m, _ := mapper.New(c)
+	n, err := NewMapperConsole(m, Options{
+		Storage: s, SaveName: "game", Autosave: autosave,
+	})
+	ExpectTrue(t, err == nil, invalidErrorText)
+	return n
+}
+
+func Test_Console_LoadSave(t *T) {
+	s := save.NewMemoryStorage()
+	s.Store("game", []byte{0x00, 0x12})
+	n := newSaveConsole(t, s, 0)
+
+	ExpectEq(t, n.GetBus().Read(0x6001), 0x12, invalidMemoryText)
+}
+
+func Test_Console_StoreSaveOnClose(t *T) {
+	s := save.NewMemoryStorage()
+	n := newSaveConsole(t, s, 0)
+	n.StepFrame()
+	data, _ := s.Load("game")
+	ExpectEq(t, len(data), 0, invalidMemoryText)
+
+	ExpectTrue(t, n.Close() == nil, invalidErrorText)
+	data, _ = s.Load("game")
+	ExpectEq(t, data[0], 0x99, invalidMemoryText)
+}
+
+// frame lasts about 16.6 ms
+func Test_Console_Autosave(t *T) {
+	s := save.NewMemoryStorage()
+	n := newSaveConsole(t, s, 30*time.Millisecond)
+	n.StepFrame()
+	data, _ := s.Load("game")
+	ExpectEq(t, len(data), 0, invalidMemoryText)
+
+	ExpectTrue(t, n.StepFrame() == nil, invalidErrorText)
+	data, _ = s.Load("game")
+	ExpectEq(t, data[0], 0x99, invalidMemoryText)
+}
+
+type closingMapper struct {
+	mapper.Mapper
+	isClosed bool
+}
+
+func (m *closingMapper) Close() error {
+	m.isClosed = true
+	return nil
+}
+
+func Test_Console_CloseMapper(t *T) {
+	m, _ := mapper.New(newCartridge(idleProgram))
+	c := &closingMapper{Mapper: m}
+	n, _ := NewMapperConsole(c, Options{})
+
+	ExpectTrue(t, n.Close() == nil, invalidErrorText)
+	ExpectTrue(t, c.isClosed, "mapper not closed")
+}
+
+type tickingMapper struct {
+	mapper.Mapper
+	ticks  int
+	readAt int
+}
+
+func (m *tickingMapper) Read(addr uint16) byte {
+	if addr == 0x6000 {
+		m.readAt = m.ticks
+	}
+	return m.Mapper.Read(addr)
+}
+
+func (m *tickingMapper) Tick() {
+	m.ticks++
+}
+
+// LDA absolute reads operand in its fourth cycle
+func Test_Console_AccessBusAtInstructionCycle(t *T) {
+	m, _ := mapper.New(newCartridge([]byte{
+		0xad, 0x00, 0x60, // $8000 LDA $6000
+		0x4c, 0x03, 0x80, //       JMP $8003
+	}))
+	c := &tickingMapper{Mapper: m}
+	n, _ := NewMapperConsole(c, Options{})
+
+	n.Tick()
+	ExpectEq(t, c.readAt, 3, invalidCountText)
+	for i := 0; i < 3; i++ {
+		n.Tick()
+	}
+	ExpectEq(t, c.ticks, 4, invalidCountText)
+}
+
+// page crossing LDA reads unfixed address in fourth cycle
+// and operand in fifth, after two cycles of LDX
+func Test_Console_AccessBusAfterDummyRead(t *T) {
+	m, _ := mapper.New(newCartridge([]byte{
+		0xa2, 0x01, //       $8000 LDX #1
+		0xbd, 0xff, 0x5f, //       LDA $5FFF,X
+		0x4c, 0x05, 0x80, //       JMP $8005
+	}))
+	c := &tickingMapper{Mapper: m}
+	n, _ := NewMapperConsole(c, Options{})
+
+	n.Tick()
+	n.Tick()
+	n.Tick()
+
+	ExpectEq(t, c.readAt, 6, invalidCountText)
+}
+
+// twelve solid sprites are put on one line, ninth and
+// later are drawn only without limit
+func Test_Console_SpriteLimit(t *T) {
+	tests := []struct {
+		name          string
+		noSpriteLimit bool
+		color         uint16
+	}{
+		{"Limited", false, 0x0f},
+		{"Unlimited", true, 0x16},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			m, _ := mapper.New(newCartridge(idleProgram))
+			n, _ := NewMapperConsole(m, Options{
+				NoSpriteLimit: test.noSpriteLimit,
+			})
+			setSprites(n.GetBus(), 12)
+
+			n.StepFrame()
+			n.StepFrame()
+
+			frame := n.GetFrame()
+			ExpectEq(t, frame[10*ppu.Width+95], test.color,
+				invalidPixelText)
+		})
+	}
+}
+
+// tile 1 is solid, sprite palette draws it with $16
+func setSprites(b nes.Bus, count int) {
+	writeVRAM(b, 0x0010, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff)
+	writeVRAM(b, 0x3f00, 0x0f)
+	writeVRAM(b, 0x3f11, 0x16)
+	b.Write(0x2003, 0)
+	for i := 0; i < 64; i++ {
+		sprite := []byte{0xff, 0, 0, 0}
+		if i < count {
+			sprite = []byte{9, 1, 0, byte(8 * i)}
+		}
+		for _, value := range sprite {
+			b.Write(0x2004, value)
+		}
+	}
+	b.Write(0x2001, 0x14)
+}
+
+func writeVRAM(b nes.Bus, addr uint16, data ...byte) {
+	b.Write(0x2006, byte(addr>>8))
+	b.Write(0x2006, byte(addr))
+	for _, value := range data {
+		b.Write(0x2007, value)
+	}
+}
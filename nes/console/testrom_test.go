@@ -0,0 +1,136 @@
+package console_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+
+	"github.com/smarkuck/nes/nes/cartridge"
+	. "github.com/smarkuck/nes/nes/console"
+	. "github.com/smarkuck/unittest"
+)
+
+const (
+	testROMStatus    = 0x6000
+	testROMSignature = 0x6001
+	testROMText      = 0x6004
+	testROMRunning   = 0x80
+	testROMReset     = 0x81
+	maxTestROMFrames = 60 * 60
+	maxTestROMText   = 0x1000
+
+	invalidResultText = "invalid test ROM result"
+)
+
+var signature = []byte{0xde, 0xb0, 0x61}
+
+// runTestROM follows blargg's protocol, $6001-$6003 hold
+// signature, $6000 is result code when test is done and
+// $6004 zero terminated text
+func runTestROM(t *T, n Console) (byte, string) {
+	b := n.GetBus()
+	for i := 0; i < maxTestROMFrames; i++ {
+		n.StepFrame()
+		if !hasSignature(n) {
+			continue
+		}
+		switch status := b.Read(testROMStatus); status {
+		case testROMRunning:
+		case testROMReset:
+			t.Fatal("test ROM needs reset")
+		default:
+			return status, readText(n)
+		}
+	}
+	t.Fatal("test ROM timed out")
+	return 0, ""
+}
+
+func hasSignature(n Console) bool {
+	for i, value := range signature {
+		addr := testROMSignature + uint16(i)
+		if n.GetBus().Read(addr) != value {
+			return false
+		}
+	}
+	return true
+}
+
+func readText(n Console) string {
+	var text bytes.Buffer
+	for i := uint16(0); i < maxTestROMText; i++ {
+		c := n.GetBus().Read(testROMText + i)
+		if c == 0 {
+			break
+		}
+		text.WriteByte(c)
+	}
+	return text.String()
+}
+
+// program writes result in the way test ROMs do
+func newTestROMProgram(status byte, text string) []byte {
+	var program []byte
+	store := func(addr uint16, value byte) {
+		program = append(program, 0xa9, value,
+			0x8d, byte(addr), byte(addr>>8))
+	}
+	store(testROMStatus, testROMRunning)
+	for i, value := range signature {
+		store(testROMSignature+uint16(i), value)
+	}
+	for i, c := range []byte(text + "\x00") {
+		store(testROMText+uint16(i), c)
+	}
+	store(testROMStatus, status)
+	end := 0x8000 + uint16(len(program))
+	return append(program, 0x4c, byte(end), byte(end>>8))
+}
+
+func Test_TestROM_ReadResult(t *T) {
+	tests := []struct {
+		name   string
+		status byte
+		text   string
+	}{
+		{"Passed", 0, "Passed"},
+		{"Failed", 3, "Failed #3"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			c := newCartridge(
+				newTestROMProgram(test.status, test.text))
+			c.PRGRAMSize = 0x2000
+			n, err := NewConsole(c)
+			ExpectTrue(t, err == nil, invalidErrorText)
+
+			status, text := runTestROM(t, n)
+			ExpectEq(t, status, test.status, invalidResultText)
+			ExpectEq(t, text, test.text, invalidResultText)
+		})
+	}
+}
+
+// ROMs are not distributed with the repository, the suite
+// runs when they are copied to testdata/ppu_vbl_nmi
+func Test_TestROM_PPUVBLNMI(t *T) {
+	paths, _ := filepath.Glob("testdata/ppu_vbl_nmi/*.nes")
+	if len(paths) == 0 {
+		t.Skip("ppu_vbl_nmi ROMs not found")
+	}
+
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *T) {
+			data, err := os.ReadFile(path)
+			ExpectTrue(t, err == nil, invalidErrorText)
+			c, err := cartridge.Load(data)
+			ExpectTrue(t, err == nil, invalidErrorText)
+			n, err := NewConsole(c)
+			ExpectTrue(t, err == nil, invalidErrorText)
+
+			status, text := runTestROM(t, n)
+			ExpectEq(t, status, 0, text)
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/smarkuck/nes/nes"
 	"github.com/smarkuck/nes/nes/cpu/byteutil"
 	"github.com/smarkuck/nes/nes/cpu/instruction"
+	"github.com/smarkuck/nes/nes/cpu/instruction/cmd"
 	"github.com/smarkuck/nes/nes/cpu/state"
 )
 
@@ -15,6 +16,8 @@ const (
 
 	invalidCyclesFormat = "encountered instruction needs " +
 		"0 cycles to execute: " + byteutil.HexByte
+
+	interruptCycles = 7
 )
 
 type instr = instruction.Instruction
@@ -22,6 +25,10 @@ type instr = instruction.Instruction
 type CPU interface {
 	Tick()
 	Reset()
+	Jump(addr uint16)
+	AddIRQSource(nes.IRQSource)
+	AddNMISource(nes.NMISource)
+	PollNMI()
 	GetState() *state.State
 	GetRemainingCycles() uint8
 }
@@ -30,6 +37,12 @@ type cpu struct {
 	Instructions
 	state.State
 	remainingCycles uint8
+	irq             instr
+	irqSources      []nes.IRQSource
+	nmi             instr
+	nmiSources      []nes.NMISource
+	isNMILine       bool
+	isNMIPending    bool
 }
 
 type Instructions map[byte]instr
@@ -37,6 +50,8 @@ type Instructions map[byte]instr
 func NewCPU(b nes.Bus, i Instructions) CPU {
 	c := new(cpu)
 	c.Bus, c.Instructions = b, i
+	c.irq = instruction.NewInterrupt(cmd.IRQ, interruptCycles)
+	c.nmi = instruction.NewInterrupt(cmd.NMI, interruptCycles)
 	c.Reset()
 	return c
 }
@@ -44,17 +59,78 @@ func NewCPU(b nes.Bus, i Instructions) CPU {
 func (c *cpu) Reset() {
 	c.State.Reset()
 	c.remainingCycles = 0
+	c.isNMIPending = false
+}
+
+// Jump aborts current instruction and continues at addr
+// keeping registers, stack and flags
+func (c *cpu) Jump(addr uint16) {
+	c.ProgramCounter = addr
+	c.remainingCycles = 0
+}
+
+func (c *cpu) AddIRQSource(s nes.IRQSource) {
+	c.irqSources = append(c.irqSources, s)
+}
+
+func (c *cpu) AddNMISource(s nes.NMISource) {
+	c.nmiSources = append(c.nmiSources, s)
 }
 
+// interrupt is chosen from line state polled before the
+// last cycle of previous instruction, NMI raised in that
+// cycle waits for one more instruction
 func (c *cpu) Tick() {
 	if c.remainingCycles == 0 {
-		code, instr := c.getInstruction()
-		instr.Execute(&c.State)
-		c.updateCycles(code, instr)
+		c.executeNext()
 	}
+	c.PollNMI()
 	c.remainingCycles--
 }
 
+// NMI is edge triggered, line is sampled every cycle so
+// short pulses during an instruction are not lost, Tick
+// polls it too
+func (c *cpu) PollNMI() {
+	isNMILine := false
+	for _, s := range c.nmiSources {
+		isNMILine = isNMILine || s.IsNMI()
+	}
+	if isNMILine && !c.isNMILine {
+		c.isNMIPending = true
+	}
+	c.isNMILine = isNMILine
+}
+
+func (c *cpu) executeNext() {
+	if c.isNMIPending {
+		c.isNMIPending = false
+		c.nmi.Execute(&c.State)
+		c.remainingCycles = c.nmi.GetCycles()
+		return
+	}
+	if c.isIRQPending() {
+		c.irq.Execute(&c.State)
+		c.remainingCycles = c.irq.GetCycles()
+		return
+	}
+	code, instr := c.getInstruction()
+	instr.Execute(&c.State)
+	c.updateCycles(code, instr)
+}
+
+func (c *cpu) isIRQPending() bool {
+	if c.Status&state.InterruptDisable != 0 {
+		return false
+	}
+	for _, s := range c.irqSources {
+		if s.IsIRQ() {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *cpu) getInstruction() (byte, instr) {
 	code := c.ReadInstructionCode()
 	if i, ok := c.Instructions[code]; ok {
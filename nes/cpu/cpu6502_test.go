@@ -1,6 +1,8 @@
 package cpu_test
 
 import (
+	"fmt"
+
 	"github.com/smarkuck/nes/nes/cpu"
 	. "github.com/smarkuck/nes/nes/cpu/testutil"
 	. "github.com/smarkuck/unittest"
@@ -14,6 +16,7 @@ const (
 	loadA, loadACycles                       = 0xa9, 2
 	loadX, loadXCycles                       = 0xa2, 2
 	loadY, loadYCycles                       = 0xa4, 3
+	loadYImmediate, loadYImmediateCycles     = 0xa0, 2
 	storeA, storeACycles                     = 0x85, 3
 	storeX, storeXCycles                     = 0x86, 3
 	bonusBranchCycle                         = 1
@@ -82,3 +85,50 @@ func Test_CPU6502_StartNextInstructionAfterProgram(t *T) {
 
 	ExpectProgramCounterEq(t, cpu.GetState(), 0x0000)
 }
+
+type countingBus struct {
+	TestBus
+	accesses int
+}
+
+func (c *countingBus) Read(addr uint16) byte {
+	c.accesses++
+	return c.TestBus.Read(addr)
+}
+
+func (c *countingBus) Write(addr uint16, value byte) {
+	c.accesses++
+	c.TestBus.Write(addr, value)
+}
+
+// index 1 makes indexed modes cross page and flips taken
+// branches, real CPU accesses bus in every cycle
+func Test_CPU6502_AccessBusOncePerCycle(t *T) {
+	for code := 0; code < 0x100; code++ {
+		for index := byte(0); index < 2; index++ {
+			accesses, cycles, ok := runInstruction(byte(code), index)
+			if ok {
+				ExpectEq(t, accesses, cycles,
+					fmt.Sprintf("%#02x with index %d", code, index))
+			}
+		}
+	}
+}
+
+// unknown opcodes panic and are skipped
+func runInstruction(code, index byte) (accesses, cycles int,
+	ok bool) {
+	bus := &countingBus{TestBus: NewTestBusResetPrg(prgAddr,
+		Program{loadX, index, loadYImmediate, index,
+			code, 0xff, 0x12})}
+	c := cpu.NewCPU6502(bus)
+	for i := 0; i < loadXCycles+loadYImmediateCycles; i++ {
+		c.Tick()
+	}
+	bus.accesses = 0
+	defer func() {
+		ok = recover() == nil
+	}()
+	c.Tick()
+	return bus.accesses, int(c.GetRemainingCycles()) + 1, true
+}
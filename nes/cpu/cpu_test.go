@@ -5,6 +5,8 @@ import (
 
 	. "github.com/smarkuck/nes/nes/cpu"
 	"github.com/smarkuck/nes/nes/cpu/byteutil"
+	"github.com/smarkuck/nes/nes/cpu/instruction"
+	"github.com/smarkuck/nes/nes/cpu/instruction/cmd"
 	"github.com/smarkuck/nes/nes/cpu/state"
 	. "github.com/smarkuck/nes/nes/cpu/testutil"
 	. "github.com/smarkuck/unittest"
@@ -12,11 +14,15 @@ import (
 
 const (
 	resetPrgAddr = 0x1050
+	irqPrgAddr   = 0x20a0
+	nmiPrgAddr   = 0x30b0
 	address      = 0x1060
 	code         = 0x07
 	value        = 0xea
 	cycles       = 13
 
+	interruptCycles = 7
+
 	invalidRemainingCyclesText = "invalid remaining cycles"
 	invalidExecCountText       = "invalid number of executions"
 	invalidErrorText           = "invalid error message"
@@ -71,6 +77,20 @@ func (stateModifier) GetCycles() uint8 {
 	return cycles
 }
 
+type irqSource bool
+
+func (i irqSource) IsIRQ() bool {
+	return bool(i)
+}
+
+type nmiSource struct {
+	isNMI bool
+}
+
+func (n *nmiSource) IsNMI() bool {
+	return n.isNMI
+}
+
 func expectRemainingCyclesEq(t *T, cpu CPU, value uint8) {
 	ExpectEq(t, cpu.GetRemainingCycles(), value,
 		invalidRemainingCyclesText)
@@ -199,3 +219,151 @@ func (s cpuSuite) OnReset_RestoreInitialState(t *T) {
 		cpu.GetState(), NewInitState(resetPrgAddr, s.bus))
 	expectRemainingCyclesEq(t, cpu, 0)
 }
+
+func (s cpuSuite) OnJump_KeepStateAndRunFromAddress(t *T) {
+	sm := stateModifier{value}
+	cpu := s.newCPU(Instructions{code: sm})
+
+	cpu.Tick()
+	cpu.Jump(address)
+
+	expected := NewState(value, s.bus)
+	expected.ProgramCounter = address
+	ExpectStateEq(t, cpu.GetState(), expected)
+	expectRemainingCyclesEq(t, cpu, 0)
+}
+
+func (s cpuSuite) loadIRQVector() {
+	s.bus[IRQVector] = byteutil.GetLow(irqPrgAddr)
+	s.bus[IRQVector+1] = byteutil.GetHigh(irqPrgAddr)
+}
+
+func (s cpuSuite) WhenIRQActive_RunInterrupt(t *T) {
+	s.loadIRQVector()
+	cli := instruction.NewImplied(cmd.CLI, 2)
+	cpu := s.newCPU(Instructions{code: cli})
+	cpu.AddIRQSource(irqSource(false))
+	cpu.AddIRQSource(irqSource(true))
+
+	cpu.Tick()
+	cpu.Tick()
+	cpu.Tick()
+
+	ExpectProgramCounterEq(t, cpu.GetState(), irqPrgAddr)
+	expectRemainingCyclesEq(t, cpu, 6)
+}
+
+func (s cpuSuite) WhenIRQInactive_ExecNextInstr(t *T) {
+	s.loadIRQVector()
+	cli := instruction.NewImplied(cmd.CLI, 2)
+	cpu := s.newCPU(Instructions{code: cli})
+	cpu.AddIRQSource(irqSource(false))
+	s.bus[resetPrgAddr+1] = code
+
+	cpu.Tick()
+	cpu.Tick()
+	cpu.Tick()
+
+	ExpectProgramCounterEq(t, cpu.GetState(), resetPrgAddr+2)
+}
+
+func (s cpuSuite) WhenInterruptDisabled_IgnoreIRQ(t *T) {
+	checker := &execChecker{cycles: cycles}
+	cpu := s.newCPU(Instructions{code: checker})
+	cpu.AddIRQSource(irqSource(true))
+
+	cpu.Tick()
+
+	checker.expectExecCountEq(t, 1)
+}
+
+func (s cpuSuite) loadNMIVector() {
+	s.bus[NMIVector] = byteutil.GetLow(nmiPrgAddr)
+	s.bus[NMIVector+1] = byteutil.GetHigh(nmiPrgAddr)
+}
+
+func (s cpuSuite) WhenNMILineRises_RunInterrupt(t *T) {
+	s.loadNMIVector()
+	checker := &execChecker{cycles: 2}
+	cpu := s.newCPU(Instructions{code: checker})
+	nmi := &nmiSource{}
+	cpu.AddNMISource(nmi)
+
+	cpu.Tick()
+	nmi.isNMI = true
+	cpu.Tick()
+	cpu.Tick()
+
+	ExpectProgramCounterEq(t, cpu.GetState(), nmiPrgAddr)
+	expectRemainingCyclesEq(t, cpu, 6)
+}
+
+func (s cpuSuite) WhenNMILineStaysActive_RunInterruptOnce(t *T) {
+	s.loadNMIVector()
+	s.bus[nmiPrgAddr] = code
+	checker := &execChecker{cycles: 2}
+	cpu := s.newCPU(Instructions{code: checker})
+	cpu.AddNMISource(&nmiSource{true})
+
+	for i := 0; i < 2+interruptCycles+3*2; i++ {
+		cpu.Tick()
+	}
+
+	ExpectProgramCounterEq(t, cpu.GetState(), nmiPrgAddr)
+	checker.expectExecCountEq(t, 4)
+}
+
+func (s cpuSuite) WhenNMIRaisedInLastCycle_RunInterruptAfterNextInstr(t *T) {
+	s.loadNMIVector()
+	checker := &execChecker{cycles: 2}
+	cpu := s.newCPU(Instructions{code: checker})
+	nmi := &nmiSource{}
+	cpu.AddNMISource(nmi)
+
+	cpu.Tick()
+	cpu.Tick()
+	nmi.isNMI = true
+	cpu.Tick()
+	cpu.Tick()
+
+	ExpectProgramCounterEq(t, cpu.GetState(), resetPrgAddr)
+	checker.expectExecCountEq(t, 2)
+	cpu.Tick()
+	ExpectProgramCounterEq(t, cpu.GetState(), nmiPrgAddr)
+}
+
+func (s cpuSuite) WhenNMIPulseDuringInstr_RunInterruptAfter(t *T) {
+	s.loadNMIVector()
+	checker := &execChecker{cycles: 3}
+	cpu := s.newCPU(Instructions{code: checker})
+	nmi := &nmiSource{}
+	cpu.AddNMISource(nmi)
+
+	cpu.Tick()
+	nmi.isNMI = true
+	cpu.Tick()
+	nmi.isNMI = false
+	cpu.Tick()
+	cpu.Tick()
+
+	ExpectProgramCounterEq(t, cpu.GetState(), nmiPrgAddr)
+	checker.expectExecCountEq(t, 1)
+}
+
+func (s cpuSuite) WhenNMIPulseBetweenTicks_PollIt(t *T) {
+	s.loadNMIVector()
+	checker := &execChecker{cycles: 2}
+	cpu := s.newCPU(Instructions{code: checker})
+	nmi := &nmiSource{}
+	cpu.AddNMISource(nmi)
+
+	cpu.Tick()
+	nmi.isNMI = true
+	cpu.PollNMI()
+	nmi.isNMI = false
+	cpu.Tick()
+	cpu.Tick()
+
+	ExpectProgramCounterEq(t, cpu.GetState(), nmiPrgAddr)
+	checker.expectExecCountEq(t, 1)
+}
@@ -454,3 +454,38 @@ func Test_BinaryAddressedCommands(t *T) {
 		})
 	}
 }
+
+type writeRecorder struct {
+	TestBus
+	writes []byte
+}
+
+func (w *writeRecorder) Write(addr uint16, value byte) {
+	w.writes = append(w.writes, value)
+	w.TestBus.Write(addr, value)
+}
+
+func Test_ReadModifyWrite_WriteUnmodifiedValueFirst(t *T) {
+	tests := []struct {
+		name   string
+		cmd    Addressed
+		result byte
+	}{
+		{"ASL", ASL, 0xfa},
+		{"DEC", DEC, 0x7c},
+		{"INC", INC, 0x7e},
+		{"LSR", LSR, 0x3e},
+		{"ROL", ROL, 0xfa},
+		{"ROR", ROR, 0x3e},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			bus := &writeRecorder{TestBus: TestBus{}}
+			bus.TestBus[cellAddr] = value
+			test.cmd(&State{Bus: bus}, cellAddr)
+			ExpectDeepEq(t, bus.writes,
+				[]byte{value, test.result}, invalidBusText)
+		})
+	}
+}
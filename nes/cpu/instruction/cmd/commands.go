@@ -14,6 +14,8 @@ type Implied = func(*state.State)
 type Addressed = func(_ *state.State, addr uint16)
 type Relative = func(status byte) bool
 
+type modifier = func(_ *state.State, cell *byte)
+
 func ADC(s *state.State, addr uint16) {
 	add(s, s.Read(addr))
 }
@@ -24,9 +26,7 @@ func AND(s *state.State, addr uint16) {
 }
 
 func ASL(s *state.State, addr uint16) {
-	b := s.Read(addr)
-	asl(s, &b)
-	s.Write(addr, b)
+	modify(s, addr, asl)
 }
 
 func AccumASL(s *state.State) {
@@ -39,6 +39,15 @@ func asl(s *state.State, cell *byte) {
 	s.UpdateZeroNegative(*cell)
 }
 
+func modify(s *state.State, addr uint16, m modifier) {
+	b := s.Read(addr)
+	// read-modify-write instructions write the unmodified
+	// value back before the result, mappers can observe it
+	s.Write(addr, b)
+	m(s, &b)
+	s.Write(addr, b)
+}
+
 func BCC(status byte) bool {
 	return !state.IsCarry(status)
 }
@@ -121,9 +130,12 @@ func compare(s *state.State, a, b byte) {
 }
 
 func DEC(s *state.State, addr uint16) {
-	v := s.Read(addr) - 1
-	s.Write(addr, v)
-	s.UpdateZeroNegative(v)
+	modify(s, addr, dec)
+}
+
+func dec(s *state.State, cell *byte) {
+	*cell--
+	s.UpdateZeroNegative(*cell)
 }
 
 func DEX(s *state.State) {
@@ -142,9 +154,12 @@ func EOR(s *state.State, addr uint16) {
 }
 
 func INC(s *state.State, addr uint16) {
-	v := s.Read(addr) + 1
-	s.Write(addr, v)
-	s.UpdateZeroNegative(v)
+	modify(s, addr, inc)
+}
+
+func inc(s *state.State, cell *byte) {
+	*cell++
+	s.UpdateZeroNegative(*cell)
 }
 
 func INX(s *state.State) {
@@ -157,11 +172,19 @@ func INY(s *state.State) {
 	s.UpdateZeroNegative(s.RegisterY)
 }
 
+func IRQ(s *state.State) {
+	s.PushTwoBytesOnStack(s.ProgramCounter)
+	s.PushOnStack(s.Status &^ state.Break)
+	s.EnableFlags(state.InterruptDisable)
+	s.LoadIRQProgram()
+}
+
 func JMP(s *state.State, addr uint16) {
 	s.ProgramCounter = addr
 }
 
 func JSR(s *state.State, addr uint16) {
+	s.PeekStack()
 	s.PushTwoBytesOnStack(
 		s.ProgramCounter - subroutineOffset)
 	s.ProgramCounter = addr
@@ -183,9 +206,7 @@ func LDY(s *state.State, addr uint16) {
 }
 
 func LSR(s *state.State, addr uint16) {
-	b := s.Read(addr)
-	lsr(s, &b)
-	s.Write(addr, b)
+	modify(s, addr, lsr)
 }
 
 func AccumLSR(s *state.State) {
@@ -198,6 +219,13 @@ func lsr(s *state.State, cell *byte) {
 	s.UpdateZeroNegative(*cell)
 }
 
+func NMI(s *state.State) {
+	s.PushTwoBytesOnStack(s.ProgramCounter)
+	s.PushOnStack(s.Status &^ state.Break)
+	s.EnableFlags(state.InterruptDisable)
+	s.LoadNMIProgram()
+}
+
 func NOP(s *state.State) {}
 
 func ORA(s *state.State, addr uint16) {
@@ -214,19 +242,19 @@ func PHP(s *state.State) {
 }
 
 func PLA(s *state.State) {
+	s.PeekStack()
 	s.Accumulator = s.PullFromStack()
 	s.UpdateZeroNegative(s.Accumulator)
 }
 
 func PLP(s *state.State) {
+	s.PeekStack()
 	s.Status = s.PullFromStack()
 	s.EnableFlags(state.Break | state.Unused)
 }
 
 func ROL(s *state.State, addr uint16) {
-	b := s.Read(addr)
-	rol(s, &b)
-	s.Write(addr, b)
+	modify(s, addr, rol)
 }
 
 func AccumROL(s *state.State) {
@@ -241,9 +269,7 @@ func rol(s *state.State, cell *byte) {
 }
 
 func ROR(s *state.State, addr uint16) {
-	b := s.Read(addr)
-	ror(s, &b)
-	s.Write(addr, b)
+	modify(s, addr, ror)
 }
 
 func AccumROR(s *state.State) {
@@ -258,14 +284,18 @@ func ror(s *state.State, cell *byte) {
 }
 
 func RTI(s *state.State) {
+	s.PeekStack()
 	s.Status = s.PullFromStack()
 	s.EnableFlags(state.Break | state.Unused)
 	s.ProgramCounter = s.PullTwoBytesFromStack()
 }
 
+// return address is read before it is incremented
 func RTS(s *state.State) {
-	s.ProgramCounter =
-		s.PullTwoBytesFromStack() + subroutineOffset
+	s.PeekStack()
+	addr := s.PullTwoBytesFromStack()
+	s.Read(addr)
+	s.ProgramCounter = addr + subroutineOffset
 }
 
 func SBC(s *state.State, addr uint16) {
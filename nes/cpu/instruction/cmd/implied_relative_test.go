@@ -95,6 +95,42 @@ func Test_ImpliedCommands(t *T) {
 			env{RegisterY: 0xfe, Status: notNegStatus},
 			env{RegisterY: 0xff, Status: negStatus}},
 
+		{"IRQ_Interrupt_PushStatusWithoutBreak", IRQ,
+			env{ProgramCounter: prgAddr,
+				Status:   breakStatus &^ InterruptDisable,
+				StackPtr: InitStackPtr,
+				Memory: Memory{
+					IRQVector:     irqProgramLow,
+					IRQVector + 1: irqProgramHigh}},
+			env{ProgramCounter: irqProgram,
+				Status:   breakStatus | InterruptDisable,
+				StackPtr: InitStackPtr - 3,
+				Stack: Stack{
+					prgAddrHigh,
+					prgAddrLow,
+					(breakStatus &^ InterruptDisable) &^ Break},
+				Memory: Memory{
+					IRQVector:     irqProgramLow,
+					IRQVector + 1: irqProgramHigh}}},
+
+		{"NMI_Interrupt_PushStatusWithoutBreak", NMI,
+			env{ProgramCounter: prgAddr,
+				Status:   breakStatus &^ InterruptDisable,
+				StackPtr: InitStackPtr,
+				Memory: Memory{
+					NMIVector:     irqProgramLow,
+					NMIVector + 1: irqProgramHigh}},
+			env{ProgramCounter: irqProgram,
+				Status:   breakStatus | InterruptDisable,
+				StackPtr: InitStackPtr - 3,
+				Stack: Stack{
+					prgAddrHigh,
+					prgAddrLow,
+					(breakStatus &^ InterruptDisable) &^ Break},
+				Memory: Memory{
+					NMIVector:     irqProgramLow,
+					NMIVector + 1: irqProgramHigh}}},
+
 		{"NOP_NoOperation", NOP, env{}, env{}},
 
 		{"PHA_PushAccumulatorOnStack", PHA,
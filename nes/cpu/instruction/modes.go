@@ -30,8 +30,11 @@ func NewAccumulative(c cmd.Implied, cycles uint8) instr {
 	return &impliedMode{c, cycles}
 }
 
+// byte after opcode is read and dropped, every cycle
+// accesses bus
 func (i *impliedMode) Execute(s *state.State) {
 	s.ProgramCounter += impliedInstrSize
+	s.Read(s.ProgramCounter)
 	i.cmd(s)
 }
 
@@ -39,6 +42,22 @@ func (i *impliedMode) GetCycles() uint8 {
 	return i.cycles
 }
 
+type interruptMode struct {
+	impliedMode
+}
+
+func NewInterrupt(c cmd.Implied, cycles uint8) instr {
+	return &interruptMode{impliedMode{c, cycles}}
+}
+
+// opcode fetch is replaced by two reads of the
+// interrupted instruction
+func (i *interruptMode) Execute(s *state.State) {
+	s.Read(s.ProgramCounter)
+	s.Read(s.ProgramCounter)
+	i.cmd(s)
+}
+
 type immediateMode struct {
 	addressMode
 }
@@ -76,7 +95,7 @@ func NewZeroPageX(c cmd.Addressed, cycles uint8) instr {
 }
 
 func (z *zeroPageXMode) Execute(s *state.State) {
-	addr := s.ReadOneByteParam() + s.RegisterX
+	addr := s.ReadIndexedZeroPage(s.RegisterX)
 	s.ProgramCounter += oneByteAddrInstrSize
 	z.cmd(s, uint16(addr))
 }
@@ -90,7 +109,7 @@ func NewZeroPageY(c cmd.Addressed, cycles uint8) instr {
 }
 
 func (z *zeroPageYMode) Execute(s *state.State) {
-	addr := s.ReadOneByteParam() + s.RegisterY
+	addr := s.ReadIndexedZeroPage(s.RegisterY)
 	s.ProgramCounter += oneByteAddrInstrSize
 	z.cmd(s, uint16(addr))
 }
@@ -122,7 +141,7 @@ func NewAbsoluteX(c cmd.Addressed,
 func (a *absoluteXMode) Execute(s *state.State) {
 	base := s.ReadTwoBytesParam()
 	final := base + uint16(s.RegisterX)
-	a.checkPageCross(base, final)
+	a.checkPageCross(s, base, final)
 	s.ProgramCounter += twoBytesAddrInstrSize
 	a.cmd(s, final)
 }
@@ -140,7 +159,7 @@ func NewAbsoluteY(c cmd.Addressed,
 func (a *absoluteYMode) Execute(s *state.State) {
 	base := s.ReadTwoBytesParam()
 	final := base + uint16(s.RegisterY)
-	a.checkPageCross(base, final)
+	a.checkPageCross(s, base, final)
 	s.ProgramCounter += twoBytesAddrInstrSize
 	a.cmd(s, final)
 }
@@ -170,7 +189,7 @@ func NewIndirectX(c cmd.Addressed, cycles uint8) instr {
 }
 
 func (a *indirectXMode) Execute(s *state.State) {
-	pointer := s.ReadOneByteParam() + s.RegisterX
+	pointer := s.ReadIndexedZeroPage(s.RegisterX)
 	addr := s.ReadTwoBytesPageOverflow(uint16(pointer))
 	s.ProgramCounter += oneByteAddrInstrSize
 	a.cmd(s, addr)
@@ -188,7 +207,7 @@ func NewIndirectY(c cmd.Addressed,
 
 func (a *indirectYMode) Execute(s *state.State) {
 	base, final := a.getAddresses(s)
-	a.checkPageCross(base, final)
+	a.checkPageCross(s, base, final)
 	s.ProgramCounter += oneByteAddrInstrSize
 	a.cmd(s, final)
 }
@@ -214,8 +233,19 @@ func newPageCrossMode(c cmd.Addressed,
 		bonusCycles: bonus}
 }
 
-func (p *pageCrossMode) checkPageCross(base, final uint16) {
+// address is read before high byte is fixed, writes and
+// read-modify-write have no bonus and always do it
+func (p *pageCrossMode) checkPageCross(s *state.State,
+	base, final uint16) {
 	p.isPageCross = !byteutil.IsHighEqual(base, final)
+	if p.isPageCross || p.bonusCycles == 0 {
+		s.Read(getUnfixedAddr(base, final))
+	}
+}
+
+func getUnfixedAddr(base, final uint16) uint16 {
+	return byteutil.Merge(byteutil.GetHigh(base),
+		byteutil.GetLow(final))
 }
 
 func (p *pageCrossMode) GetCycles() uint8 {
@@ -254,15 +284,20 @@ func (r *relativeMode) Execute(s *state.State) {
 func (r *relativeMode) runCmd(s *state.State, shift uint16) {
 	if r.cmd(s.Status) {
 		finalAddr := s.ProgramCounter + shift
-		r.updateCycles(s.ProgramCounter, finalAddr)
+		r.updateCycles(s, s.ProgramCounter, finalAddr)
 		s.ProgramCounter = finalAddr
 	}
 }
 
-func (r *relativeMode) updateCycles(base, final uint16) {
+// taken branch reads next opcode and address before
+// fixing its high byte in the bonus cycles
+func (r *relativeMode) updateCycles(s *state.State,
+	base, final uint16) {
 	r.bonusCycles++
+	s.Read(base)
 	if !byteutil.IsHighEqual(base, final) {
 		r.bonusCycles++
+		s.Read(getUnfixedAddr(base, final))
 	}
 }
 
@@ -58,6 +58,7 @@ func Test_OnExecute_RunProvidedCommandOnce(t *T) {
 	}{
 		{"Implied", NewImplied(count, cycles)},
 		{"Accumulative", NewAccumulative(count, cycles)},
+		{"Interrupt", NewInterrupt(count, cycles)},
 		{"Immediate", NewImmediate(countAddr, cycles)},
 		{"ZeroPage", NewZeroPage(countAddr, cycles)},
 		{"ZeroPageX", NewZeroPageX(countAddr, cycles)},
@@ -92,6 +93,7 @@ func Test_OnExecute_ShiftProgramCounterBeforeCommand(t *T) {
 	}{
 		{"Implied", 1, NewImplied(save, cycles)},
 		{"Accumulative", 1, NewAccumulative(save, cycles)},
+		{"Interrupt", 0, NewInterrupt(save, cycles)},
 		{"Immediate", 2, NewImmediate(saveAddr, cycles)},
 		{"ZeroPage", 2, NewZeroPage(saveAddr, cycles)},
 		{"ZeroPageX", 2, NewZeroPageX(saveAddr, cycles)},
@@ -123,6 +125,7 @@ func Test_OnExecute_ProvidedCommandCanModifyState(t *T) {
 	}{
 		{"Implied", NewImplied(save, cycles)},
 		{"Accumulative", NewAccumulative(save, cycles)},
+		{"Interrupt", NewInterrupt(save, cycles)},
 		{"Immediate", NewImmediate(saveAddr, cycles)},
 		{"ZeroPage", NewZeroPage(saveAddr, cycles)},
 		{"ZeroPageX", NewZeroPageX(saveAddr, cycles)},
@@ -285,6 +288,7 @@ func Test_OnGetCycles_ReturnBasicProvidedNumOfCycles(t *T) {
 		instruction Instruction
 	}{
 		{"Implied", NewImplied(nil, cycles)},
+		{"Interrupt", NewInterrupt(nil, cycles)},
 		{"Accumulative", NewAccumulative(nil, cycles)},
 		{"Immediate", NewImmediate(nil, cycles)},
 		{"ZeroPage", NewZeroPage(nil, cycles)},
@@ -17,6 +17,7 @@ const (
 
 	initStatus = InterruptDisable | Break | Unused
 
+	nmiVector    = 0xfffa
 	resetVector  = 0xfffc
 	irqVector    = 0xfffe
 	stackOffset  = 0x0100
@@ -51,6 +52,10 @@ func (s *State) LoadResetProgram() {
 	s.ProgramCounter = s.ReadTwoBytes(resetVector)
 }
 
+func (s *State) LoadNMIProgram() {
+	s.ProgramCounter = s.ReadTwoBytes(nmiVector)
+}
+
 func (s *State) LoadIRQProgram() {
 	s.ProgramCounter = s.ReadTwoBytes(irqVector)
 }
@@ -67,6 +72,14 @@ func (s *State) ReadInstructionCode() byte {
 	return s.Read(s.ProgramCounter)
 }
 
+// ReadIndexedZeroPage reads base address before adding
+// index to it like CPU does
+func (s *State) ReadIndexedZeroPage(index byte) byte {
+	base := s.ReadOneByteParam()
+	s.Read(uint16(base))
+	return base + index
+}
+
 func (s *State) ReadTwoBytes(addr uint16) uint16 {
 	lo := s.Read(addr)
 	hi := s.Read(addr + 1)
@@ -100,6 +113,12 @@ func (s *State) PullTwoBytesFromStack() uint16 {
 	return byteutil.Merge(hi, lo)
 }
 
+// PeekStack reads top of stack without pulling, CPU does
+// it in cycle that increments stack pointer
+func (s *State) PeekStack() byte {
+	return s.Read(s.getStackAddr())
+}
+
 func (s *State) PullFromStack() byte {
 	s.StackPtr++
 	return s.Read(s.getStackAddr())
@@ -91,6 +91,17 @@ func Test_LoadResetProgram(t *T) {
 	ExpectTwoHexBytesEq(t, s.ProgramCounter, value16)
 }
 
+func Test_LoadNMIProgram(t *T) {
+	s := State{Bus: TestBus{
+		NMIVector:     value16Low,
+		NMIVector + 1: value16High,
+	}}
+
+	s.LoadNMIProgram()
+
+	ExpectTwoHexBytesEq(t, s.ProgramCounter, value16)
+}
+
 func Test_LoadIRQProgram(t *T) {
 	s := State{Bus: TestBus{
 		IRQVector:     value16Low,
@@ -24,6 +24,7 @@ const (
 
 	InitStatus = InterruptDisable | Break | Unused
 
+	NMIVector     = 0xfffa
 	ResetVector   = 0xfffc
 	IRQVector     = 0xfffe
 	StackOffset   = 0x0100
@@ -0,0 +1,271 @@
+package fds
+
+const (
+	waveTableAddr = 0x4040
+	waveTableSize = 64
+	waveMask      = 0x3f
+
+	envelopeSpeed    = 0x3f
+	envelopeIncrease = 0x40
+	envelopeOff      = 0x80
+	maxGain          = 32
+
+	frequencyHi      = 0x0f
+	haltWave         = 0x80
+	disableEnvelopes = 0x40
+	haltModulation   = 0x80
+	waveWritable     = 0x80
+	masterVolumeMask = 0x03
+	modCounterMask   = 0x7f
+	modTableMask     = 0x07
+	modReset         = 4
+
+	defaultMasterSpeed = 0xe8
+	fdsMixScale        = 0.0075
+)
+
+var (
+	masterVolumes = [...]int{36, 24, 17, 14}
+	modSteps      = [...]int{0, 1, 2, 4, 0, -4, -2, -1}
+)
+
+type envelope struct {
+	speed        byte
+	gain         byte
+	timer        int
+	isIncreasing bool
+	isOff        bool
+}
+
+func (e *envelope) write(value, masterSpeed byte) {
+	e.speed = value & envelopeSpeed
+	e.isIncreasing = value&envelopeIncrease != 0
+	e.isOff = value&envelopeOff != 0
+	e.reset(masterSpeed)
+	if e.isOff {
+		e.gain = e.speed
+	}
+}
+
+func (e *envelope) reset(masterSpeed byte) {
+	e.timer = 8 * (int(e.speed) + 1) * int(masterSpeed)
+}
+
+func (e *envelope) tick(masterSpeed byte) bool {
+	if e.isOff || masterSpeed == 0 {
+		return false
+	}
+	if e.timer--; e.timer > 0 {
+		return false
+	}
+	e.reset(masterSpeed)
+	if e.isIncreasing && e.gain < maxGain {
+		e.gain++
+	} else if !e.isIncreasing && e.gain > 0 {
+		e.gain--
+	}
+	return true
+}
+
+type modulator struct {
+	envelope
+	frequency uint16
+	overflow  uint16
+	counter   int
+	table     [waveTableSize]byte
+	position  byte
+	isHalted  bool
+	output    int
+}
+
+func (m *modulator) writeTable(value byte) {
+	if !m.isHalted {
+		return
+	}
+	m.table[m.position] = value & modTableMask
+	m.table[m.position+1] = value & modTableMask
+	m.position = (m.position + 2) & waveMask
+}
+
+func (m *modulator) setCounter(counter int) {
+	switch {
+	case counter >= 64:
+		counter -= 128
+	case counter < -64:
+		counter += 128
+	}
+	m.counter = counter
+}
+
+func (m *modulator) clock() bool {
+	if m.isHalted || m.frequency == 0 {
+		return false
+	}
+	m.overflow += m.frequency
+	if m.overflow >= m.frequency {
+		return false
+	}
+	step := m.table[m.position]
+	if step == modReset {
+		m.setCounter(0)
+	} else {
+		m.setCounter(m.counter + modSteps[step])
+	}
+	m.position = (m.position + 1) & waveMask
+	return true
+}
+
+// pitch offset calculation as described on nesdev wiki,
+// rounding quirks included
+func (m *modulator) updateOutput(pitch uint16) {
+	temp := m.counter * int(m.gain)
+	remainder := temp & 0x0f
+	temp >>= 4
+	if remainder > 0 && temp&0x80 == 0 {
+		if m.counter < 0 {
+			temp--
+		} else {
+			temp += 2
+		}
+	}
+	if temp >= 192 {
+		temp -= 256
+	} else if temp < -64 {
+		temp += 256
+	}
+	temp *= int(pitch)
+	remainder = temp & 0x3f
+	temp >>= 6
+	if remainder >= 32 {
+		temp++
+	}
+	m.output = temp
+}
+
+type audio struct {
+	volume             envelope
+	mod                modulator
+	waveTable          [waveTableSize]byte
+	position           byte
+	frequency          uint16
+	overflow           uint16
+	masterVolume       byte
+	masterSpeed        byte
+	level              byte
+	isHalted           bool
+	isEnvelopeDisabled bool
+	isWaveWritable     bool
+}
+
+func newAudio() *audio {
+	return &audio{masterSpeed: defaultMasterSpeed}
+}
+
+func (a *audio) read(addr uint16) byte {
+	switch {
+	case addr < waveTableAddr+waveTableSize:
+		return a.waveTable[addr&waveMask]
+	case addr == 0x4090:
+		return a.volume.gain
+	case addr == 0x4092:
+		return a.mod.gain
+	}
+	return 0
+}
+
+func (a *audio) write(addr uint16, value byte) {
+	if addr < waveTableAddr+waveTableSize {
+		if a.isWaveWritable {
+			a.waveTable[addr&waveMask] = value & waveMask
+		}
+		return
+	}
+	switch addr {
+	case 0x4080:
+		a.volume.write(value, a.masterSpeed)
+	case 0x4082:
+		a.frequency = a.frequency&0x0f00 | uint16(value)
+	case 0x4083:
+		a.writeFrequencyHi(value)
+	case 0x4084:
+		a.mod.write(value, a.masterSpeed)
+		a.mod.updateOutput(a.frequency)
+	case 0x4085:
+		a.mod.setCounter(int(value & modCounterMask))
+		a.mod.updateOutput(a.frequency)
+	case 0x4086:
+		a.mod.frequency = a.mod.frequency&0x0f00 | uint16(value)
+	case 0x4087:
+		a.writeModFrequencyHi(value)
+	case 0x4088:
+		a.mod.writeTable(value)
+	case 0x4089:
+		a.isWaveWritable = value&waveWritable != 0
+		a.masterVolume = value & masterVolumeMask
+	case 0x408a:
+		a.masterSpeed = value
+	}
+}
+
+func (a *audio) writeFrequencyHi(value byte) {
+	a.frequency = a.frequency&0x00ff |
+		uint16(value&frequencyHi)<<8
+	a.isHalted = value&haltWave != 0
+	a.isEnvelopeDisabled = value&disableEnvelopes != 0
+	if a.isHalted {
+		a.position = 0
+	}
+	if a.isEnvelopeDisabled {
+		a.volume.reset(a.masterSpeed)
+		a.mod.reset(a.masterSpeed)
+	}
+}
+
+func (a *audio) writeModFrequencyHi(value byte) {
+	a.mod.frequency = a.mod.frequency&0x00ff |
+		uint16(value&frequencyHi)<<8
+	a.mod.isHalted = value&haltModulation != 0
+	if a.mod.isHalted {
+		a.mod.overflow = 0
+	}
+}
+
+func (a *audio) clock() {
+	if !a.isHalted && !a.isEnvelopeDisabled {
+		a.volume.tick(a.masterSpeed)
+		if a.mod.envelope.tick(a.masterSpeed) {
+			a.mod.updateOutput(a.frequency)
+		}
+	}
+	if a.mod.clock() {
+		a.mod.updateOutput(a.frequency)
+	}
+	a.updateLevel()
+	if !a.isHalted {
+		a.advanceWave()
+	}
+}
+
+func (a *audio) advanceWave() {
+	pitch := int(a.frequency) + a.mod.output
+	if pitch <= 0 || a.isWaveWritable {
+		return
+	}
+	a.overflow += uint16(pitch)
+	if a.overflow < uint16(pitch) {
+		a.position = (a.position + 1) & waveMask
+	}
+}
+
+func (a *audio) updateLevel() {
+	gain := int(a.volume.gain)
+	if gain > maxGain {
+		gain = maxGain
+	}
+	level := gain * masterVolumes[a.masterVolume]
+	a.level = byte(int(a.waveTable[a.position]) * level / 1152)
+}
+
+func (a *audio) getOutput() float32 {
+	return float32(a.level) * fdsMixScale
+}
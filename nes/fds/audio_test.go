@@ -0,0 +1,176 @@
+package fds_test
+
+import (
+	. "github.com/smarkuck/nes/nes/fds"
+	. "github.com/smarkuck/unittest"
+)
+
+const (
+	fdsMixScale      = 0.0075
+	invalidAudioText = "invalid audio output"
+	invalidGainText  = "invalid gain"
+)
+
+func Test_Audio_IgnoreRegistersWhenSoundDisabled(t *T) {
+	f := newFDS(t, nil)
+
+	f.Write(0x4089, 0x80)
+	f.Write(0x4040, 0x3f)
+	f.Write(0x4023, 0x02)
+
+	ExpectEq(t, f.Read(0x4040), 0x00, invalidReadText)
+}
+
+func Test_Audio_WriteWaveTableOnlyWhenEnabled(t *T) {
+	f := newAudioFDS(t)
+
+	f.Write(0x4041, 0x3f)
+	f.Write(0x4089, 0x80)
+	f.Write(0x4042, 0xff)
+
+	ExpectEq(t, f.Read(0x4041), 0x00, invalidReadText)
+	ExpectEq(t, f.Read(0x4042), 0x3f, invalidReadText)
+}
+
+func Test_Audio_ScaleWaveByGainAndMasterVolume(t *T) {
+	tests := map[string]struct {
+		gain, masterVolume byte
+		level              int
+	}{
+		"full":         {32, 0, 63},
+		"gain clamped": {40, 0, 63},
+		"half gain":    {16, 0, 31},
+		"volume 2/3":   {32, 1, 42},
+		"volume 2/5":   {32, 3, 24},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *T) {
+			f := newAudioFDS(t)
+			writeWave(f, 0x3f)
+			f.Write(0x4089, test.masterVolume)
+			f.Write(0x4080, 0x80|test.gain)
+
+			f.Tick()
+
+			expectAudioEq(t, f, test.level)
+		})
+	}
+}
+
+func Test_Audio_StepThroughWaveTable(t *T) {
+	f := newWaveStepFDS(t)
+
+	tick(f, 32)
+	expectAudioEq(t, f, 0)
+	tick(f, 1)
+	expectAudioEq(t, f, 63)
+	tick(f, 32)
+	expectAudioEq(t, f, 0)
+}
+
+func Test_Audio_HaltResetsWavePosition(t *T) {
+	f := newAudioFDS(t)
+	writeWave(f, 0x00)
+	f.Write(0x4089, 0x80)
+	f.Write(0x4040, 0x3f)
+	f.Write(0x4089, 0x00)
+	f.Write(0x4080, 0xa0)
+	f.Write(0x4083, 0x08)
+	tick(f, 33)
+
+	f.Write(0x4083, 0x88)
+	f.Tick()
+
+	expectAudioEq(t, f, 63)
+}
+
+func Test_Audio_VolumeEnvelope(t *T) {
+	f := newAudioFDS(t)
+	f.Write(0x408a, 0x01)
+	f.Write(0x4080, 0x41)
+
+	tick(f, 15)
+	ExpectEq(t, f.Read(0x4090), 0, invalidGainText)
+	tick(f, 1)
+	ExpectEq(t, f.Read(0x4090), 1, invalidGainText)
+
+	f.Write(0x4083, 0x40)
+	tick(f, 16)
+	ExpectEq(t, f.Read(0x4090), 1, invalidGainText)
+}
+
+func Test_Audio_ModulatorEnvelopeGain(t *T) {
+	f := newAudioFDS(t)
+
+	f.Write(0x4084, 0x85)
+
+	ExpectEq(t, f.Read(0x4092), 5, invalidGainText)
+}
+
+func Test_Audio_ModulationRaisesPitch(t *T) {
+	f := newWaveStepFDS(t)
+	f.Write(0x4087, 0x80)
+	for i := 0; i < 32; i++ {
+		f.Write(0x4088, 0x03)
+	}
+	f.Write(0x4084, 0xa0)
+	f.Write(0x4086, 0xff)
+	f.Write(0x4087, 0x0f)
+
+	ExpectTrue(t, ticksUntilStep(f) < ticksUntilStep(
+		newWaveStepFDS(t)), "modulation has not raised pitch")
+}
+
+func Test_Audio_WriteModTableOnlyWhenHalted(t *T) {
+	f := newWaveStepFDS(t)
+	f.Write(0x4087, 0x00)
+	for i := 0; i < 32; i++ {
+		f.Write(0x4088, 0x03)
+	}
+	f.Write(0x4084, 0xa0)
+	f.Write(0x4086, 0xff)
+	f.Write(0x4087, 0x0f)
+
+	ExpectEq(t, ticksUntilStep(f), 33)
+}
+
+func newWaveStepFDS(t *T) FDS {
+	f := newAudioFDS(t)
+	writeWave(f, 0x00)
+	f.Write(0x4089, 0x80)
+	f.Write(0x4041, 0x3f)
+	f.Write(0x4089, 0x00)
+	f.Write(0x4080, 0xa0)
+	f.Write(0x4082, 0x00)
+	f.Write(0x4083, 0x08)
+	return f
+}
+
+func ticksUntilStep(f FDS) int {
+	ticks := 0
+	for ; ticks < 100 && f.GetAudioOutput() == 0; ticks++ {
+		f.Tick()
+	}
+	return ticks
+}
+
+func newAudioFDS(t *T) FDS {
+	f := newFDS(t, nil)
+	f.Write(0x4023, 0x03)
+	return f
+}
+
+func writeWave(f FDS, value byte) {
+	f.Write(0x4089, 0x80)
+	for addr := uint16(0x4040); addr < 0x4080; addr++ {
+		f.Write(addr, value)
+	}
+	f.Write(0x4089, f.Read(0x4089)&0x7f)
+}
+
+func expectAudioEq(t *T, f FDS, level int) {
+	t.Helper()
+	ExpectEq(t, f.GetAudioOutput(), float32(level)*fdsMixScale,
+		invalidAudioText)
+}
@@ -0,0 +1,129 @@
+package fds
+
+const (
+	diskInfoBlock   = 1
+	fileAmountBlock = 2
+	fileHeaderBlock = 3
+	fileDataBlock   = 4
+
+	diskInfoSize   = 56
+	fileAmountSize = 2
+	fileHeaderSize = 16
+	fileSizeOffset = 13
+	crcSize        = 2
+	crcPolynomial  = 0x8408
+
+	// gaps are measured in bits on real disk
+	leadingGap  = 28300 / 8
+	blockGap    = 976 / 8
+	blockMark   = 0x80
+	rawSideSize = 0x12000
+)
+
+func parseBlocks(side []byte, hasCRC bool) [][]byte {
+	var blocks [][]byte
+	fileSize := 0
+	for pos := 0; pos < len(side); {
+		size := getBlockSize(side[pos], fileSize)
+		if size == 0 || pos+size > len(side) {
+			break
+		}
+		block := side[pos : pos+size]
+		fileSize = updateFileSize(block, fileSize)
+		blocks = append(blocks, block)
+		pos += size
+		if hasCRC {
+			pos += crcSize
+		}
+	}
+	return blocks
+}
+
+func getBlockSize(blockType byte, fileSize int) int {
+	switch blockType {
+	case diskInfoBlock:
+		return diskInfoSize
+	case fileAmountBlock:
+		return fileAmountSize
+	case fileHeaderBlock:
+		return fileHeaderSize
+	case fileDataBlock:
+		return 1 + fileSize
+	}
+	return 0
+}
+
+func updateFileSize(block []byte, fileSize int) int {
+	if block[0] != fileHeaderBlock {
+		return fileSize
+	}
+	return int(block[fileSizeOffset]) |
+		int(block[fileSizeOffset+1])<<8
+}
+
+// raw side is what the drive head sees: gaps, block marks
+// and CRCs around every block
+func encodeRaw(blocks [][]byte) []byte {
+	raw := make([]byte, leadingGap, rawSideSize)
+	for _, b := range blocks {
+		raw = append(raw, blockMark)
+		raw = append(raw, b...)
+		crc := blockCRC(b)
+		raw = append(raw, byte(crc), byte(crc>>8))
+		raw = append(raw, make([]byte, blockGap)...)
+	}
+	if len(raw) < rawSideSize {
+		raw = append(raw, make([]byte, rawSideSize-len(raw))...)
+	}
+	return raw
+}
+
+func decodeRaw(raw []byte) [][]byte {
+	var blocks [][]byte
+	fileSize := 0
+	for pos := skipGap(raw, 0); pos < len(raw) &&
+		raw[pos] == blockMark; pos = skipGap(raw, pos) {
+		pos++
+		if pos >= len(raw) {
+			break
+		}
+		size := getBlockSize(raw[pos], fileSize)
+		if size == 0 || pos+size > len(raw) {
+			break
+		}
+		block := append([]byte(nil), raw[pos:pos+size]...)
+		fileSize = updateFileSize(block, fileSize)
+		blocks = append(blocks, block)
+		pos += size + crcSize
+	}
+	return blocks
+}
+
+func skipGap(raw []byte, pos int) int {
+	for pos < len(raw) && raw[pos] == 0 {
+		pos++
+	}
+	return pos
+}
+
+func blockCRC(block []byte) uint16 {
+	crc := updateCRC(0, blockMark)
+	for _, b := range block {
+		crc = updateCRC(crc, b)
+	}
+	return updateCRC(updateCRC(crc, 0), 0)
+}
+
+func updateCRC(crc uint16, value byte) uint16 {
+	for bit := 0; bit < 8; bit++ {
+		carry := crc & 0x01
+		crc >>= 1
+		if carry != 0 {
+			crc ^= crcPolynomial
+		}
+		if value>>bit&0x01 != 0 {
+			crc ^= 0x8000
+		}
+	}
+	return crc
+}
@@ -0,0 +1,182 @@
+package fds
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/smarkuck/nes/nes/patch"
+	"github.com/smarkuck/nes/nes/save"
+)
+
+const (
+	fdsHeader     = "FDS\x1a"
+	fdsHeaderSize = 16
+	fdsSideSize   = 65500
+	qdSideSize    = 0x10000
+	diffExtension = ".ips"
+
+	invalidImageText = "invalid FDS disk image"
+)
+
+var errInvalidImage = errors.New(invalidImageText)
+
+// QD images store block CRCs and use whole 64KB per side
+type imageFormat struct {
+	offset   int
+	sideSize int
+	hasCRC   bool
+}
+
+type Disk interface {
+	GetSideCount() int
+	IsModified() bool
+	GetDiff() ([]byte, error)
+	Save() error
+	getSide(side int) []byte
+	setModified(side int)
+}
+
+type disk struct {
+	image    []byte
+	format   imageFormat
+	sides    [][]byte
+	modified []bool
+	storage  save.Storage
+	name     string
+}
+
+// LoadDisk reads .fds or QD image, diff is IPS patch
+// holding previous disk writes and can be nil
+func LoadDisk(image, diff []byte) (Disk, error) {
+	return loadDisk(image, diff)
+}
+
+// NewDiffStorage keeps diffs in dir as .ips files, so
+// they never collide with battery saves of the same name
+func NewDiffStorage(dir string) save.Storage {
+	return save.NewFileStorageWithExtension(dir, diffExtension)
+}
+
+// LoadSavedDisk keeps diff in storage under given name,
+// it is loaded now and written back by Save
+func LoadSavedDisk(image []byte, s save.Storage,
+	name string) (Disk, error) {
+	diff, err := s.Load(name)
+	if err != nil {
+		return nil, err
+	}
+	d, err := loadDisk(image, diff)
+	if err != nil {
+		return nil, err
+	}
+	d.storage, d.name = s, name
+	return d, nil
+}
+
+func loadDisk(image, diff []byte) (*disk, error) {
+	f, err := detectFormat(image)
+	if err != nil {
+		return nil, err
+	}
+	current := image
+	if diff != nil {
+		if current, err = patch.ApplyIPS(image, diff); err != nil {
+			return nil, err
+		}
+		if len(current) != len(image) {
+			return nil, errInvalidImage
+		}
+	}
+	d := &disk{image: image, format: f}
+	for i := 0; i < f.getSideCount(len(image)); i++ {
+		blocks := parseBlocks(f.getSide(current, i), f.hasCRC)
+		d.sides = append(d.sides, encodeRaw(blocks))
+	}
+	d.modified = make([]bool, len(d.sides))
+	return d, nil
+}
+
+func detectFormat(image []byte) (imageFormat, error) {
+	switch {
+	case bytes.HasPrefix(image, []byte(fdsHeader)) &&
+		len(image) > fdsHeaderSize &&
+		(len(image)-fdsHeaderSize)%fdsSideSize == 0:
+		return imageFormat{fdsHeaderSize, fdsSideSize, false}, nil
+	case len(image) > 0 && len(image)%qdSideSize == 0:
+		return imageFormat{0, qdSideSize, true}, nil
+	case len(image) > 0 && len(image)%fdsSideSize == 0:
+		return imageFormat{0, fdsSideSize, false}, nil
+	}
+	return imageFormat{}, errInvalidImage
+}
+
+func (f imageFormat) getSideCount(imageSize int) int {
+	return (imageSize - f.offset) / f.sideSize
+}
+
+func (f imageFormat) getSide(image []byte, side int) []byte {
+	start := f.offset + side*f.sideSize
+	return image[start : start+f.sideSize]
+}
+
+func (f imageFormat) encodeSide(blocks [][]byte) []byte {
+	side := make([]byte, 0, f.sideSize)
+	for _, b := range blocks {
+		side = append(side, b...)
+		if f.hasCRC {
+			crc := blockCRC(b)
+			side = append(side, byte(crc), byte(crc>>8))
+		}
+	}
+	if len(side) > f.sideSize {
+		return side[:f.sideSize]
+	}
+	return append(side, make([]byte, f.sideSize-len(side))...)
+}
+
+func (d *disk) GetSideCount() int {
+	return len(d.sides)
+}
+
+func (d *disk) IsModified() bool {
+	for _, m := range d.modified {
+		if m {
+			return true
+		}
+	}
+	return false
+}
+
+// only modified sides are rebuilt, so untouched ones stay
+// byte for byte equal to the original image
+func (d *disk) GetDiff() ([]byte, error) {
+	current := append([]byte(nil), d.image...)
+	for i, raw := range d.sides {
+		if d.modified[i] {
+			side := d.format.encodeSide(decodeRaw(raw))
+			copy(d.format.getSide(current, i), side)
+		}
+	}
+	return patch.CreateIPS(d.image, current)
+}
+
+// Save stores diff only for modified disk loaded
+// with LoadSavedDisk
+func (d *disk) Save() error {
+	if d.storage == nil || !d.IsModified() {
+		return nil
+	}
+	diff, err := d.GetDiff()
+	if err != nil {
+		return err
+	}
+	return d.storage.Store(d.name, diff)
+}
+
+func (d *disk) getSide(side int) []byte {
+	return d.sides[side]
+}
+
+func (d *disk) setModified(side int) {
+	d.modified[side] = true
+}
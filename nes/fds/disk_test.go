@@ -0,0 +1,140 @@
+package fds_test
+
+import (
+	"bytes"
+
+	. "github.com/smarkuck/nes/nes/fds"
+	"github.com/smarkuck/nes/nes/patch"
+	"github.com/smarkuck/nes/nes/save"
+	. "github.com/smarkuck/unittest"
+)
+
+const (
+	sideSize   = 65500
+	qdSideSize = 0x10000
+
+	invalidErrorText = "invalid error"
+	invalidDiskText  = "invalid disk"
+)
+
+var fileData = []byte{0xa9, 0x01, 0x60}
+
+func Test_Disk_LoadImageFormats(t *T) {
+	side := newSide(false)
+	qdSide := newSide(true)
+	images := map[string]struct {
+		image []byte
+		sides int
+	}{
+		"fds":        {append(fdsHeader(2), repeatSide(side, 2)...), 2},
+		"headerless": {repeatSide(side, 3), 3},
+		"qd":         {repeatSide(qdSide, 2), 2},
+	}
+
+	for name, i := range images {
+		t.Run(name, func(t *T) {
+			d, err := LoadDisk(i.image, nil)
+
+			ExpectTrue(t, err == nil, invalidErrorText)
+			ExpectEq(t, d.GetSideCount(), i.sides, invalidDiskText)
+			ExpectFalse(t, d.IsModified(), invalidDiskText)
+		})
+	}
+}
+
+func Test_Disk_WhenImageInvalid_ReturnError(t *T) {
+	images := map[string][]byte{
+		"empty":      nil,
+		"size":       make([]byte, sideSize+1),
+		"header":     fdsHeader(1),
+		"diff":       newSide(false),
+		"diff range": newSide(false),
+	}
+	diffs := map[string][]byte{
+		"diff":       []byte("PATCH"),
+		"diff range": []byte("PATCH\x01\x00\x00\x00\x01\x00EOF"),
+	}
+
+	for name, image := range images {
+		t.Run(name, func(t *T) {
+			_, err := LoadDisk(image, diffs[name])
+
+			ExpectTrue(t, err != nil, invalidErrorText)
+		})
+	}
+}
+
+func Test_Disk_WhenNotModified_ReturnEmptyDiff(t *T) {
+	d, _ := LoadDisk(newSide(false), nil)
+
+	diff, err := d.GetDiff()
+
+	ExpectTrue(t, err == nil, invalidErrorText)
+	ExpectDeepEq(t, diff, []byte("PATCHEOF"), invalidDiskText)
+}
+
+func Test_Disk_WhenNotModified_SaveNothing(t *T) {
+	s := save.NewMemoryStorage()
+	d, _ := LoadSavedDisk(newSide(false), s, "disk")
+
+	ExpectTrue(t, d.Save() == nil, invalidErrorText)
+	diff, _ := s.Load("disk")
+	ExpectEq(t, len(diff), 0, invalidDiskText)
+}
+
+func Test_Disk_LoadWithDiff(t *T) {
+	image := newSide(false)
+	changed := append([]byte(nil), image...)
+	changed[fileDataOffset+2] = 0x42
+	diff, _ := patch.CreateIPS(image, changed)
+	d, _ := LoadDisk(image, diff)
+	f := newFDS(t, d)
+
+	data := readBlocks(f, 4)
+
+	ExpectEq(t, data[3][2], 0x42, invalidDiskText)
+	ExpectEq(t, image[fileDataOffset+2], 0x01, invalidDiskText)
+}
+
+const (
+	diskInfoSize   = 56
+	fileHeaderSize = 16
+	fileDataOffset = diskInfoSize + 2 + fileHeaderSize
+	leadingGap     = 28300 / 8
+)
+
+func newBlocks() [][]byte {
+	info := make([]byte, diskInfoSize)
+	info[0] = 1
+	copy(info[1:], "*NINTENDO-HVC*")
+	header := make([]byte, fileHeaderSize)
+	header[0], header[13] = 3, byte(len(fileData))
+	return [][]byte{info, {2, 1}, header,
+		append([]byte{4}, fileData...)}
+}
+
+func newSide(hasCRC bool) []byte {
+	size := sideSize
+	if hasCRC {
+		size = qdSideSize
+	}
+	side := make([]byte, 0, size)
+	for _, b := range newBlocks() {
+		side = append(side, b...)
+		if hasCRC {
+			side = append(side, 0, 0)
+		}
+	}
+	return append(side, make([]byte, size-len(side))...)
+}
+
+func fdsHeader(sides byte) []byte {
+	header := make([]byte, 16)
+	copy(header, "FDS\x1a")
+	header[4] = sides
+	return header
+}
+
+func repeatSide(side []byte, count int) []byte {
+	return bytes.Repeat(side, count)
+}
@@ -0,0 +1,211 @@
+package fds
+
+const (
+	noSide = -1
+
+	motorOn       = 0x01
+	transferReset = 0x02
+	readMode      = 0x04
+	crcControl    = 0x10
+	diskReady     = 0x40
+	transferIRQ   = 0x80
+
+	transferFlag = 0x02
+	endOfHead    = 0x40
+
+	notInserted  = 0x01
+	notReady     = 0x02
+	writeProtect = 0x04
+
+	// timings in CPU cycles
+	headReturnDelay = 50000
+	byteDelay       = 150
+	insertDelay     = 1789773
+	writeLag        = 2
+)
+
+type drive struct {
+	disk          Disk
+	side          int
+	pendingSide   int
+	insertCounter int
+	position      int
+	delay         int
+	readBuffer    byte
+	writeBuffer   byte
+	crc           uint16
+	control       byte
+	previousCRC   bool
+	isAtEndOfHead bool
+	isScanning    bool
+	isGapEnded    bool
+	isTransferred bool
+	isIRQ         bool
+}
+
+func newDrive(d Disk) drive {
+	return drive{disk: d, side: 0, pendingSide: noSide,
+		isAtEndOfHead: true}
+}
+
+// new side is reported as inserted after a delay,
+// so the game notices disk swap
+func (d *drive) insert(side int) {
+	d.eject()
+	d.pendingSide = side
+	d.insertCounter = insertDelay
+}
+
+func (d *drive) eject() {
+	d.side = noSide
+	d.pendingSide = noSide
+}
+
+func (d *drive) isInserted() bool {
+	return d.side != noSide
+}
+
+func (d *drive) readStatus() byte {
+	var value byte
+	if d.isTransferred {
+		value |= transferFlag
+	}
+	if d.isAtEndOfHead {
+		value |= endOfHead
+	}
+	d.isTransferred = false
+	d.isIRQ = false
+	return value
+}
+
+func (d *drive) readData() byte {
+	d.isTransferred = false
+	d.isIRQ = false
+	return d.readBuffer
+}
+
+func (d *drive) readDriveStatus() byte {
+	switch {
+	case !d.isInserted():
+		return notInserted | notReady | writeProtect
+	case !d.isScanning:
+		return notReady
+	}
+	return 0
+}
+
+func (d *drive) writeData(value byte) {
+	d.writeBuffer = value
+	d.isTransferred = false
+	d.isIRQ = false
+}
+
+func (d *drive) writeControl(value byte) {
+	d.control = value
+	d.isIRQ = false
+}
+
+func (d *drive) tick() {
+	if d.pendingSide != noSide {
+		if d.insertCounter--; d.insertCounter <= 0 {
+			d.side, d.pendingSide = d.pendingSide, noSide
+		}
+	}
+	if !d.isInserted() || d.control&motorOn == 0 {
+		d.isAtEndOfHead = true
+		d.isScanning = false
+		return
+	}
+	if d.control&transferReset != 0 && !d.isScanning {
+		return
+	}
+	if d.isAtEndOfHead {
+		d.delay = headReturnDelay
+		d.isAtEndOfHead = false
+		d.position = 0
+		d.isGapEnded = false
+		return
+	}
+	if d.delay > 0 {
+		d.delay--
+		return
+	}
+	d.transferByte()
+}
+
+func (d *drive) transferByte() {
+	d.isScanning = true
+	if d.control&readMode != 0 {
+		d.readByte()
+	} else {
+		d.writeByte()
+	}
+	d.previousCRC = d.control&crcControl != 0
+	d.position++
+	if d.position >= len(d.disk.getSide(d.side)) {
+		d.control &^= motorOn
+	} else {
+		d.delay = byteDelay
+	}
+}
+
+// first non zero byte after the gap is the block mark,
+// it ends the gap but is not reported to the CPU
+func (d *drive) readByte() {
+	value := d.disk.getSide(d.side)[d.position]
+	if !d.previousCRC {
+		d.crc = updateCRC(d.crc, value)
+	}
+	needIRQ := d.control&transferIRQ != 0
+	switch {
+	case d.control&diskReady == 0:
+		d.isGapEnded = false
+		d.crc = 0
+	case value != 0 && !d.isGapEnded:
+		d.isGapEnded = true
+		needIRQ = false
+	}
+	if d.isGapEnded {
+		d.isTransferred = true
+		d.readBuffer = value
+		d.isIRQ = d.isIRQ || needIRQ
+	}
+}
+
+func (d *drive) writeByte() {
+	isCRC := d.control&crcControl != 0
+	value := d.writeBuffer
+	if !isCRC {
+		d.isTransferred = true
+		d.isIRQ = d.isIRQ || d.control&transferIRQ != 0
+	}
+	if d.control&diskReady == 0 {
+		value = 0
+		d.crc = 0
+	}
+	if isCRC {
+		value = d.nextCRCByte()
+	} else {
+		d.crc = updateCRC(d.crc, value)
+	}
+	d.store(value)
+	d.isGapEnded = false
+}
+
+func (d *drive) nextCRCByte() byte {
+	if !d.previousCRC {
+		d.crc = updateCRC(updateCRC(d.crc, 0), 0)
+	}
+	value := byte(d.crc)
+	d.crc >>= 8
+	return value
+}
+
+// head writes bytes a bit behind the read position
+func (d *drive) store(value byte) {
+	if d.position < writeLag {
+		return
+	}
+	d.disk.getSide(d.side)[d.position-writeLag] = value
+	d.disk.setModified(d.side)
+}
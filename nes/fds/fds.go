@@ -0,0 +1,227 @@
+package fds
+
+import (
+	"fmt"
+
+	"github.com/smarkuck/nes/nes"
+	"github.com/smarkuck/nes/nes/cartridge"
+	"github.com/smarkuck/nes/nes/mapper"
+)
+
+const (
+	biosSize   = 0x2000
+	prgRAMSize = 0x8000
+	chrRAMSize = 0x2000
+
+	prgRAMAddr = 0x6000
+	biosAddr   = 0xe000
+
+	timerRepeat   = 0x01
+	timerEnabled  = 0x02
+	diskEnabled   = 0x01
+	soundEnabled  = 0x02
+	horizontalBit = 0x08
+	batteryGood   = 0x80
+
+	invalidBIOSFormat = "invalid FDS BIOS size: %d"
+	invalidSideFormat = "invalid disk side %d, disk has %d sides"
+)
+
+type FDS interface {
+	mapper.Mapper
+	nes.IRQSource
+	nes.AudioSource
+	InsertSide(side int) error
+	EjectDisk() error
+	GetSide() int
+	GetDisk() Disk
+	Close() error
+}
+
+type fds struct {
+	drive
+	bios           []byte
+	prgRAM         []byte
+	chrRAM         []byte
+	audio          *audio
+	mirroring      cartridge.Mirroring
+	timerReload    uint16
+	timerCounter   uint16
+	isTimerRepeat  bool
+	isTimerEnabled bool
+	isTimerIRQ     bool
+	isDiskEnabled  bool
+	isSoundEnabled bool
+	external       byte
+}
+
+// New emulates RAM adapter, BIOS has to be supplied
+// by the user and first disk side is inserted
+func New(bios []byte, d Disk) (FDS, error) {
+	if len(bios) != biosSize {
+		return nil, fmt.Errorf(invalidBIOSFormat, len(bios))
+	}
+	f := &fds{
+		drive:     newDrive(d),
+		bios:      bios,
+		prgRAM:    make([]byte, prgRAMSize),
+		chrRAM:    make([]byte, chrRAMSize),
+		audio:     newAudio(),
+		mirroring: cartridge.Horizontal,
+	}
+	return f, nil
+}
+
+func (f *fds) Read(addr uint16) byte {
+	switch {
+	case addr >= biosAddr:
+		return f.bios[addr-biosAddr]
+	case addr >= prgRAMAddr:
+		return f.prgRAM[addr-prgRAMAddr]
+	case addr >= waveTableAddr && f.isSoundEnabled:
+		return f.audio.read(addr)
+	case addr >= 0x4030 && addr <= 0x4033 && f.isDiskEnabled:
+		return f.readDiskRegister(addr)
+	}
+	return 0
+}
+
+func (f *fds) readDiskRegister(addr uint16) byte {
+	switch addr {
+	case 0x4030:
+		value := f.drive.readStatus()
+		if f.isTimerIRQ {
+			value |= 0x01
+		}
+		f.isTimerIRQ = false
+		return value
+	case 0x4031:
+		return f.drive.readData()
+	case 0x4032:
+		return f.drive.readDriveStatus()
+	}
+	return f.external | batteryGood
+}
+
+func (f *fds) Write(addr uint16, value byte) {
+	switch {
+	case addr >= biosAddr:
+	case addr >= prgRAMAddr:
+		f.prgRAM[addr-prgRAMAddr] = value
+	case addr >= waveTableAddr:
+		if f.isSoundEnabled {
+			f.audio.write(addr, value)
+		}
+	case addr == 0x4023:
+		f.writeIOEnable(value)
+	case addr >= 0x4020 && addr <= 0x4026 && f.isDiskEnabled:
+		f.writeDiskRegister(addr, value)
+	}
+}
+
+func (f *fds) writeIOEnable(value byte) {
+	f.isDiskEnabled = value&diskEnabled != 0
+	f.isSoundEnabled = value&soundEnabled != 0
+	if !f.isDiskEnabled {
+		f.isTimerEnabled = false
+		f.isTimerIRQ = false
+		f.drive.isIRQ = false
+	}
+}
+
+func (f *fds) writeDiskRegister(addr uint16, value byte) {
+	switch addr {
+	case 0x4020:
+		f.timerReload = f.timerReload&0xff00 | uint16(value)
+	case 0x4021:
+		f.timerReload = f.timerReload&0x00ff | uint16(value)<<8
+	case 0x4022:
+		f.writeTimerControl(value)
+	case 0x4024:
+		f.drive.writeData(value)
+	case 0x4025:
+		f.drive.writeControl(value)
+		f.mirroring = cartridge.Vertical
+		if value&horizontalBit != 0 {
+			f.mirroring = cartridge.Horizontal
+		}
+	case 0x4026:
+		f.external = value
+	}
+}
+
+func (f *fds) writeTimerControl(value byte) {
+	f.isTimerRepeat = value&timerRepeat != 0
+	f.isTimerEnabled = value&timerEnabled != 0
+	if f.isTimerEnabled {
+		f.timerCounter = f.timerReload
+	} else {
+		f.isTimerIRQ = false
+	}
+}
+
+func (f *fds) ReadCHR(addr uint16) byte {
+	return f.chrRAM[addr]
+}
+
+func (f *fds) WriteCHR(addr uint16, value byte) {
+	f.chrRAM[addr] = value
+}
+
+func (f *fds) GetMirroring() cartridge.Mirroring {
+	return f.mirroring
+}
+
+func (f *fds) Tick() {
+	f.clockTimer()
+	f.audio.clock()
+	f.drive.tick()
+}
+
+func (f *fds) clockTimer() {
+	if !f.isTimerEnabled {
+		return
+	}
+	if f.timerCounter > 0 {
+		f.timerCounter--
+		return
+	}
+	f.isTimerIRQ = true
+	f.timerCounter = f.timerReload
+	f.isTimerEnabled = f.isTimerRepeat
+}
+
+func (f *fds) IsIRQ() bool {
+	return f.isTimerIRQ || f.drive.isIRQ
+}
+
+func (f *fds) GetAudioOutput() float32 {
+	return f.audio.getOutput()
+}
+
+func (f *fds) InsertSide(side int) error {
+	if side < 0 || side >= f.disk.GetSideCount() {
+		return fmt.Errorf(invalidSideFormat, side,
+			f.disk.GetSideCount())
+	}
+	f.drive.insert(side)
+	return nil
+}
+
+// EjectDisk saves disk writes like Close
+func (f *fds) EjectDisk() error {
+	f.drive.eject()
+	return f.disk.Save()
+}
+
+func (f *fds) GetSide() int {
+	return f.drive.side
+}
+
+func (f *fds) GetDisk() Disk {
+	return f.disk
+}
+
+func (f *fds) Close() error {
+	return f.disk.Save()
+}
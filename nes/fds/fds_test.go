@@ -0,0 +1,308 @@
+package fds_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/smarkuck/nes/nes/cartridge"
+	. "github.com/smarkuck/nes/nes/fds"
+	"github.com/smarkuck/nes/nes/save"
+	. "github.com/smarkuck/unittest"
+)
+
+const (
+	motorOn     = 0x01
+	readMode    = 0x04
+	horizontal  = 0x08
+	crcControl  = 0x10
+	alwaysSet   = 0x20
+	diskReady   = 0x40
+	transferIRQ = 0x80
+
+	insertDelay     = 1789773
+	byteCycles      = 151
+	maxTransferWait = 1000000
+
+	invalidReadText      = "invalid read"
+	invalidIRQText       = "invalid IRQ"
+	invalidStatusText    = "invalid drive status"
+	invalidMirroringText = "invalid mirroring"
+)
+
+func Test_FDS_WhenBIOSSizeInvalid_ReturnError(t *T) {
+	d, _ := LoadDisk(newSide(false), nil)
+
+	_, err := New(make([]byte, 0x1000), d)
+
+	ExpectTrue(t, err != nil, invalidErrorText)
+}
+
+func Test_FDS_MapRAMAndBIOS(t *T) {
+	f := newFDS(t, nil)
+
+	f.Write(0x6000, 1)
+	f.Write(0xdfff, 2)
+	f.Write(0xe000, 3)
+	f.WriteCHR(0x1fff, 4)
+
+	ExpectEq(t, f.Read(0x6000), 1, invalidReadText)
+	ExpectEq(t, f.Read(0xdfff), 2, invalidReadText)
+	ExpectEq(t, f.Read(0xe000), 0x00, invalidReadText)
+	ExpectEq(t, f.Read(0xffff), 0x1f, invalidReadText)
+	ExpectEq(t, f.ReadCHR(0x1fff), 4, invalidReadText)
+}
+
+func Test_FDS_SetMirroring(t *T) {
+	f := newFDS(t, nil)
+	f.Write(0x4023, 0x01)
+
+	f.Write(0x4025, alwaysSet)
+	ExpectEq(t, f.GetMirroring(), cartridge.Vertical,
+		invalidMirroringText)
+
+	f.Write(0x4025, alwaysSet|horizontal)
+	ExpectEq(t, f.GetMirroring(), cartridge.Horizontal,
+		invalidMirroringText)
+}
+
+func Test_FDS_TimerIRQ(t *T) {
+	f := newFDS(t, nil)
+	f.Write(0x4023, 0x01)
+	f.Write(0x4020, 0x02)
+	f.Write(0x4021, 0x00)
+
+	f.Write(0x4022, 0x03)
+	tick(f, 2)
+	ExpectFalse(t, f.IsIRQ(), invalidIRQText)
+	tick(f, 1)
+	ExpectTrue(t, f.IsIRQ(), invalidIRQText)
+
+	ExpectEq(t, f.Read(0x4030)&0x01, 0x01, invalidReadText)
+	ExpectFalse(t, f.IsIRQ(), invalidIRQText)
+	tick(f, 3)
+	ExpectTrue(t, f.IsIRQ(), invalidIRQText)
+}
+
+func Test_FDS_OneShotTimer(t *T) {
+	f := newFDS(t, nil)
+	f.Write(0x4023, 0x01)
+	f.Write(0x4020, 0x01)
+	f.Write(0x4022, 0x02)
+	tick(f, 2)
+	f.Read(0x4030)
+
+	tick(f, 10)
+
+	ExpectFalse(t, f.IsIRQ(), invalidIRQText)
+}
+
+func Test_FDS_DisableDiskRegisters(t *T) {
+	f := newFDS(t, nil)
+	f.Write(0x4023, 0x01)
+	f.Write(0x4022, 0x02)
+	tick(f, 1)
+
+	f.Write(0x4023, 0x00)
+	f.Write(0x4022, 0x02)
+	tick(f, 2)
+
+	ExpectFalse(t, f.IsIRQ(), invalidIRQText)
+	ExpectEq(t, f.Read(0x4032), 0x00, invalidStatusText)
+}
+
+func Test_FDS_ReportBattery(t *T) {
+	f := newFDS(t, nil)
+	f.Write(0x4023, 0x01)
+
+	f.Write(0x4026, 0x01)
+
+	ExpectEq(t, f.Read(0x4033), 0x81, invalidReadText)
+}
+
+func Test_FDS_SwapDiskSides(t *T) {
+	d, _ := LoadDisk(repeatSide(newSide(false), 2), nil)
+	f := newFDS(t, d)
+	f.Write(0x4023, 0x01)
+	ExpectEq(t, f.GetSide(), 0)
+	ExpectEq(t, f.Read(0x4032), 0x02, invalidStatusText)
+
+	f.EjectDisk()
+	ExpectEq(t, f.GetSide(), -1)
+	ExpectEq(t, f.Read(0x4032), 0x07, invalidStatusText)
+
+	ExpectTrue(t, f.InsertSide(1) == nil, invalidErrorText)
+	tick(f, insertDelay-1)
+	ExpectEq(t, f.GetSide(), -1)
+	tick(f, 1)
+	ExpectEq(t, f.GetSide(), 1)
+	ExpectTrue(t, f.InsertSide(2) != nil, invalidErrorText)
+}
+
+func Test_FDS_ReadDiskBlocks(t *T) {
+	f := newFDS(t, nil)
+
+	blocks := readBlocks(f, 4)
+
+	ExpectDeepEq(t, blocks, newBlocks(), invalidDiskText)
+	ExpectEq(t, f.Read(0x4032), 0x00, invalidStatusText)
+}
+
+func Test_FDS_StopAtEndOfSide(t *T) {
+	f := newFDS(t, nil)
+	f.Write(0x4023, 0x01)
+	f.Write(0x4025, alwaysSet|readMode|motorOn)
+	ExpectEq(t, f.Read(0x4030)&0x40, 0x40, invalidStatusText)
+	tick(f, 2)
+	ExpectEq(t, f.Read(0x4030)&0x40, 0x00, invalidStatusText)
+
+	tick(f, 0x12000*byteCycles+50000)
+
+	ExpectEq(t, f.Read(0x4030)&0x40, 0x40, invalidStatusText)
+}
+
+func Test_FDS_WriteDiskToDiff(t *T) {
+	image := newSide(false)
+	d, _ := LoadDisk(image, nil)
+	f := newFDS(t, d)
+	info := newBlocks()[0]
+	copy(info[1:], "*WRITTEN-DISK*")
+
+	writeBlock(f, info)
+
+	ExpectTrue(t, d.IsModified(), invalidDiskText)
+	diff, err := d.GetDiff()
+	ExpectTrue(t, err == nil, invalidErrorText)
+	patched, _ := LoadDisk(image, diff)
+	blocks := readBlocks(newFDS(t, patched), 4)
+	ExpectDeepEq(t, blocks[0], info, invalidDiskText)
+	ExpectDeepEq(t, blocks[3], newBlocks()[3], invalidDiskText)
+	ExpectEq(t, image[1], '*', invalidDiskText)
+	ExpectEq(t, image[2], 'N', invalidDiskText)
+}
+
+func Test_FDS_SaveDiskWrites(t *T) {
+	tests := []struct {
+		name  string
+		close func(f FDS) error
+	}{
+		{"OnEject", FDS.EjectDisk},
+		{"OnClose", FDS.Close},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			s := save.NewMemoryStorage()
+			image := newSide(false)
+			d, _ := LoadSavedDisk(image, s, "disk")
+			f := newFDS(t, d)
+			info := newBlocks()[0]
+			copy(info[1:], "*WRITTEN-DISK*")
+			writeBlock(f, info)
+			ExpectTrue(t, test.close(f) == nil, invalidErrorText)
+
+			saved, err := LoadSavedDisk(image, s, "disk")
+			ExpectTrue(t, err == nil, invalidErrorText)
+			blocks := readBlocks(newFDS(t, saved), 1)
+			ExpectDeepEq(t, blocks[0], info, invalidDiskText)
+		})
+	}
+}
+
+func Test_FDS_SaveDiskWritesToIPSFile(t *T) {
+	dir := t.TempDir()
+	image := newSide(false)
+	d, _ := LoadSavedDisk(image, NewDiffStorage(dir), "game")
+	f := newFDS(t, d)
+	info := newBlocks()[0]
+	copy(info[1:], "*WRITTEN-DISK*")
+	writeBlock(f, info)
+	ExpectTrue(t, f.Close() == nil, invalidErrorText)
+
+	_, err := os.Stat(filepath.Join(dir, "game.ips"))
+	ExpectTrue(t, err == nil, invalidErrorText)
+	saved, err := LoadSavedDisk(image, NewDiffStorage(dir), "game")
+	ExpectTrue(t, err == nil, invalidErrorText)
+	blocks := readBlocks(newFDS(t, saved), 1)
+	ExpectDeepEq(t, blocks[0], info, invalidDiskText)
+}
+
+func newFDS(t *T, d Disk) FDS {
+	if d == nil {
+		d, _ = LoadDisk(newSide(false), nil)
+	}
+	bios := make([]byte, 0x2000)
+	for i := range bios {
+		bios[i] = byte(i >> 8)
+	}
+	f, err := New(bios, d)
+	ExpectTrue(t, err == nil, invalidErrorText)
+	return f
+}
+
+func tick(f FDS, cycles int) {
+	for i := 0; i < cycles; i++ {
+		f.Tick()
+	}
+}
+
+func waitTransfer(f FDS) {
+	for i := 0; i < maxTransferWait && !f.IsIRQ(); i++ {
+		f.Tick()
+	}
+}
+
+// mimics BIOS: start reading after the gap and take
+// block type to know size of the following block
+func readBlocks(f FDS, count int) [][]byte {
+	f.Write(0x4023, 0x01)
+	control := byte(alwaysSet | readMode | motorOn | transferIRQ)
+	f.Write(0x4025, control|diskReady)
+	var blocks [][]byte
+	fileSize := 0
+	for i := 0; i < count; i++ {
+		waitTransfer(f)
+		block := []byte{f.Read(0x4031)}
+		size := map[byte]int{1: 56, 2: 2, 3: 16, 4: 1 + fileSize}
+		for len(block) < size[block[0]] {
+			waitTransfer(f)
+			block = append(block, f.Read(0x4031))
+		}
+		if block[0] == 3 {
+			fileSize = int(block[13]) | int(block[14])<<8
+		}
+		blocks = append(blocks, block)
+		f.Write(0x4025, control|diskReady|crcControl)
+		waitTransfer(f)
+		f.Read(0x4031)
+		waitTransfer(f)
+		f.Read(0x4031)
+		f.Write(0x4025, control)
+		tick(f, byteCycles)
+		f.Write(0x4025, control|diskReady)
+	}
+	return blocks
+}
+
+// overwrites first block in place, head writes two bytes
+// behind the transfer position and CRC bytes do not
+// raise transfer IRQ
+func writeBlock(f FDS, block []byte) {
+	f.Write(0x4023, 0x01)
+	control := byte(alwaysSet | motorOn | transferIRQ)
+	f.Write(0x4024, 0x00)
+	f.Write(0x4025, control)
+	for i := 0; i < leadingGap+2; i++ {
+		waitTransfer(f)
+		f.Write(0x4024, 0x00)
+	}
+	f.Write(0x4024, 0x80)
+	f.Write(0x4025, control|diskReady)
+	for _, b := range block {
+		waitTransfer(f)
+		f.Write(0x4024, b)
+	}
+	waitTransfer(f)
+	f.Write(0x4025, control|diskReady|crcControl)
+	tick(f, 2*byteCycles)
+	f.Write(0x4025, alwaysSet)
+}
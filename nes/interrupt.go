@@ -0,0 +1,10 @@
+package nes
+
+type IRQSource interface {
+	IsIRQ() bool
+}
+
+// NMISource reports NMI line level, CPU reacts on edge
+type NMISource interface {
+	IsNMI() bool
+}
@@ -0,0 +1,139 @@
+package mapper
+
+import "github.com/smarkuck/nes/nes/cartridge"
+
+const (
+	bandaiRegister   = 0x0f
+	bandaiPRGBank    = 0x0f
+	bandaiMirroring  = 0x03
+	bandaiIRQEnabled = 0x01
+
+	bandaiSCL        = 0x20
+	bandaiSDA        = 0x40
+	bandaiReadEnable = 0x80
+	bandaiDataOut    = 0x10
+
+	bandaiFCGSubMapper     = 4
+	bandaiLZ93D50SubMapper = 5
+)
+
+// FCG-1/2 are controlled at $6000-$7FFF and load IRQ counter
+// directly, LZ93D50 uses $8000-$FFFF and latches it
+type bandaiFCG struct {
+	base
+	eeprom       *eeprom
+	eepromCtrl   byte
+	isFCG        bool
+	isLZ93D50    bool
+	irqLatch     uint16
+	irqCounter   uint16
+	isIRQEnabled bool
+	isIRQPending bool
+}
+
+func newBandaiFCG(c *cartridge.Cartridge) Mapper {
+	m := &bandaiFCG{
+		base:      newBase(c, 0),
+		isFCG:     c.SubMapper != bandaiLZ93D50SubMapper,
+		isLZ93D50: c.SubMapper != bandaiFCGSubMapper,
+	}
+	switch {
+	case c.Mapper == 159:
+		m.eeprom = new24C01()
+	case c.SubMapper != bandaiFCGSubMapper:
+		m.eeprom = new24C02()
+	}
+	m.prg.set(0x4000, 0x4000, -1)
+	m.mirroring = cartridge.Vertical
+	return m
+}
+
+func (m *bandaiFCG) Read(addr uint16) byte {
+	if addr >= prgROMAddr {
+		return m.base.Read(addr)
+	}
+	if addr >= prgRAMAddr && m.isEEPROMReadable() {
+		if m.eeprom.read() {
+			return bandaiDataOut
+		}
+	}
+	return 0
+}
+
+func (m *bandaiFCG) isEEPROMReadable() bool {
+	return m.eeprom != nil &&
+		m.eepromCtrl&bandaiReadEnable != 0
+}
+
+func (m *bandaiFCG) Write(addr uint16, value byte) {
+	switch {
+	case addr >= prgROMAddr && m.isLZ93D50:
+		m.writeRegister(addr, value)
+	case addr >= prgRAMAddr && addr < prgROMAddr && m.isFCG:
+		m.writeRegister(addr, value)
+	}
+}
+
+func (m *bandaiFCG) writeRegister(addr uint16, value byte) {
+	switch r := addr & bandaiRegister; {
+	case r < 8:
+		m.chr.set(int(r)*chrWindow, chrWindow, int(value))
+	case r == 8:
+		m.prg.set(0x0000, 0x4000, int(value&bandaiPRGBank))
+	case r == 9:
+		m.mirroring = vrcMirrorings[value&bandaiMirroring]
+	case r == 0x0a:
+		m.writeIRQControl(value)
+	case r == 0x0b:
+		m.writeIRQValue(m.irqLatch&0xff00 | uint16(value))
+	case r == 0x0c:
+		m.writeIRQValue(m.irqLatch&0x00ff | uint16(value)<<8)
+	case r == 0x0d:
+		m.writeEEPROM(value)
+	}
+}
+
+func (m *bandaiFCG) writeIRQControl(value byte) {
+	m.isIRQEnabled = value&bandaiIRQEnabled != 0
+	m.isIRQPending = false
+	if !m.isFCG {
+		m.irqCounter = m.irqLatch
+	}
+}
+
+func (m *bandaiFCG) writeIRQValue(value uint16) {
+	m.irqLatch = value
+	if m.isFCG {
+		m.irqCounter = value
+	}
+}
+
+func (m *bandaiFCG) writeEEPROM(value byte) {
+	m.eepromCtrl = value
+	if m.eeprom != nil {
+		m.eeprom.write(value&bandaiSCL != 0,
+			value&bandaiSDA != 0)
+	}
+}
+
+// EEPROM keeps its content without battery
+func (m *bandaiFCG) GetSaveRAM() []byte {
+	if m.eeprom == nil {
+		return nil
+	}
+	return m.eeprom.data
+}
+
+func (m *bandaiFCG) IsIRQ() bool {
+	return m.isIRQPending
+}
+
+func (m *bandaiFCG) Tick() {
+	if !m.isIRQEnabled {
+		return
+	}
+	m.irqCounter--
+	if m.irqCounter == 0 {
+		m.isIRQPending = true
+	}
+}
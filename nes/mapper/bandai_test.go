@@ -0,0 +1,250 @@
+package mapper_test
+
+import (
+	"github.com/smarkuck/nes/nes/cartridge"
+	. "github.com/smarkuck/nes/nes/mapper"
+	. "github.com/smarkuck/unittest"
+)
+
+const (
+	bandaiFCG     = 4
+	bandaiLZ93D50 = 5
+
+	invalidEEPROMText = "invalid EEPROM content"
+	invalidAckText    = "invalid EEPROM acknowledge"
+)
+
+type i2cBus struct {
+	m          Mapper
+	isLSBFirst bool
+}
+
+func (b *i2cBus) set(scl, sda bool) {
+	value := byte(0x80)
+	if scl {
+		value |= 0x20
+	}
+	if sda {
+		value |= 0x40
+	}
+	b.m.Write(0x800d, value)
+}
+
+func (b *i2cBus) start() {
+	b.set(false, true)
+	b.set(true, true)
+	b.set(true, false)
+	b.set(false, false)
+}
+
+func (b *i2cBus) stop() {
+	b.set(false, false)
+	b.set(true, false)
+	b.set(true, true)
+}
+
+func (b *i2cBus) writeBit(bit bool) {
+	b.set(false, bit)
+	b.set(true, bit)
+	b.set(false, bit)
+}
+
+func (b *i2cBus) readBit() bool {
+	b.set(false, true)
+	b.set(true, true)
+	bit := b.m.Read(0x6000)&0x10 != 0
+	b.set(false, true)
+	return bit
+}
+
+func (b *i2cBus) getBitIndex(i int) int {
+	if b.isLSBFirst {
+		return i
+	}
+	return 7 - i
+}
+
+func (b *i2cBus) writeByte(value byte) bool {
+	for i := 0; i < 8; i++ {
+		b.writeBit(value>>b.getBitIndex(i)&0x01 != 0)
+	}
+	return !b.readBit()
+}
+
+func (b *i2cBus) readByte(isLast bool) byte {
+	var value byte
+	for i := 0; i < 8; i++ {
+		if b.readBit() {
+			value |= 1 << b.getBitIndex(i)
+		}
+	}
+	b.writeBit(isLast)
+	return value
+}
+
+func newBandai(t *T, mapper uint16, subMapper uint8) Mapper {
+	return newMapper(t, &cartridge.Cartridge{
+		PRG:       newBankedData(8, prgBankSize),
+		CHR:       newBankedData(64, chr1KBBankSize),
+		Mapper:    mapper,
+		SubMapper: subMapper,
+	})
+}
+
+func Test_Bandai_Banks(t *T) {
+	tests := []struct {
+		name      string
+		subMapper uint8
+		addr      uint16
+	}{
+		{"FCG", bandaiFCG, 0x6000},
+		{"LZ93D50", bandaiLZ93D50, 0x8000},
+		{"Unknown", 0, 0x7ff0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			m := newBandai(t, 16, test.subMapper)
+			for i := uint16(0); i < 8; i++ {
+				m.Write(test.addr+i, byte(30+i))
+			}
+			m.Write(test.addr+8, 3)
+			m.Write(test.addr+9, 1)
+			expectPRGEq(t, m, 0x8000, 3)
+			expectPRGEq(t, m, 0xc000, 7)
+			for i := uint16(0); i < 8; i++ {
+				expectCHREq(t, m, i*chr1KBBankSize, byte(30+i))
+			}
+			expectMirroringEq(t, m, cartridge.Horizontal)
+		})
+	}
+}
+
+func Test_Bandai_IgnoreOtherRegisterRange(t *T) {
+	m := newBandai(t, 16, bandaiFCG)
+
+	m.Write(0x8008, 3)
+
+	expectPRGEq(t, m, 0x8000, 0)
+}
+
+func Test_Bandai_IRQ(t *T) {
+	tests := []struct {
+		name      string
+		subMapper uint8
+		addr      uint16
+	}{
+		{"FCGLoadsCounter", bandaiFCG, 0x600b},
+		{"LZ93D50LatchesCounter", bandaiLZ93D50, 0x800b},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			m := newBandai(t, 16, test.subMapper)
+			m.Write(test.addr, 0x05)
+			m.Write(test.addr+1, 0x00)
+			m.Write(test.addr-1, 0x01)
+			m.Write(test.addr, 0x03)
+			tick(m, 2)
+			expectIRQEq(t, m, false)
+			tick(m, 1)
+			expectIRQEq(t, m, test.subMapper == bandaiFCG)
+			tick(m, 2)
+			expectIRQEq(t, m, true)
+			m.Write(test.addr-1, 0x00)
+			expectIRQEq(t, m, false)
+		})
+	}
+}
+
+func Test_Bandai_24C02(t *T) {
+	m := newBandai(t, 16, bandaiLZ93D50)
+	bus := &i2cBus{m: m}
+
+	bus.start()
+	ExpectTrue(t, bus.writeByte(0xa0), invalidAckText)
+	ExpectTrue(t, bus.writeByte(0x16), invalidAckText)
+	ExpectTrue(t, bus.writeByte(0x12), invalidAckText)
+	ExpectTrue(t, bus.writeByte(0x34), invalidAckText)
+	ExpectTrue(t, bus.writeByte(0x56), invalidAckText)
+	bus.stop()
+
+	ram := m.(SaveRAMProvider).GetSaveRAM()
+	ExpectEq(t, len(ram), 256)
+	ExpectDeepEq(t, ram[0x10:0x17],
+		[]byte{0x56, 0, 0, 0, 0, 0, 0x12}, invalidEEPROMText)
+	ExpectEq(t, ram[0x17], 0x34, invalidEEPROMText)
+
+	bus.start()
+	bus.writeByte(0xa0)
+	bus.writeByte(0x16)
+	bus.start()
+	ExpectTrue(t, bus.writeByte(0xa1), invalidAckText)
+	ExpectEq(t, bus.readByte(false), 0x12, invalidEEPROMText)
+	ExpectEq(t, bus.readByte(true), 0x34, invalidEEPROMText)
+	bus.stop()
+}
+
+func Test_Bandai_24C02_WithWrongDevice_DoNotAck(t *T) {
+	m := newBandai(t, 16, 0)
+	bus := &i2cBus{m: m}
+
+	bus.start()
+
+	ExpectFalse(t, bus.writeByte(0x50), invalidAckText)
+}
+
+func Test_Bandai_24C01(t *T) {
+	m := newBandai(t, 159, 0)
+	bus := &i2cBus{m: m, isLSBFirst: true}
+
+	bus.start()
+	ExpectTrue(t, bus.writeByte(0x05), invalidAckText)
+	ExpectTrue(t, bus.writeByte(0xab), invalidAckText)
+	ExpectTrue(t, bus.writeByte(0xcd), invalidAckText)
+	bus.stop()
+	bus.start()
+	ExpectTrue(t, bus.writeByte(0x85), invalidAckText)
+	ExpectEq(t, bus.readByte(false), 0xab, invalidEEPROMText)
+	ExpectEq(t, bus.readByte(true), 0xcd, invalidEEPROMText)
+	bus.stop()
+
+	ExpectEq(t, len(m.(SaveRAMProvider).GetSaveRAM()), 128)
+}
+
+func Test_Bandai_WithoutReadEnable_ReadZero(t *T) {
+	m := newBandai(t, 16, 0)
+
+	m.Write(0x800d, 0x40)
+
+	ExpectEq(t, m.Read(0x6000), 0x00, invalidEEPROMText)
+}
+
+func Test_Bandai_FCGHasNoEEPROM(t *T) {
+	m := newBandai(t, 16, bandaiFCG)
+
+	ExpectEq(t, len(m.(SaveRAMProvider).GetSaveRAM()), 0)
+}
+
+func Test_SaveRAM_RequiresBattery(t *T) {
+	tests := []struct {
+		name    string
+		battery bool
+		size    int
+	}{
+		{"WithBattery", true, 0x2000},
+		{"WithoutBattery", false, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			m := newMapper(t, &cartridge.Cartridge{
+				PRG:     newBankedData(2, prgBankSize),
+				Mapper:  1,
+				Battery: test.battery,
+			})
+			ram := m.(SaveRAMProvider).GetSaveRAM()
+			ExpectEq(t, len(ram), test.size)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package mapper
+
+type banks struct {
+	data    []byte
+	offsets []int
+	window  int
+}
+
+func newBanks(data []byte, size, window int) banks {
+	b := banks{
+		data:    data,
+		offsets: make([]int, size/window),
+		window:  window,
+	}
+	b.set(0, size, 0)
+	return b
+}
+
+func (b *banks) set(addr, size, bank int) {
+	if len(b.data) == 0 {
+		return
+	}
+	start := b.getBankStart(size, bank)
+	for i := 0; i < size/b.window; i++ {
+		offset := (start + i*b.window) % len(b.data)
+		b.offsets[addr/b.window+i] = offset
+	}
+}
+
+func (b *banks) getBankStart(size, bank int) int {
+	count := len(b.data) / size
+	if count == 0 {
+		return 0
+	}
+	// negative banks are counted from the end
+	return (bank%count + count) % count * size
+}
+
+func (b *banks) read(addr int) byte {
+	if len(b.data) == 0 {
+		return 0
+	}
+	return b.data[b.getIndex(addr)]
+}
+
+func (b *banks) write(addr int, value byte) {
+	if len(b.data) != 0 {
+		b.data[b.getIndex(addr)] = value
+	}
+}
+
+func (b *banks) getIndex(addr int) int {
+	return b.offsets[addr/b.window] + addr%b.window
+}
+
+func (b *banks) getSize() int {
+	return len(b.data)
+}
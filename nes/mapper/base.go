@@ -0,0 +1,96 @@
+package mapper
+
+import "github.com/smarkuck/nes/nes/cartridge"
+
+const (
+	prgRAMAddr = 0x6000
+	prgROMAddr = 0x8000
+
+	prgROMSize   = 0x8000
+	prgROMWindow = 0x2000
+	prgRAMWindow = 0x2000
+	chrSize      = 0x2000
+	chrWindow    = 0x0400
+
+	defaultPRGRAMSize = 0x2000
+	defaultCHRRAMSize = 0x2000
+)
+
+type base struct {
+	prg        banks
+	prgRAM     banks
+	chr        banks
+	mirroring  cartridge.Mirroring
+	isCHRRAM   bool
+	hasBattery bool
+}
+
+func newBase(c *cartridge.Cartridge, prgRAMSize int) base {
+	chr, isCHRRAM := c.CHR, len(c.CHR) == 0
+	if isCHRRAM {
+		chr = make([]byte, getCHRRAMSize(c))
+	}
+	return base{
+		prg: newBanks(c.PRG, prgROMSize, prgROMWindow),
+		prgRAM: newBanks(make([]byte, prgRAMSize),
+			prgRAMWindow, prgRAMWindow),
+		chr:        newBanks(chr, chrSize, chrWindow),
+		mirroring:  c.Mirroring,
+		isCHRRAM:   isCHRRAM,
+		hasBattery: c.Battery,
+	}
+}
+
+func getPRGRAMSize(c *cartridge.Cartridge) int {
+	if c.PRGRAMSize == 0 {
+		return defaultPRGRAMSize
+	}
+	return c.PRGRAMSize
+}
+
+func getCHRRAMSize(c *cartridge.Cartridge) int {
+	if c.CHRRAMSize == 0 {
+		return defaultCHRRAMSize
+	}
+	return c.CHRRAMSize
+}
+
+func (b *base) Read(addr uint16) byte {
+	switch {
+	case addr >= prgROMAddr:
+		return b.prg.read(int(addr - prgROMAddr))
+	case addr >= prgRAMAddr:
+		return b.prgRAM.read(int(addr - prgRAMAddr))
+	}
+	return 0
+}
+
+func (b *base) Write(addr uint16, value byte) {
+	if addr >= prgRAMAddr && addr < prgROMAddr {
+		b.prgRAM.write(int(addr-prgRAMAddr), value)
+	}
+}
+
+func (b *base) ReadCHR(addr uint16) byte {
+	return b.chr.read(int(addr))
+}
+
+func (b *base) WriteCHR(addr uint16, value byte) {
+	if b.isCHRRAM {
+		b.chr.write(int(addr), value)
+	}
+}
+
+func (b *base) GetMirroring() cartridge.Mirroring {
+	return b.mirroring
+}
+
+// without battery PRG-RAM content is lost on power off
+func (b *base) GetSaveRAM() []byte {
+	if !b.hasBattery {
+		return nil
+	}
+	return b.prgRAM.data
+}
+
+func (b *base) Tick() {}
@@ -0,0 +1,116 @@
+package mapper
+
+import "github.com/smarkuck/nes/nes/cartridge"
+
+const (
+	noBusConflicts   = 1
+	withBusConflicts = 2
+
+	axromPRGBank  = 0x07
+	axromScreenB  = 0x10
+	gxromPRGBank  = 0x30
+	gxromPRGShift = 4
+	gxromCHRBank  = 0x03
+)
+
+type discrete struct {
+	base
+	hasBusConflicts bool
+}
+
+func newDiscrete(c *cartridge.Cartridge,
+	hasConflictsByDefault bool) discrete {
+	conflicts := hasBusConflicts(c, hasConflictsByDefault)
+	return discrete{newBase(c, c.PRGRAMSize), conflicts}
+}
+
+func hasBusConflicts(c *cartridge.Cartridge,
+	byDefault bool) bool {
+	switch c.SubMapper {
+	case noBusConflicts:
+		return false
+	case withBusConflicts:
+		return true
+	}
+	return byDefault
+}
+
+func (d *discrete) write(addr uint16, value byte,
+	latch func(byte)) {
+	if addr < prgROMAddr {
+		d.base.Write(addr, value)
+		return
+	}
+	// ROM drives the data bus at the same time as CPU,
+	// so the latch receives both values ANDed together
+	if d.hasBusConflicts {
+		value &= d.Read(addr)
+	}
+	latch(value)
+}
+
+type uxrom struct {
+	discrete
+}
+
+func newUxROM(c *cartridge.Cartridge) Mapper {
+	u := &uxrom{newDiscrete(c, true)}
+	u.prg.set(0x4000, 0x4000, -1)
+	return u
+}
+
+func (u *uxrom) Write(addr uint16, value byte) {
+	u.write(addr, value, func(bank byte) {
+		u.prg.set(0x0000, 0x4000, int(bank))
+	})
+}
+
+type cnrom struct {
+	discrete
+}
+
+func newCNROM(c *cartridge.Cartridge) Mapper {
+	return &cnrom{newDiscrete(c, true)}
+}
+
+func (n *cnrom) Write(addr uint16, value byte) {
+	n.write(addr, value, func(bank byte) {
+		n.chr.set(0x0000, 0x2000, int(bank))
+	})
+}
+
+type axrom struct {
+	discrete
+}
+
+func newAxROM(c *cartridge.Cartridge) Mapper {
+	a := &axrom{newDiscrete(c, false)}
+	a.mirroring = cartridge.SingleScreenA
+	return a
+}
+
+func (a *axrom) Write(addr uint16, value byte) {
+	a.write(addr, value, func(v byte) {
+		a.prg.set(0x0000, 0x8000, int(v&axromPRGBank))
+		a.mirroring = cartridge.SingleScreenA
+		if v&axromScreenB != 0 {
+			a.mirroring = cartridge.SingleScreenB
+		}
+	})
+}
+
+type gxrom struct {
+	discrete
+}
+
+func newGxROM(c *cartridge.Cartridge) Mapper {
+	return &gxrom{newDiscrete(c, true)}
+}
+
+func (g *gxrom) Write(addr uint16, value byte) {
+	g.write(addr, value, func(v byte) {
+		prgBank := (v & gxromPRGBank) >> gxromPRGShift
+		g.prg.set(0x0000, 0x8000, int(prgBank))
+		g.chr.set(0x0000, 0x2000, int(v&gxromCHRBank))
+	})
+}
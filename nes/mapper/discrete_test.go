@@ -0,0 +1,158 @@
+package mapper_test
+
+import (
+	"github.com/smarkuck/nes/nes/cartridge"
+	. "github.com/smarkuck/unittest"
+)
+
+const (
+	prg32KBBankSize = 0x8000
+	chr8KBBankSize  = 0x2000
+
+	defaultBoard = 0
+	noConflicts  = 1
+	conflicts    = 2
+)
+
+func Test_UxROM(t *T) {
+	tests := []struct {
+		name      string
+		subMapper uint8
+		addr      uint16
+		value     byte
+		low       byte
+		high      byte
+	}{
+		{"OnPowerUp_FixLastBank", defaultBoard,
+			0x6000, 0, 0, 7},
+		{"SwitchLowBank", defaultBoard, 0xc000, 3, 3, 7},
+		{"BusConflictByDefault", defaultBoard, 0x8000, 3, 0, 7},
+		{"BusConflict", conflicts, 0x8000, 3, 0, 7},
+		{"NoBusConflict", noConflicts, 0x8000, 3, 3, 7},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			m := newMapper(t, &cartridge.Cartridge{
+				PRG:       newBankedData(8, prgBankSize),
+				Mapper:    2,
+				SubMapper: test.subMapper,
+			})
+			m.Write(test.addr, test.value)
+			expectPRGEq(t, m, 0x8000, test.low)
+			expectPRGEq(t, m, 0xc000, test.high)
+		})
+	}
+}
+
+func Test_CNROM(t *T) {
+	tests := []struct {
+		name      string
+		subMapper uint8
+		addr      uint16
+		value     byte
+		chr       byte
+	}{
+		{"OnPowerUp_SelectFirstBank", defaultBoard,
+			0x6000, 3, 0},
+		{"SwitchCHRBank", noConflicts, 0xc000, 2, 2},
+		{"BusConflictByDefault", defaultBoard, 0xc000, 2, 0},
+		{"BusConflict", conflicts, 0xc000, 3, 1},
+		{"NoBusConflict", noConflicts, 0x8000, 3, 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			m := newMapper(t, &cartridge.Cartridge{
+				PRG:       newBankedData(2, prgBankSize),
+				CHR:       newBankedData(4, chr8KBBankSize),
+				Mapper:    3,
+				SubMapper: test.subMapper,
+			})
+			m.Write(test.addr, test.value)
+			expectCHREq(t, m, 0x0000, test.chr)
+			expectCHREq(t, m, 0x1fff, test.chr)
+		})
+	}
+}
+
+func Test_AxROM(t *T) {
+	tests := []struct {
+		name      string
+		subMapper uint8
+		addr      uint16
+		value     byte
+		prg       byte
+		mirroring cartridge.Mirroring
+	}{
+		{"OnPowerUp_FirstBankScreenA", defaultBoard,
+			0x6000, 0x13, 0, cartridge.SingleScreenA},
+		{"SwitchBankScreenB", defaultBoard,
+			0x8000, 0x13, 3, cartridge.SingleScreenB},
+		{"SwitchBankScreenA", defaultBoard,
+			0xffff, 0x05, 5, cartridge.SingleScreenA},
+		{"IgnoreHighBankBits", defaultBoard,
+			0x8000, 0x0e, 6, cartridge.SingleScreenA},
+		{"BusConflict", conflicts,
+			0x8000, 0x13, 0, cartridge.SingleScreenA},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			m := newMapper(t, &cartridge.Cartridge{
+				PRG:       newBankedData(8, prg32KBBankSize),
+				Mapper:    7,
+				SubMapper: test.subMapper,
+			})
+			m.Write(test.addr, test.value)
+			expectPRGEq(t, m, 0x8000, test.prg)
+			expectPRGEq(t, m, 0xffff, test.prg)
+			expectMirroringEq(t, m, test.mirroring)
+		})
+	}
+}
+
+func Test_GxROM(t *T) {
+	tests := []struct {
+		name      string
+		subMapper uint8
+		addr      uint16
+		value     byte
+		prg       byte
+		chr       byte
+	}{
+		{"OnPowerUp_SelectFirstBanks", defaultBoard,
+			0x6000, 0x33, 0, 0},
+		{"SwitchBanks", noConflicts, 0x8000, 0x21, 2, 1},
+		{"IgnoreUnusedBits", noConflicts, 0x8000, 0xcc, 0, 0},
+		{"BusConflictByDefault", defaultBoard,
+			0x8000, 0x21, 0, 0},
+		{"BusConflict", conflicts, 0x8000, 0x33, 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			m := newMapper(t, &cartridge.Cartridge{
+				PRG:       newBankedData(4, prg32KBBankSize),
+				CHR:       newBankedData(4, chr8KBBankSize),
+				Mapper:    66,
+				SubMapper: test.subMapper,
+			})
+			m.Write(test.addr, test.value)
+			expectPRGEq(t, m, 0x8000, test.prg)
+			expectCHREq(t, m, 0x0000, test.chr)
+		})
+	}
+}
+
+func Test_DiscreteMappers_WritePRGRAM(t *T) {
+	for _, mapper := range []uint16{2, 3, 7, 66} {
+		m := newMapper(t, &cartridge.Cartridge{
+			PRG:        newBankedData(2, prgBankSize),
+			PRGRAMSize: 0x2000,
+			Mapper:     mapper,
+		})
+		m.Write(0x6000, 0x42)
+		ExpectEq(t, m.Read(0x6000), 0x42, invalidPRGRAMText)
+	}
+}
@@ -0,0 +1,195 @@
+package mapper
+
+const (
+	eeprom24C01Size = 0x80
+	eeprom24C02Size = 0x100
+
+	eeprom24C01Page = 0x03
+	eeprom24C02Page = 0x07
+
+	eepromDeviceCode = 0x0a
+	eepromReadBit    = 0x01
+	eepromAckBit     = 8
+	eepromByteBits   = 8
+)
+
+const (
+	eepromIdle = iota
+	eepromDevice
+	eepromAddress
+	eepromWrite
+	eepromRead
+)
+
+// serial EEPROM on I2C-like bus, 24C02 is addressed with
+// device byte and sends MSB first, 24C01 starts with
+// word address and sends LSB first
+type eeprom struct {
+	data    []byte
+	is24C01 bool
+	scl     bool
+	sda     bool
+	output  bool
+	state   int
+	next    int
+	bit     int
+	shift   byte
+	address byte
+	isNack  bool
+}
+
+func new24C01() *eeprom {
+	return &eeprom{
+		data:    make([]byte, eeprom24C01Size),
+		is24C01: true,
+		output:  true,
+	}
+}
+
+func new24C02() *eeprom {
+	return &eeprom{
+		data:   make([]byte, eeprom24C02Size),
+		output: true,
+	}
+}
+
+func (e *eeprom) write(scl, sda bool) {
+	switch {
+	case e.scl && scl && e.sda && !sda:
+		e.start()
+	case e.scl && scl && !e.sda && sda:
+		e.state, e.output = eepromIdle, true
+	case !e.scl && scl:
+		e.rise(sda)
+	case e.scl && !scl:
+		e.fall()
+	}
+	e.scl, e.sda = scl, sda
+}
+
+func (e *eeprom) read() bool {
+	return e.output
+}
+
+func (e *eeprom) start() {
+	e.state, e.bit, e.output = eepromDevice, 0, true
+	if e.is24C01 {
+		e.state = eepromAddress
+	}
+}
+
+func (e *eeprom) rise(sda bool) {
+	switch e.state {
+	case eepromIdle:
+		return
+	case eepromRead:
+		if e.bit == eepromAckBit {
+			e.isNack = sda
+		}
+	default:
+		if e.bit < eepromByteBits {
+			e.receiveBit(sda)
+		}
+	}
+	e.bit++
+}
+
+func (e *eeprom) receiveBit(sda bool) {
+	var bit byte
+	if sda {
+		bit = 1
+	}
+	if e.is24C01 {
+		e.shift = e.shift>>1 | bit<<7
+	} else {
+		e.shift = e.shift<<1 | bit
+	}
+}
+
+func (e *eeprom) fall() {
+	switch {
+	case e.state == eepromIdle:
+	case e.state == eepromRead:
+		e.fallRead()
+	case e.bit == eepromAckBit:
+		e.next = e.receiveByte()
+		e.output = e.next == eepromIdle
+	case e.bit > eepromAckBit:
+		e.state, e.bit, e.output = e.next, 0, true
+		if e.state == eepromRead {
+			e.loadByte()
+		}
+	}
+}
+
+func (e *eeprom) fallRead() {
+	if e.bit > eepromAckBit {
+		if e.isNack {
+			e.state = eepromIdle
+			return
+		}
+		e.address = e.wrapAddress(e.address + 1)
+		e.loadByte()
+		return
+	}
+	if e.bit == eepromAckBit {
+		e.output = true
+		return
+	}
+	e.outputBit()
+}
+
+func (e *eeprom) loadByte() {
+	e.shift, e.bit = e.data[e.address], 0
+	e.outputBit()
+}
+
+func (e *eeprom) outputBit() {
+	if e.is24C01 {
+		e.output = e.shift>>e.bit&0x01 != 0
+	} else {
+		e.output = e.shift>>(7-e.bit)&0x01 != 0
+	}
+}
+
+func (e *eeprom) receiveByte() int {
+	switch e.state {
+	case eepromDevice:
+		return e.receiveDevice()
+	case eepromAddress:
+		return e.receiveAddress()
+	}
+	e.data[e.address] = e.shift
+	page := byte(eeprom24C02Page)
+	if e.is24C01 {
+		page = eeprom24C01Page
+	}
+	e.address = e.address&^page | (e.address+1)&page
+	return eepromWrite
+}
+
+func (e *eeprom) receiveDevice() int {
+	switch {
+	case e.shift>>4 != eepromDeviceCode:
+		return eepromIdle
+	case e.shift&eepromReadBit != 0:
+		return eepromRead
+	}
+	return eepromAddress
+}
+
+func (e *eeprom) receiveAddress() int {
+	if !e.is24C01 {
+		e.address = e.shift
+		return eepromWrite
+	}
+	e.address = e.wrapAddress(e.shift)
+	if e.shift&0x80 != 0 {
+		return eepromRead
+	}
+	return eepromWrite
+}
+
+func (e *eeprom) wrapAddress(address byte) byte {
+	return byte(int(address) % len(e.data))
+}
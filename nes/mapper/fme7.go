@@ -0,0 +1,113 @@
+package mapper
+
+import "github.com/smarkuck/nes/nes/cartridge"
+
+const (
+	fme7Command    = 0x0f
+	fme7PRGBank    = 0x3f
+	fme7RAMSelect  = 0x40
+	fme7RAMEnabled = 0x80
+	fme7Mirroring  = 0x03
+	fme7IRQEnabled = 0x01
+	fme7CountOn    = 0x80
+)
+
+type fme7 struct {
+	base
+	command      byte
+	prgRAMBank   byte
+	irqControl   byte
+	irqCounter   uint16
+	isIRQPending bool
+	audio        sunsoft5B
+}
+
+func newFME7(c *cartridge.Cartridge) Mapper {
+	m := &fme7{base: newBase(c, getPRGRAMSize(c))}
+	m.prg.set(0x6000, 0x2000, -1)
+	m.mirroring = cartridge.Vertical
+	return m
+}
+
+// $6000-$7FFF maps either PRG-ROM or PRG-RAM bank
+func (m *fme7) Read(addr uint16) byte {
+	switch {
+	case addr >= prgROMAddr:
+		return m.base.Read(addr)
+	case addr < prgRAMAddr:
+		return 0
+	case m.prgRAMBank&fme7RAMSelect == 0:
+		return m.readROMAt6000(addr)
+	case m.prgRAMBank&fme7RAMEnabled != 0:
+		return m.base.Read(addr)
+	}
+	return 0
+}
+
+func (m *fme7) readROMAt6000(addr uint16) byte {
+	data := m.prg.data
+	if len(data) == 0 {
+		return 0
+	}
+	bank := int(m.prgRAMBank & fme7PRGBank)
+	index := bank*prgROMWindow + int(addr-prgRAMAddr)
+	return data[index%len(data)]
+}
+
+func (m *fme7) Write(addr uint16, value byte) {
+	switch addr & 0xe000 {
+	case 0x6000:
+		if m.prgRAMBank&(fme7RAMSelect|fme7RAMEnabled) ==
+			fme7RAMSelect|fme7RAMEnabled {
+			m.base.Write(addr, value)
+		}
+	case 0x8000:
+		m.command = value & fme7Command
+	case 0xa000:
+		m.writeParameter(value)
+	case 0xc000:
+		m.audio.selectRegister(value)
+	case 0xe000:
+		m.audio.writeRegister(value)
+	}
+}
+
+func (m *fme7) writeParameter(value byte) {
+	switch c := m.command; {
+	case c < 8:
+		m.chr.set(int(c)*chrWindow, chrWindow, int(value))
+	case c == 8:
+		m.prgRAMBank = value
+	case c < 0x0c:
+		bank := int(value & fme7PRGBank)
+		m.prg.set(int(c-9)*prgROMWindow, prgROMWindow, bank)
+	case c == 0x0c:
+		m.mirroring = vrcMirrorings[value&fme7Mirroring]
+	case c == 0x0d:
+		m.irqControl, m.isIRQPending = value, false
+	case c == 0x0e:
+		m.irqCounter = m.irqCounter&0xff00 | uint16(value)
+	case c == 0x0f:
+		m.irqCounter = m.irqCounter&0x00ff | uint16(value)<<8
+	}
+}
+
+func (m *fme7) IsIRQ() bool {
+	return m.isIRQPending
+}
+
+func (m *fme7) GetAudioOutput() float32 {
+	return m.audio.getOutput()
+}
+
+func (m *fme7) Tick() {
+	m.audio.tick()
+	if m.irqControl&fme7CountOn == 0 {
+		return
+	}
+	m.irqCounter--
+	if m.irqCounter == 0xffff &&
+		m.irqControl&fme7IRQEnabled != 0 {
+		m.isIRQPending = true
+	}
+}
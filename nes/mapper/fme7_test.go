@@ -0,0 +1,135 @@
+package mapper_test
+
+import (
+	"github.com/smarkuck/nes/nes/cartridge"
+	. "github.com/smarkuck/nes/nes/mapper"
+	. "github.com/smarkuck/unittest"
+)
+
+const ayMixScale = 0.2
+
+func newFME7(t *T) Mapper {
+	return newVRC(t, 69, 0)
+}
+
+func writeFME7(m Mapper, command, value byte) {
+	m.Write(0x8000, command)
+	m.Write(0xa000, value)
+}
+
+func writeAY(m Mapper, register, value byte) {
+	m.Write(0xc000, register)
+	m.Write(0xe000, value)
+}
+
+func Test_FME7_Banks(t *T) {
+	m := newFME7(t)
+	for i := byte(0); i < 8; i++ {
+		writeFME7(m, i, 10+i)
+	}
+	writeFME7(m, 0x09, 1)
+	writeFME7(m, 0x0a, 2)
+	writeFME7(m, 0x0b, 3)
+
+	expectPRGEq(t, m, 0x8000, 1)
+	expectPRGEq(t, m, 0xa000, 2)
+	expectPRGEq(t, m, 0xc000, 3)
+	expectPRGEq(t, m, 0xe000, 15)
+	for i := uint16(0); i < 8; i++ {
+		expectCHREq(t, m, i*chr1KBBankSize, byte(10+i))
+	}
+}
+
+func Test_FME7_Bank6000(t *T) {
+	tests := []struct {
+		name  string
+		bank  byte
+		value byte
+	}{
+		{"ROM", 0x05, 0x05},
+		{"DisabledRAM", 0x40, 0x00},
+		{"RAM", 0xc0, 0x42},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			m := newFME7(t)
+			writeFME7(m, 0x08, 0xc0)
+			m.Write(0x6000, 0x42)
+			writeFME7(m, 0x08, test.bank)
+			ExpectEq(t, m.Read(0x6000), test.value,
+				invalidPRGRAMText)
+		})
+	}
+}
+
+func Test_FME7_Mirroring(t *T) {
+	m := newFME7(t)
+
+	writeFME7(m, 0x0c, 0x02)
+
+	expectMirroringEq(t, m, cartridge.SingleScreenA)
+}
+
+func Test_FME7_IRQ(t *T) {
+	m := newFME7(t)
+	writeFME7(m, 0x0e, 0x02)
+	writeFME7(m, 0x0f, 0x00)
+	writeFME7(m, 0x0d, 0x81)
+
+	tick(m, 2)
+	expectIRQEq(t, m, false)
+	tick(m, 1)
+	expectIRQEq(t, m, true)
+
+	writeFME7(m, 0x0d, 0x00)
+	expectIRQEq(t, m, false)
+}
+
+func Test_FME7_WithoutIRQEnabled_OnlyCount(t *T) {
+	m := newFME7(t)
+	writeFME7(m, 0x0d, 0x80)
+
+	tick(m, 1)
+	expectIRQEq(t, m, false)
+}
+
+func Test_Sunsoft5B_Tone(t *T) {
+	m := newFME7(t)
+	writeAY(m, 0x00, 0x02)
+	writeAY(m, 0x07, 0x3e)
+	writeAY(m, 0x08, 0x0f)
+
+	expectAudioEq(t, m, 0)
+	tick(m, 32)
+	expectAudioEq(t, m, ayMixScale)
+	tick(m, 32)
+	expectAudioEq(t, m, 0)
+}
+
+func Test_Sunsoft5B_EnvelopeShapes(t *T) {
+	tests := []struct {
+		name   string
+		shape  byte
+		levels [4]float32
+	}{
+		{"DecayHoldLow", 0x00, [4]float32{1, 0, 0, 0}},
+		{"AttackHoldHigh", 0x0d, [4]float32{0, 1, 1, 1}},
+		{"Sawtooth", 0x0c, [4]float32{0, 0, 0, 0}},
+		{"Triangle", 0x0e, [4]float32{0, 1, 0, 1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			m := newFME7(t)
+			writeAY(m, 0x07, 0x3f)
+			writeAY(m, 0x08, 0x10)
+			writeAY(m, 0x0b, 0x01)
+			writeAY(m, 0x0d, test.shape)
+			for _, level := range test.levels {
+				expectAudioEq(t, m, level*ayMixScale)
+				tick(m, 16*16)
+			}
+		})
+	}
+}
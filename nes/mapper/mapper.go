@@ -0,0 +1,80 @@
+package mapper
+
+import (
+	"fmt"
+
+	"github.com/smarkuck/nes/nes"
+	"github.com/smarkuck/nes/nes/cartridge"
+)
+
+const unsupportedMapperFormat = "unsupported mapper: %d"
+
+type Mapper interface {
+	nes.Bus
+	ReadCHR(addr uint16) byte
+	WriteCHR(addr uint16, value byte)
+	GetMirroring() cartridge.Mirroring
+	Tick()
+}
+
+type PPUObserver interface {
+	ObservePPUAddress(addr uint16)
+}
+
+// PPURegisterObserver sees CPU writes to PPU registers,
+// they are visible on cartridge connector too
+type PPURegisterObserver interface {
+	ObservePPURegister(addr uint16, value byte)
+}
+
+type NametableProvider interface {
+	ReadNametable(addr uint16, ciram []byte) byte
+	WriteNametable(addr uint16, value byte, ciram []byte)
+}
+
+type SaveRAMProvider interface {
+	GetSaveRAM() []byte
+}
+
+type constructor = func(*cartridge.Cartridge) Mapper
+
+var constructors = map[uint16]constructor{
+	0:   newNROM,
+	1:   newMMC1,
+	2:   newUxROM,
+	3:   newCNROM,
+	4:   newMMC3,
+	5:   newMMC5,
+	7:   newAxROM,
+	9:   newMMC2,
+	10:  newMMC4,
+	16:  newBandaiFCG,
+	19:  newNamco163,
+	21:  newVRC24,
+	22:  newVRC24,
+	23:  newVRC24,
+	24:  newVRC6,
+	25:  newVRC24,
+	26:  newVRC6,
+	58:  newGK192,
+	66:  newGxROM,
+	69:  newFME7,
+	79:  newNINA,
+	85:  newVRC7,
+	133: newSachen72008,
+	146: newNINA,
+	159: newBandaiFCG,
+	201: newNovelDiamond,
+}
+
+func New(c *cartridge.Cartridge) (Mapper, error) {
+	if newMapper, ok := constructors[c.Mapper]; ok {
+		return newMapper(c), nil
+	}
+	return nil, fmt.Errorf(unsupportedMapperFormat, c.Mapper)
+}
+
+func newNROM(c *cartridge.Cartridge) Mapper {
+	b := newBase(c, c.PRGRAMSize)
+	return &b
+}
@@ -0,0 +1,94 @@
+package mapper_test
+
+import (
+	"github.com/smarkuck/nes/nes"
+	"github.com/smarkuck/nes/nes/cartridge"
+	. "github.com/smarkuck/nes/nes/mapper"
+	. "github.com/smarkuck/unittest"
+)
+
+const (
+	prgBankSize = 0x4000
+	chrBankSize = 0x1000
+
+	invalidPRGText       = "invalid PRG bank"
+	invalidCHRText       = "invalid CHR bank"
+	invalidPRGRAMText    = "invalid PRG-RAM value"
+	invalidMirroringText = "invalid mirroring"
+	invalidErrorText     = "invalid error message"
+	invalidAudioText     = "invalid audio output"
+)
+
+func newBankedData(count, size int) []byte {
+	data := make([]byte, count*size)
+	for i := range data {
+		data[i] = byte(i / size)
+	}
+	return data
+}
+
+func newMapper(t *T, c *cartridge.Cartridge) Mapper {
+	m, err := New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func expectPRGEq(t *T, m Mapper, addr uint16, bank byte) {
+	ExpectEq(t, m.Read(addr), bank, invalidPRGText)
+}
+
+func expectCHREq(t *T, m Mapper, addr uint16, bank byte) {
+	ExpectEq(t, m.ReadCHR(addr), bank, invalidCHRText)
+}
+
+func expectMirroringEq(t *T,
+	m Mapper, mirroring cartridge.Mirroring) {
+	ExpectEq(t, m.GetMirroring(), mirroring,
+		invalidMirroringText)
+}
+
+func expectAudioEq(t *T, m Mapper, output float32) {
+	ExpectEq(t, m.(nes.AudioSource).GetAudioOutput(), output,
+		invalidAudioText)
+}
+
+func Test_OnUnknownMapper_ReturnError(t *T) {
+	_, err := New(&cartridge.Cartridge{Mapper: 0xfff})
+
+	ExpectEq(t, err.Error(), "unsupported mapper: 4095",
+		invalidErrorText)
+}
+
+func Test_NROM_MirrorsSinglePRGBank(t *T) {
+	m := newMapper(t, &cartridge.Cartridge{
+		PRG: newBankedData(1, prgBankSize),
+		CHR: newBankedData(2, chrBankSize),
+	})
+
+	expectPRGEq(t, m, 0x8000, 0)
+	expectPRGEq(t, m, 0xc000, 0)
+	expectCHREq(t, m, 0x1000, 1)
+}
+
+func Test_CHRROMIsReadOnly(t *T) {
+	m := newMapper(t, &cartridge.Cartridge{
+		PRG: newBankedData(1, prgBankSize),
+		CHR: newBankedData(2, chrBankSize),
+	})
+
+	m.WriteCHR(0x0000, 0xff)
+
+	expectCHREq(t, m, 0x0000, 0)
+}
+
+func Test_WithoutCHRROM_UseCHRRAM(t *T) {
+	m := newMapper(t, &cartridge.Cartridge{
+		PRG: newBankedData(1, prgBankSize),
+	})
+
+	m.WriteCHR(0x1fff, 0xa5)
+
+	expectCHREq(t, m, 0x1fff, 0xa5)
+}
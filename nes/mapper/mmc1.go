@@ -0,0 +1,168 @@
+package mapper
+
+import "github.com/smarkuck/nes/nes/cartridge"
+
+const (
+	mmc1ResetBit  = 0x80
+	mmc1ShiftInit = 0x10
+
+	mmc1Mirroring   = 0x03
+	mmc1PRGMode     = 0x0c
+	mmc1CHR4KBMode  = 0x10
+	mmc1PRGBank     = 0x0f
+	mmc1RAMDisabled = 0x10
+
+	mmc1PRGFixFirst  = 0x08
+	mmc1PRGFixLast   = 0x0c
+	mmc1OuterPRGBank = 0x10
+	mmc1OuterPRGSize = 0x40000
+
+	mmc1SOROMRAMSize = 0x4000
+	mmc1SXROMRAMSize = 0x8000
+)
+
+var mmc1Mirrorings = [...]cartridge.Mirroring{
+	cartridge.SingleScreenA,
+	cartridge.SingleScreenB,
+	cartridge.Vertical,
+	cartridge.Horizontal,
+}
+
+type mmc1 struct {
+	base
+	shift     byte
+	control   byte
+	chrBank0  byte
+	chrBank1  byte
+	prgBank   byte
+	cycle     uint64
+	lastWrite uint64
+	isWritten bool
+}
+
+func newMMC1(c *cartridge.Cartridge) Mapper {
+	m := &mmc1{
+		base:    newBase(c, getPRGRAMSize(c)),
+		shift:   mmc1ShiftInit,
+		control: mmc1PRGFixLast,
+	}
+	m.updateBanks()
+	return m
+}
+
+func (m *mmc1) Read(addr uint16) byte {
+	if addr < prgROMAddr && !m.isPRGRAMEnabled() {
+		return 0
+	}
+	return m.base.Read(addr)
+}
+
+func (m *mmc1) Write(addr uint16, value byte) {
+	if addr < prgROMAddr {
+		m.writePRGRAM(addr, value)
+		return
+	}
+	// writes on consecutive cycles are ignored, so
+	// read-modify-write instructions load only the first value
+	if !m.isConsecutiveWrite() {
+		m.writeRegister(addr, value)
+	}
+	m.lastWrite, m.isWritten = m.cycle, true
+}
+
+func (m *mmc1) writePRGRAM(addr uint16, value byte) {
+	if m.isPRGRAMEnabled() {
+		m.base.Write(addr, value)
+	}
+}
+
+func (m *mmc1) isPRGRAMEnabled() bool {
+	return m.prgBank&mmc1RAMDisabled == 0
+}
+
+func (m *mmc1) isConsecutiveWrite() bool {
+	return m.isWritten && m.cycle-m.lastWrite <= 1
+}
+
+func (m *mmc1) writeRegister(addr uint16, value byte) {
+	if value&mmc1ResetBit != 0 {
+		m.shift = mmc1ShiftInit
+		m.control |= mmc1PRGFixLast
+		m.updateBanks()
+		return
+	}
+	isComplete := m.shift&1 != 0
+	m.shift = m.shift>>1 | (value&1)<<4
+	if isComplete {
+		m.loadRegister(addr, m.shift)
+		m.shift = mmc1ShiftInit
+	}
+}
+
+func (m *mmc1) loadRegister(addr uint16, value byte) {
+	switch (addr >> 13) & 0x03 {
+	case 0:
+		m.control = value
+	case 1:
+		m.chrBank0 = value
+	case 2:
+		m.chrBank1 = value
+	case 3:
+		m.prgBank = value
+	}
+	m.updateBanks()
+}
+
+func (m *mmc1) updateBanks() {
+	m.mirroring = mmc1Mirrorings[m.control&mmc1Mirroring]
+	m.updateCHRBanks()
+	m.updatePRGBanks()
+	m.prgRAM.set(0, prgRAMWindow, m.getPRGRAMBank())
+}
+
+func (m *mmc1) updateCHRBanks() {
+	if m.control&mmc1CHR4KBMode != 0 {
+		m.chr.set(0x0000, 0x1000, int(m.chrBank0))
+		m.chr.set(0x1000, 0x1000, int(m.chrBank1))
+	} else {
+		m.chr.set(0x0000, 0x2000, int(m.chrBank0>>1))
+	}
+}
+
+func (m *mmc1) updatePRGBanks() {
+	outer := m.getOuterPRGBank()
+	bank := outer | int(m.prgBank&mmc1PRGBank)
+	switch m.control & mmc1PRGMode {
+	case mmc1PRGFixFirst:
+		m.prg.set(0x0000, 0x4000, outer)
+		m.prg.set(0x4000, 0x4000, bank)
+	case mmc1PRGFixLast:
+		m.prg.set(0x0000, 0x4000, bank)
+		m.prg.set(0x4000, 0x4000, outer|mmc1PRGBank)
+	default:
+		m.prg.set(0x0000, 0x8000, bank>>1)
+	}
+}
+
+// SUROM and SXROM select 256 KB PRG half with CHR bank bit 4
+func (m *mmc1) getOuterPRGBank() int {
+	if m.prg.getSize() > mmc1OuterPRGSize {
+		return int(m.chrBank0 & mmc1OuterPRGBank)
+	}
+	return 0
+}
+
+// SOROM and SXROM select PRG-RAM bank with CHR bank bits 2-3
+func (m *mmc1) getPRGRAMBank() int {
+	switch m.prgRAM.getSize() {
+	case mmc1SOROMRAMSize:
+		return int(m.chrBank0>>3) & 0x01
+	case mmc1SXROMRAMSize:
+		return int(m.chrBank0>>2) & 0x03
+	}
+	return 0
+}
+
+func (m *mmc1) Tick() {
+	m.cycle++
+}
@@ -0,0 +1,206 @@
+package mapper_test
+
+import (
+	"github.com/smarkuck/nes/nes/cartridge"
+	"github.com/smarkuck/nes/nes/cpu/instruction/cmd"
+	"github.com/smarkuck/nes/nes/cpu/state"
+	. "github.com/smarkuck/nes/nes/mapper"
+	. "github.com/smarkuck/unittest"
+)
+
+const (
+	mmc1Control  = 0x8000
+	mmc1CHRBank0 = 0xa000
+	mmc1CHRBank1 = 0xc000
+	mmc1PRGBank  = 0xe000
+)
+
+func newMMC1(prgBanks int, prgRAMSize int) Mapper {
+	m, _ := New(&cartridge.Cartridge{
+		PRG:        newBankedData(prgBanks, prgBankSize),
+		CHR:        newBankedData(32, chrBankSize),
+		PRGRAMSize: prgRAMSize,
+		Mapper:     1,
+	})
+	return m
+}
+
+func writeMMC1(m Mapper, addr uint16, value byte) {
+	for i := 0; i < 5; i++ {
+		m.Write(addr, value>>i&1)
+		m.Tick()
+		m.Tick()
+	}
+}
+
+func Test_MMC1_OnPowerUp_FixLastBankAtC000(t *T) {
+	m := newMMC1(8, 0)
+
+	expectPRGEq(t, m, 0x8000, 0)
+	expectPRGEq(t, m, 0xc000, 7)
+}
+
+func Test_MMC1_PRGModes(t *T) {
+	tests := []struct {
+		name    string
+		control byte
+		bank    byte
+		low     byte
+		high    byte
+	}{
+		{"32KB", 0b00000, 0x05, 4, 5},
+		{"32KB_IgnoreLowBit", 0b00100, 0x04, 4, 5},
+		{"FixFirst", 0b01000, 0x05, 0, 5},
+		{"FixLast", 0b01100, 0x05, 5, 7},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			m := newMMC1(8, 0)
+			writeMMC1(m, mmc1Control, test.control)
+			writeMMC1(m, mmc1PRGBank, test.bank)
+			expectPRGEq(t, m, 0x8000, test.low)
+			expectPRGEq(t, m, 0xc000, test.high)
+		})
+	}
+}
+
+func Test_MMC1_CHRModes(t *T) {
+	tests := []struct {
+		name    string
+		control byte
+		low     byte
+		high    byte
+	}{
+		{"8KB_IgnoreLowBit", 0b00000, 4, 5},
+		{"4KB", 0b10000, 5, 9},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			m := newMMC1(8, 0)
+			writeMMC1(m, mmc1Control, test.control)
+			writeMMC1(m, mmc1CHRBank0, 5)
+			writeMMC1(m, mmc1CHRBank1, 9)
+			expectCHREq(t, m, 0x0000, test.low)
+			expectCHREq(t, m, 0x1000, test.high)
+		})
+	}
+}
+
+func Test_MMC1_Mirroring(t *T) {
+	tests := []struct {
+		control   byte
+		mirroring cartridge.Mirroring
+	}{
+		{0b00, cartridge.SingleScreenA},
+		{0b01, cartridge.SingleScreenB},
+		{0b10, cartridge.Vertical},
+		{0b11, cartridge.Horizontal},
+	}
+
+	for _, test := range tests {
+		m := newMMC1(2, 0)
+		writeMMC1(m, mmc1Control, test.control)
+		expectMirroringEq(t, m, test.mirroring)
+	}
+}
+
+func Test_MMC1_PRGRAMEnable(t *T) {
+	m := newMMC1(2, 0)
+
+	m.Write(0x6000, 0x5a)
+	ExpectEq(t, m.Read(0x6000), 0x5a, invalidPRGRAMText)
+
+	writeMMC1(m, mmc1PRGBank, 0x10)
+	m.Write(0x6000, 0x77)
+	ExpectEq(t, m.Read(0x6000), 0x00, invalidPRGRAMText)
+
+	writeMMC1(m, mmc1PRGBank, 0x00)
+	ExpectEq(t, m.Read(0x6000), 0x5a, invalidPRGRAMText)
+}
+
+func Test_MMC1_OnResetBit_FixLastBank(t *T) {
+	m := newMMC1(8, 0)
+	writeMMC1(m, mmc1Control, 0b00000)
+
+	m.Write(mmc1Control, 0x80)
+
+	expectPRGEq(t, m, 0xc000, 7)
+}
+
+func Test_MMC1_SUROM_SelectOuterPRGBank(t *T) {
+	m := newMMC1(32, 0)
+	writeMMC1(m, mmc1CHRBank0, 0x10)
+	writeMMC1(m, mmc1PRGBank, 0x02)
+
+	expectPRGEq(t, m, 0x8000, 18)
+	expectPRGEq(t, m, 0xc000, 31)
+
+	writeMMC1(m, mmc1CHRBank0, 0x00)
+
+	expectPRGEq(t, m, 0x8000, 2)
+	expectPRGEq(t, m, 0xc000, 15)
+}
+
+func Test_MMC1_SXROM_SelectPRGRAMBank(t *T) {
+	m := newMMC1(2, 0x8000)
+	for bank := byte(0); bank < 4; bank++ {
+		writeMMC1(m, mmc1CHRBank0, bank<<2)
+		m.Write(0x6000, bank+1)
+	}
+
+	writeMMC1(m, mmc1CHRBank0, 2<<2)
+
+	ExpectEq(t, m.Read(0x6000), 3, invalidPRGRAMText)
+}
+
+func Test_MMC1_SOROM_SelectPRGRAMBank(t *T) {
+	m := newMMC1(2, 0x4000)
+	m.Write(0x6000, 0x11)
+	writeMMC1(m, mmc1CHRBank0, 1<<3)
+	m.Write(0x6000, 0x22)
+
+	writeMMC1(m, mmc1CHRBank0, 0)
+
+	ExpectEq(t, m.Read(0x6000), 0x11, invalidPRGRAMText)
+}
+
+func Test_MMC1_IgnoreWriteOnConsecutiveCycle(t *T) {
+	m := newMMC1(8, 0)
+	writeMMC1(m, mmc1Control, 0b00000)
+	for _, bit := range []byte{0, 1, 0, 0} {
+		m.Write(mmc1PRGBank, bit)
+		m.Tick()
+		m.Tick()
+	}
+
+	m.Write(mmc1PRGBank, 0)
+	m.Write(mmc1PRGBank, 1)
+	m.Tick()
+	m.Tick()
+
+	expectPRGEq(t, m, 0x8000, 2)
+	writeMMC1(m, mmc1PRGBank, 0x04)
+	expectPRGEq(t, m, 0x8000, 4)
+}
+
+func Test_MMC1_ReadModifyWriteOnlyResetsShiftRegister(t *T) {
+	prg := newBankedData(8, prgBankSize)
+	for i := prgBankSize - 1; i < len(prg); i += prgBankSize {
+		prg[i] = 0xff
+	}
+	m := newMapper(t, &cartridge.Cartridge{PRG: prg, Mapper: 1})
+	writeMMC1(m, mmc1Control, 0b00000)
+	m.Write(mmc1PRGBank, 1)
+	m.Tick()
+	m.Tick()
+
+	cmd.INC(&state.State{Bus: m}, 0xffff)
+	m.Tick()
+	m.Tick()
+	writeMMC1(m, mmc1PRGBank, 0x02)
+
+	expectPRGEq(t, m, 0x8000, 2)
+	expectPRGEq(t, m, 0xc000, 7)
+}
@@ -0,0 +1,110 @@
+package mapper
+
+import "github.com/smarkuck/nes/nes/cartridge"
+
+const (
+	mmc2PRGBank    = 0x0f
+	mmc2CHRBank    = 0x1f
+	mmc2Horizontal = 0x01
+
+	latchFD = 0
+	latchFE = 1
+
+	latchTileFD   = 0x0fd8
+	latchTileFE   = 0x0fe8
+	latchTileMask = 0x0ff8
+	latchTileRow  = 0x0007
+)
+
+type mmc2 struct {
+	base
+	chrBanks [2][2]byte
+	latches  [2]int
+	isMMC4   bool
+}
+
+func newMMC2(c *cartridge.Cartridge) Mapper {
+	m := &mmc2{
+		base:    newBase(c, c.PRGRAMSize),
+		latches: [2]int{latchFE, latchFE},
+	}
+	m.prg.set(0x2000, 0x2000, -3)
+	m.prg.set(0x4000, 0x2000, -2)
+	m.prg.set(0x6000, 0x2000, -1)
+	return m
+}
+
+func newMMC4(c *cartridge.Cartridge) Mapper {
+	m := &mmc2{
+		base:    newBase(c, getPRGRAMSize(c)),
+		latches: [2]int{latchFE, latchFE},
+		isMMC4:  true,
+	}
+	m.prg.set(0x4000, 0x4000, -1)
+	return m
+}
+
+func (m *mmc2) Write(addr uint16, value byte) {
+	if addr < prgROMAddr {
+		m.base.Write(addr, value)
+		return
+	}
+	switch addr & 0xf000 {
+	case 0xa000:
+		m.setPRGBank(int(value & mmc2PRGBank))
+	case 0xb000, 0xc000, 0xd000, 0xe000:
+		m.setCHRBank(addr, value&mmc2CHRBank)
+	case 0xf000:
+		m.setMirroring(value)
+	}
+}
+
+func (m *mmc2) setPRGBank(bank int) {
+	if m.isMMC4 {
+		m.prg.set(0x0000, 0x4000, bank)
+	} else {
+		m.prg.set(0x0000, 0x2000, bank)
+	}
+}
+
+func (m *mmc2) setCHRBank(addr uint16, bank byte) {
+	register := (addr - 0xb000) >> 12
+	m.chrBanks[register>>1][register&0x01] = bank
+	m.updateCHRBanks()
+}
+
+func (m *mmc2) setMirroring(value byte) {
+	if value&mmc2Horizontal != 0 {
+		m.mirroring = cartridge.Horizontal
+	} else {
+		m.mirroring = cartridge.Vertical
+	}
+}
+
+func (m *mmc2) updateCHRBanks() {
+	for half, banks := range m.chrBanks {
+		bank := banks[m.latches[half]]
+		m.chr.set(half*0x1000, 0x1000, int(bank))
+	}
+}
+
+// fetching tile $FD or $FE switches the bank for next fetches,
+// MMC2 watches only first row of the tile in the left table
+func (m *mmc2) ObservePPUAddress(addr uint16) {
+	if addr >= chrSize {
+		return
+	}
+	half := addr >> 12
+	if half == 0 && !m.isMMC4 && addr&latchTileRow != 0 {
+		return
+	}
+	switch addr & latchTileMask {
+	case latchTileFD:
+		m.latches[half] = latchFD
+	case latchTileFE:
+		m.latches[half] = latchFE
+	default:
+		return
+	}
+	m.updateCHRBanks()
+}
@@ -0,0 +1,116 @@
+package mapper_test
+
+import (
+	"github.com/smarkuck/nes/nes/cartridge"
+	. "github.com/smarkuck/nes/nes/mapper"
+	. "github.com/smarkuck/unittest"
+)
+
+const (
+	mmc2PRGBank     = 0xa000
+	mmc2CHR0FD      = 0xb000
+	mmc2CHR0FE      = 0xc000
+	mmc2CHR1FD      = 0xd000
+	mmc2CHR1FE      = 0xe000
+	mmc2Mirroring   = 0xf000
+	chr4KBBankSize  = 0x1000
+	invalidHookText = "mapper does not observe PPU"
+)
+
+func newMMC2(mapper uint16) Mapper {
+	m, _ := New(&cartridge.Cartridge{
+		PRG:    newBankedData(16, prg8KBBankSize),
+		CHR:    newBankedData(32, chr4KBBankSize),
+		Mapper: mapper,
+	})
+	m.Write(mmc2CHR0FD, 1)
+	m.Write(mmc2CHR0FE, 2)
+	m.Write(mmc2CHR1FD, 3)
+	m.Write(mmc2CHR1FE, 4)
+	return m
+}
+
+func observe(t *T, m Mapper, addr uint16) {
+	o, ok := m.(PPUObserver)
+	ExpectTrue(t, ok, invalidHookText)
+	o.ObservePPUAddress(addr)
+}
+
+func Test_MMC2_PRGBanks(t *T) {
+	m := newMMC2(9)
+
+	m.Write(mmc2PRGBank, 5)
+
+	expectPRGEq(t, m, 0x8000, 5)
+	expectPRGEq(t, m, 0xa000, 13)
+	expectPRGEq(t, m, 0xc000, 14)
+	expectPRGEq(t, m, 0xe000, 15)
+}
+
+func Test_MMC4_PRGBanks(t *T) {
+	m := newMMC2(10)
+
+	m.Write(mmc2PRGBank, 5)
+
+	expectPRGEq(t, m, 0x8000, 10)
+	expectPRGEq(t, m, 0xa000, 11)
+	expectPRGEq(t, m, 0xc000, 14)
+	expectPRGEq(t, m, 0xe000, 15)
+}
+
+func Test_MMC2_OnPowerUp_UseFELatches(t *T) {
+	m := newMMC2(9)
+
+	expectCHREq(t, m, 0x0000, 2)
+	expectCHREq(t, m, 0x1000, 4)
+}
+
+func Test_MMC2_Mirroring(t *T) {
+	m := newMMC2(9)
+
+	m.Write(mmc2Mirroring, 1)
+	expectMirroringEq(t, m, cartridge.Horizontal)
+
+	m.Write(mmc2Mirroring, 0)
+	expectMirroringEq(t, m, cartridge.Vertical)
+}
+
+func Test_MMC2_MMC4_LatchSwitching(t *T) {
+	tests := []struct {
+		name   string
+		mapper uint16
+		addr   uint16
+		left   byte
+		right  byte
+	}{
+		{"MMC2_LeftFD", 9, 0x0fd8, 1, 4},
+		{"MMC2_LeftFE", 9, 0x0fe8, 2, 4},
+		{"MMC2_LeftIgnoreOtherRows", 9, 0x0fd9, 2, 4},
+		{"MMC2_RightFD", 9, 0x1fd8, 2, 3},
+		{"MMC2_RightFDAnyRow", 9, 0x1fdf, 2, 3},
+		{"MMC2_IgnoreOtherTiles", 9, 0x1fc8, 2, 4},
+		{"MMC4_LeftFDAnyRow", 10, 0x0fdf, 1, 4},
+		{"MMC4_RightFD", 10, 0x1fda, 2, 3},
+		{"IgnoreNametables", 10, 0x2fd8, 2, 4},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			m := newMMC2(test.mapper)
+			observe(t, m, test.addr)
+			expectCHREq(t, m, 0x0000, test.left)
+			expectCHREq(t, m, 0x1000, test.right)
+		})
+	}
+}
+
+func Test_MMC2_LatchStaysUntilOtherTileFetched(t *T) {
+	m := newMMC2(9)
+
+	observe(t, m, 0x1fd8)
+	observe(t, m, 0x1000)
+	expectCHREq(t, m, 0x1000, 3)
+
+	observe(t, m, 0x1fe8)
+	expectCHREq(t, m, 0x1000, 4)
+}
@@ -0,0 +1,196 @@
+package mapper
+
+import "github.com/smarkuck/nes/nes/cartridge"
+
+const (
+	mmc3BankRegister = 0x07
+	mmc3PRGInversion = 0x40
+	mmc3CHRInversion = 0x80
+	mmc3Horizontal   = 0x01
+	mmc3RAMEnabled   = 0x80
+	mmc3RAMProtected = 0x40
+
+	mmc3A12         = 0x1000
+	mmc3A12Filter   = 3
+	mmc3RevisionA   = 4
+	mmc3CHRInverted = 0x1000
+	mmc3PRGInverted = 0x4000
+)
+
+type mmc3 struct {
+	base
+	registers     [8]byte
+	bankSelect    byte
+	ramProtect    byte
+	isFourScreen  bool
+	irqLatch      byte
+	irqCounter    byte
+	isIRQReload   bool
+	isIRQEnabled  bool
+	isIRQ         bool
+	isRevisionA   bool
+	cycle         uint64
+	lastA12High   uint64
+	isA12HighSeen bool
+}
+
+func newMMC3(c *cartridge.Cartridge) Mapper {
+	m := &mmc3{
+		base:         newBase(c, getPRGRAMSize(c)),
+		ramProtect:   mmc3RAMEnabled,
+		isFourScreen: c.Mirroring == cartridge.FourScreen,
+		isRevisionA:  c.SubMapper == mmc3RevisionA,
+	}
+	m.updateBanks()
+	return m
+}
+
+func (m *mmc3) Read(addr uint16) byte {
+	if addr < prgROMAddr && m.ramProtect&mmc3RAMEnabled == 0 {
+		return 0
+	}
+	return m.base.Read(addr)
+}
+
+func (m *mmc3) Write(addr uint16, value byte) {
+	if addr < prgROMAddr {
+		m.writePRGRAM(addr, value)
+		return
+	}
+	isOdd := addr&0x01 != 0
+	switch addr & 0xe000 {
+	case 0x8000:
+		m.writeBankRegister(isOdd, value)
+	case 0xa000:
+		m.writeMemoryControl(isOdd, value)
+	case 0xc000:
+		m.writeIRQCounter(isOdd, value)
+	case 0xe000:
+		m.writeIRQEnable(isOdd)
+	}
+}
+
+func (m *mmc3) writePRGRAM(addr uint16, value byte) {
+	mask := byte(mmc3RAMEnabled | mmc3RAMProtected)
+	if m.ramProtect&mask == mmc3RAMEnabled {
+		m.base.Write(addr, value)
+	}
+}
+
+func (m *mmc3) writeBankRegister(isData bool, value byte) {
+	if isData {
+		m.registers[m.bankSelect&mmc3BankRegister] = value
+	} else {
+		m.bankSelect = value
+	}
+	m.updateBanks()
+}
+
+func (m *mmc3) writeMemoryControl(isProtect bool, value byte) {
+	switch {
+	case isProtect:
+		m.ramProtect = value
+	case m.isFourScreen:
+	case value&mmc3Horizontal != 0:
+		m.mirroring = cartridge.Horizontal
+	default:
+		m.mirroring = cartridge.Vertical
+	}
+}
+
+func (m *mmc3) writeIRQCounter(isReload bool, value byte) {
+	if isReload {
+		m.irqCounter = 0
+		m.isIRQReload = true
+	} else {
+		m.irqLatch = value
+	}
+}
+
+func (m *mmc3) writeIRQEnable(isEnable bool) {
+	m.isIRQEnabled = isEnable
+	if !isEnable {
+		m.isIRQ = false
+	}
+}
+
+func (m *mmc3) updateBanks() {
+	m.updatePRGBanks()
+	m.updateCHRBanks()
+}
+
+func (m *mmc3) updatePRGBanks() {
+	var swap int
+	if m.bankSelect&mmc3PRGInversion != 0 {
+		swap = mmc3PRGInverted
+	}
+	m.prg.set(0x0000^swap, 0x2000, int(m.registers[6]))
+	m.prg.set(0x2000, 0x2000, int(m.registers[7]))
+	m.prg.set(0x4000^swap, 0x2000, -2)
+	m.prg.set(0x6000, 0x2000, -1)
+}
+
+func (m *mmc3) updateCHRBanks() {
+	var swap int
+	if m.bankSelect&mmc3CHRInversion != 0 {
+		swap = mmc3CHRInverted
+	}
+	m.chr.set(0x0000^swap, 0x0800, int(m.registers[0]>>1))
+	m.chr.set(0x0800^swap, 0x0800, int(m.registers[1]>>1))
+	for i := 0; i < 4; i++ {
+		addr := (0x1000 + i*0x0400) ^ swap
+		m.chr.set(addr, 0x0400, int(m.registers[2+i]))
+	}
+}
+
+func (m *mmc3) ReadCHR(addr uint16) byte {
+	m.watchA12(addr)
+	return m.base.ReadCHR(addr)
+}
+
+func (m *mmc3) WriteCHR(addr uint16, value byte) {
+	m.watchA12(addr)
+	m.base.WriteCHR(addr, value)
+}
+
+// A12 rises many times per scanline, the counter is clocked
+// only after A12 stayed low for a few CPU cycles
+func (m *mmc3) watchA12(addr uint16) {
+	if addr&mmc3A12 == 0 {
+		return
+	}
+	if !m.isA12HighSeen || m.cycle-m.lastA12High >= mmc3A12Filter {
+		m.clockIRQCounter()
+	}
+	m.lastA12High, m.isA12HighSeen = m.cycle, true
+}
+
+func (m *mmc3) clockIRQCounter() {
+	previous := m.irqCounter
+	if m.irqCounter == 0 || m.isIRQReload {
+		m.irqCounter = m.irqLatch
+	} else {
+		m.irqCounter--
+	}
+	if m.isIRQTriggered(previous) {
+		m.isIRQ = true
+	}
+	m.isIRQReload = false
+}
+
+// MMC3A fires only when the counter becomes zero by decrement
+// or reload, MMC3B and MMC3C fire on every clock with zero
+func (m *mmc3) isIRQTriggered(previous byte) bool {
+	if m.irqCounter != 0 || !m.isIRQEnabled {
+		return false
+	}
+	return !m.isRevisionA || previous != 0 || m.isIRQReload
+}
+
+func (m *mmc3) IsIRQ() bool {
+	return m.isIRQ
+}
+
+func (m *mmc3) Tick() {
+	m.cycle++
+}
@@ -0,0 +1,217 @@
+package mapper_test
+
+import (
+	"github.com/smarkuck/nes/nes"
+	"github.com/smarkuck/nes/nes/cartridge"
+	. "github.com/smarkuck/nes/nes/mapper"
+	. "github.com/smarkuck/unittest"
+)
+
+const (
+	prg8KBBankSize = 0x2000
+	chr1KBBankSize = 0x0400
+
+	mmc3BankSelect = 0x8000
+	mmc3BankData   = 0x8001
+	mmc3Mirroring  = 0xa000
+	mmc3RAMProtect = 0xa001
+	mmc3IRQLatch   = 0xc000
+	mmc3IRQReload  = 0xc001
+	mmc3IRQDisable = 0xe000
+	mmc3IRQEnable  = 0xe001
+
+	mmc3RevisionA = 4
+
+	invalidIRQText = "invalid IRQ state"
+)
+
+func newMMC3(subMapper uint8,
+	mirroring cartridge.Mirroring) Mapper {
+	m, _ := New(&cartridge.Cartridge{
+		PRG:       newBankedData(16, prg8KBBankSize),
+		CHR:       newBankedData(64, chr1KBBankSize),
+		Mapper:    4,
+		SubMapper: subMapper,
+		Mirroring: mirroring,
+	})
+	return m
+}
+
+func writeMMC3Banks(m Mapper, mode byte, banks [8]byte) {
+	for i, bank := range banks {
+		m.Write(mmc3BankSelect, mode|byte(i))
+		m.Write(mmc3BankData, bank)
+	}
+}
+
+func clockA12(m Mapper) {
+	m.ReadCHR(0x0000)
+	m.Tick()
+	m.Tick()
+	m.Tick()
+	m.ReadCHR(0x1000)
+}
+
+func expectIRQEq(t *T, m Mapper, value bool) {
+	ExpectEq(t, m.(nes.IRQSource).IsIRQ(), value,
+		invalidIRQText)
+}
+
+func Test_MMC3_PRGModes(t *T) {
+	tests := []struct {
+		name  string
+		mode  byte
+		banks [4]byte
+	}{
+		{"SwitchableAt8000", 0x00, [4]byte{3, 5, 14, 15}},
+		{"SwitchableAtC000", 0x40, [4]byte{14, 5, 3, 15}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			m := newMMC3(0, cartridge.Vertical)
+			writeMMC3Banks(m, test.mode,
+				[8]byte{0, 0, 0, 0, 0, 0, 3, 5})
+			for i, bank := range test.banks {
+				addr := 0x8000 + uint16(i)*prg8KBBankSize
+				expectPRGEq(t, m, addr, bank)
+			}
+		})
+	}
+}
+
+func Test_MMC3_CHRModes(t *T) {
+	tests := []struct {
+		name  string
+		mode  byte
+		banks [8]byte
+	}{
+		{"2KBBanksAt0000", 0x00,
+			[8]byte{8, 9, 12, 13, 20, 21, 22, 23}},
+		{"2KBBanksAt1000", 0x80,
+			[8]byte{20, 21, 22, 23, 8, 9, 12, 13}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			m := newMMC3(0, cartridge.Vertical)
+			writeMMC3Banks(m, test.mode,
+				[8]byte{9, 12, 20, 21, 22, 23, 0, 0})
+			for i, bank := range test.banks {
+				addr := uint16(i) * chr1KBBankSize
+				expectCHREq(t, m, addr, bank)
+			}
+		})
+	}
+}
+
+func Test_MMC3_Mirroring(t *T) {
+	m := newMMC3(0, cartridge.Vertical)
+
+	m.Write(mmc3Mirroring, 1)
+	expectMirroringEq(t, m, cartridge.Horizontal)
+
+	m.Write(mmc3Mirroring, 0)
+	expectMirroringEq(t, m, cartridge.Vertical)
+}
+
+func Test_MMC3_FourScreen_IgnoreMirroring(t *T) {
+	m := newMMC3(0, cartridge.FourScreen)
+
+	m.Write(mmc3Mirroring, 1)
+
+	expectMirroringEq(t, m, cartridge.FourScreen)
+}
+
+func Test_MMC3_PRGRAMProtect(t *T) {
+	tests := []struct {
+		name    string
+		protect byte
+		read    byte
+	}{
+		{"Enabled", 0x80, 0x42},
+		{"WriteProtected", 0xc0, 0x11},
+		{"Disabled", 0x00, 0x00},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			m := newMMC3(0, cartridge.Vertical)
+			m.Write(0x6000, 0x11)
+			m.Write(mmc3RAMProtect, test.protect)
+			m.Write(0x6000, 0x42)
+			ExpectEq(t, m.Read(0x6000), test.read,
+				invalidPRGRAMText)
+		})
+	}
+}
+
+func Test_MMC3_IRQAfterLatchPlusOneClocks(t *T) {
+	m := newMMC3(0, cartridge.Vertical)
+	m.Write(mmc3IRQLatch, 3)
+	m.Write(mmc3IRQReload, 0)
+	m.Write(mmc3IRQEnable, 0)
+
+	for i := 0; i < 3; i++ {
+		clockA12(m)
+		expectIRQEq(t, m, false)
+	}
+	clockA12(m)
+
+	expectIRQEq(t, m, true)
+}
+
+func Test_MMC3_FilterQuickA12Rises(t *T) {
+	m := newMMC3(0, cartridge.Vertical)
+	m.Write(mmc3IRQLatch, 1)
+	m.Write(mmc3IRQEnable, 0)
+	clockA12(m)
+
+	m.ReadCHR(0x0000)
+	m.Tick()
+	m.ReadCHR(0x1000)
+	m.Tick()
+	m.ReadCHR(0x1fff)
+
+	expectIRQEq(t, m, false)
+}
+
+func Test_MMC3_WhenIRQDisabled_AcknowledgeIRQ(t *T) {
+	m := newMMC3(0, cartridge.Vertical)
+	m.Write(mmc3IRQEnable, 0)
+	clockA12(m)
+	expectIRQEq(t, m, true)
+
+	m.Write(mmc3IRQDisable, 0)
+	expectIRQEq(t, m, false)
+
+	clockA12(m)
+	expectIRQEq(t, m, false)
+}
+
+func Test_MMC3_ZeroLatchRevisions(t *T) {
+	tests := []struct {
+		name      string
+		subMapper uint8
+		irqs      [3]bool
+	}{
+		{"MMC3C_IRQOnEveryClock", 0, [3]bool{true, true, true}},
+		{"MMC3A_IRQOnlyAfterReload", mmc3RevisionA,
+			[3]bool{true, false, false}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			m := newMMC3(test.subMapper, cartridge.Vertical)
+			m.Write(mmc3IRQLatch, 0)
+			m.Write(mmc3IRQReload, 0)
+			m.Write(mmc3IRQEnable, 0)
+			for _, irq := range test.irqs {
+				clockA12(m)
+				expectIRQEq(t, m, irq)
+				m.Write(mmc3IRQDisable, 0)
+				m.Write(mmc3IRQEnable, 0)
+			}
+		})
+	}
+}
@@ -0,0 +1,317 @@
+package mapper
+
+import "github.com/smarkuck/nes/nes/cartridge"
+
+const (
+	mmc5PRGRAMSize = 0x10000
+	mmc5ExRAMSize  = 0x0400
+	mmc5ExRAMAddr  = 0x5c00
+
+	mmc5ROMSelect = 0x80
+	mmc5RAMBank   = 0x07
+	mmc5Mode      = 0x03
+	mmc5CHRUpper  = 0x03
+
+	mmc5RAMUnlock1 = 0x02
+	mmc5RAMUnlock2 = 0x01
+
+	mmc5ExRAMNametable  = 0
+	mmc5ExRAMAttributes = 1
+	mmc5ExRAMReadWrite  = 2
+	mmc5ExRAMReadOnly   = 3
+
+	mmc5LargeSprites = 0x20
+	mmc5Rendering    = 0x18
+
+	mmc5PPUCtrl = 0x2000
+	mmc5PPUMask = 0x2001
+
+	mmc5IRQPending = 0x80
+	mmc5InFrame    = 0x40
+)
+
+type mmc5 struct {
+	base
+	prgMode    byte
+	prgRegs    [5]byte
+	prgWindows banks
+	isPRGRAM   [4]bool
+	ramProtect [2]byte
+
+	chrMode  byte
+	chrRegs  [12]byte
+	chrUpper byte
+	chrB     banks
+	isLastB  bool
+
+	exRAMMode  byte
+	exRAM      [mmc5ExRAMSize]byte
+	ntMapping  byte
+	fillTile   byte
+	fillAttr   byte
+	splitCtrl  byte
+	splitY     byte
+	splitBank  byte
+	multiplier [2]byte
+
+	scanline     scanlineDetector
+	irqCompare   byte
+	isIRQEnabled bool
+	isIRQPending bool
+
+	isLargeSprites bool
+	isRendering    bool
+	tileOffset     uint16
+
+	audio mmc5Audio
+}
+
+func newMMC5(c *cartridge.Cartridge) Mapper {
+	m := &mmc5{
+		base:    newBase(c, getMMC5PRGRAMSize(c)),
+		prgMode: mmc5Mode,
+		chrMode: mmc5Mode,
+		audio:   newMMC5Audio(),
+	}
+	m.prgRegs[4] = 0xff
+	m.prgWindows = newBanks(m.prgRAM.data,
+		prgROMSize, prgROMWindow)
+	m.chrB = newBanks(m.chr.data, chrSize, chrWindow)
+	m.mirroring = cartridge.MapperControlled
+	m.updatePRGBanks()
+	return m
+}
+
+func getMMC5PRGRAMSize(c *cartridge.Cartridge) int {
+	if c.PRGRAMSize == 0 {
+		return mmc5PRGRAMSize
+	}
+	return c.PRGRAMSize
+}
+
+func (m *mmc5) Read(addr uint16) byte {
+	switch {
+	case addr >= prgROMAddr:
+		return m.readPRG(addr)
+	case addr >= prgRAMAddr:
+		return m.prgRAM.read(int(addr - prgRAMAddr))
+	case addr >= mmc5ExRAMAddr:
+		return m.readExRAM(addr)
+	}
+	return m.readRegister(addr)
+}
+
+func (m *mmc5) readPRG(addr uint16) byte {
+	offset := int(addr - prgROMAddr)
+	var value byte
+	if m.isPRGRAM[offset/prgROMWindow] {
+		value = m.prgWindows.read(offset)
+	} else {
+		value = m.prg.read(offset)
+	}
+	m.audio.observePRGRead(addr, value)
+	return value
+}
+
+func (m *mmc5) readExRAM(addr uint16) byte {
+	if m.exRAMMode < mmc5ExRAMReadWrite {
+		return 0
+	}
+	return m.exRAM[addr-mmc5ExRAMAddr]
+}
+
+func (m *mmc5) readRegister(addr uint16) byte {
+	switch addr {
+	case 0x5010, 0x5015:
+		return m.audio.read(addr)
+	case 0x5204:
+		return m.readIRQStatus()
+	case 0x5205:
+		return byte(m.getProduct())
+	case 0x5206:
+		return byte(m.getProduct() >> 8)
+	}
+	return 0
+}
+
+func (m *mmc5) readIRQStatus() byte {
+	var status byte
+	if m.isIRQPending {
+		status |= mmc5IRQPending
+	}
+	if m.scanline.isInFrame {
+		status |= mmc5InFrame
+	}
+	m.isIRQPending = false
+	return status
+}
+
+func (m *mmc5) getProduct() uint16 {
+	return uint16(m.multiplier[0]) * uint16(m.multiplier[1])
+}
+
+func (m *mmc5) Write(addr uint16, value byte) {
+	switch {
+	case addr >= prgRAMAddr:
+		m.writePRG(addr, value)
+	case addr >= mmc5ExRAMAddr:
+		m.writeExRAM(addr, value)
+	case addr >= 0x5100:
+		m.writeRegister(addr, value)
+	case addr >= 0x5000:
+		m.audio.write(addr, value)
+	}
+}
+
+func (m *mmc5) writePRG(addr uint16, value byte) {
+	if m.ramProtect != [2]byte{mmc5RAMUnlock1, mmc5RAMUnlock2} {
+		return
+	}
+	if addr < prgROMAddr {
+		m.prgRAM.write(int(addr-prgRAMAddr), value)
+		return
+	}
+	offset := int(addr - prgROMAddr)
+	if m.isPRGRAM[offset/prgROMWindow] {
+		m.prgWindows.write(offset, value)
+	}
+}
+
+func (m *mmc5) writeExRAM(addr uint16, value byte) {
+	switch m.exRAMMode {
+	case mmc5ExRAMReadOnly:
+		return
+	case mmc5ExRAMNametable, mmc5ExRAMAttributes:
+		// outside of rendering PPU does not drive ExRAM data
+		if !m.scanline.isInFrame {
+			value = 0
+		}
+	}
+	m.exRAM[addr-mmc5ExRAMAddr] = value
+}
+
+func (m *mmc5) writeRegister(addr uint16, value byte) {
+	switch {
+	case addr == 0x5100:
+		m.prgMode = value & mmc5Mode
+		m.updatePRGBanks()
+	case addr == 0x5101:
+		m.chrMode = value & mmc5Mode
+		m.updateCHRBanks()
+	case addr == 0x5102 || addr == 0x5103:
+		m.ramProtect[addr-0x5102] = value & 0x03
+	case addr == 0x5104:
+		m.exRAMMode = value & mmc5Mode
+	case addr == 0x5105:
+		m.ntMapping = value
+	case addr == 0x5106:
+		m.fillTile = value
+	case addr == 0x5107:
+		m.fillAttr = value & 0x03
+	case addr >= 0x5113 && addr <= 0x5117:
+		m.prgRegs[addr-0x5113] = value
+		m.updatePRGBanks()
+	case addr >= 0x5120 && addr <= 0x512b:
+		m.writeCHRRegister(addr, value)
+	case addr == 0x5130:
+		m.chrUpper = value & mmc5CHRUpper
+		m.updateCHRBanks()
+	default:
+		m.writeScanlineRegister(addr, value)
+	}
+}
+
+func (m *mmc5) writeScanlineRegister(addr uint16, value byte) {
+	switch addr {
+	case 0x5200:
+		m.splitCtrl = value
+	case 0x5201:
+		m.splitY = value
+	case 0x5202:
+		m.splitBank = value
+	case 0x5203:
+		m.irqCompare = value
+	case 0x5204:
+		m.isIRQEnabled = value&0x80 != 0
+	case 0x5205, 0x5206:
+		m.multiplier[addr-0x5205] = value
+	}
+}
+
+func (m *mmc5) writeCHRRegister(addr uint16, value byte) {
+	index := addr - 0x5120
+	m.chrRegs[index] = value
+	m.isLastB = index >= 8
+	m.updateCHRBanks()
+}
+
+func (m *mmc5) updatePRGBanks() {
+	m.prgRAM.set(0, prgRAMWindow,
+		int(m.prgRegs[0]&mmc5RAMBank))
+	rom := m.prgRegs[4] | mmc5ROMSelect
+	switch m.prgMode {
+	case 0:
+		m.setPRGWindow(0x0000, 0x8000, rom)
+	case 1:
+		m.setPRGWindow(0x0000, 0x4000, m.prgRegs[2])
+		m.setPRGWindow(0x4000, 0x4000, rom)
+	case 2:
+		m.setPRGWindow(0x0000, 0x4000, m.prgRegs[2])
+		m.setPRGWindow(0x4000, 0x2000, m.prgRegs[3])
+		m.setPRGWindow(0x6000, 0x2000, rom)
+	case 3:
+		for i := 0; i < 3; i++ {
+			m.setPRGWindow(i*0x2000, 0x2000, m.prgRegs[i+1])
+		}
+		m.setPRGWindow(0x6000, 0x2000, rom)
+	}
+}
+
+// registers always hold 8 KB bank numbers,
+// bigger windows ignore their lowest bits
+func (m *mmc5) setPRGWindow(addr, size int, reg byte) {
+	isRAM := reg&mmc5ROMSelect == 0
+	for i := 0; i < size/prgROMWindow; i++ {
+		m.isPRGRAM[addr/prgROMWindow+i] = isRAM
+	}
+	windows := size / prgROMWindow
+	if isRAM {
+		bank := int(reg&mmc5RAMBank) / windows
+		m.prgWindows.set(addr, size, bank)
+	} else {
+		bank := int(reg&^mmc5ROMSelect) / windows
+		m.prg.set(addr, size, bank)
+	}
+}
+
+func (m *mmc5) updateCHRBanks() {
+	size := chrSize >> m.chrMode
+	upper := int(m.chrUpper) << 8
+	for i := 0; i < chrSize/size; i++ {
+		reg := (i+1)*(8>>m.chrMode) - 1
+		m.chr.set(i*size, size, upper|int(m.chrRegs[reg]))
+	}
+	m.updateCHRBanksB(upper)
+}
+
+// background set covers 4 KB and is mirrored in both halves
+func (m *mmc5) updateCHRBanksB(upper int) {
+	size := chrSize >> m.chrMode
+	for i := 0; i < chrSize/size; i++ {
+		reg := 8 + ((i+1)*(8>>m.chrMode)-1)%4
+		m.chrB.set(i*size, size, upper|int(m.chrRegs[reg]))
+	}
+}
+
+func (m *mmc5) IsIRQ() bool {
+	return m.isIRQEnabled && m.isIRQPending || m.audio.isIRQ()
+}
+
+func (m *mmc5) GetAudioOutput() float32 {
+	return m.audio.getOutput()
+}
+
+func (m *mmc5) Tick() {
+	m.scanline.tick()
+	m.audio.tick()
+}
@@ -0,0 +1,113 @@
+package mapper
+
+import "github.com/smarkuck/nes/nes/apu"
+
+const (
+	mmc5FrameCycles = 7457
+	mmc5PCMReadMode = 0x01
+	mmc5PCMIRQ      = 0x80
+	mmc5PCMReadEnd  = 0xc000
+	pcmMixScale     = 0.00335 / 2
+)
+
+// pulses are the same as in APU but without sweep unit
+type mmc5Audio struct {
+	pulses      [2]apu.Pulse
+	pcm         byte
+	pcmControl  byte
+	isPCMIRQ    bool
+	frameCycles int
+	isOddCycle  bool
+}
+
+func newMMC5Audio() mmc5Audio {
+	return mmc5Audio{pulses: [2]apu.Pulse{
+		apu.NewPulse(), apu.NewPulse()}}
+}
+
+func (a *mmc5Audio) write(addr uint16, value byte) {
+	switch {
+	case addr <= 0x5007:
+		a.pulses[(addr-0x5000)/4].Write(addr%4, value)
+	case addr == 0x5010:
+		a.pcmControl = value
+	case addr == 0x5011:
+		a.writePCM(value)
+	case addr == 0x5015:
+		a.pulses[0].SetEnabled(value&0x01 != 0)
+		a.pulses[1].SetEnabled(value&0x02 != 0)
+	}
+}
+
+func (a *mmc5Audio) writePCM(value byte) {
+	if a.pcmControl&mmc5PCMReadMode == 0 && value != 0 {
+		a.pcm = value
+	}
+}
+
+func (a *mmc5Audio) read(addr uint16) byte {
+	if addr == 0x5010 {
+		return a.readPCMStatus()
+	}
+	var status byte
+	for i, p := range a.pulses {
+		if p.IsPlaying() {
+			status |= 1 << i
+		}
+	}
+	return status
+}
+
+func (a *mmc5Audio) readPCMStatus() byte {
+	var status byte
+	if a.isIRQ() {
+		status = mmc5PCMIRQ
+	}
+	a.isPCMIRQ = false
+	return status
+}
+
+// in read mode PCM samples come from CPU reads of $8000-$BFFF,
+// zero sample raises IRQ instead of changing output
+func (a *mmc5Audio) observePRGRead(addr uint16, value byte) {
+	if a.pcmControl&mmc5PCMReadMode == 0 ||
+		addr >= mmc5PCMReadEnd {
+		return
+	}
+	if value == 0 {
+		a.isPCMIRQ = true
+	} else {
+		a.pcm = value
+	}
+}
+
+func (a *mmc5Audio) isIRQ() bool {
+	return a.isPCMIRQ && a.pcmControl&mmc5PCMIRQ != 0
+}
+
+func (a *mmc5Audio) tick() {
+	a.isOddCycle = !a.isOddCycle
+	if a.isOddCycle {
+		a.pulses[0].ClockTimer()
+		a.pulses[1].ClockTimer()
+	}
+	a.frameCycles++
+	if a.frameCycles == mmc5FrameCycles {
+		a.frameCycles = 0
+		a.clockFrame()
+	}
+}
+
+// MMC5 has no frame counter, envelopes and length
+// counters are always clocked at 240 Hz
+func (a *mmc5Audio) clockFrame() {
+	for _, p := range a.pulses {
+		p.ClockQuarterFrame()
+		p.ClockHalfFrame()
+	}
+}
+
+func (a *mmc5Audio) getOutput() float32 {
+	return apu.MixPulses(a.pulses[0].GetOutput(),
+		a.pulses[1].GetOutput()) + float32(a.pcm)*pcmMixScale
+}
@@ -0,0 +1,284 @@
+package mapper
+
+const (
+	scanlineMatches   = 2
+	scanlineIdleLimit = 3
+
+	bgFetchesEnd     = 128
+	spriteFetchesEnd = 160
+	prefetchesEnd    = 168
+	fetchesPerTile   = 4
+	prefetchedTiles  = 2
+	attributeFetch   = 1
+
+	nametableAddr  = 0x2000
+	nametableEnd   = 0x3000
+	nametableSize  = 0x0400
+	attributeStart = 0x03c0
+	screenHeight   = 240
+
+	ciramA = 0
+	ciramB = 1
+	exRAM  = 2
+
+	splitEnabled = 0x80
+	splitRight   = 0x40
+	splitTile    = 0x1f
+
+	extendedBank    = 0x3f
+	extendedPalette = 6
+	paletteFill     = 0x55
+	chr4KBBank      = 0x1000
+	chr4KBOffset    = 0x0fff
+)
+
+type scanlineDetector struct {
+	lastAddr   uint16
+	matches    int
+	fetch      int
+	idleCycles int
+	line       int
+	isInFrame  bool
+}
+
+// PPU reads the same nametable address three times in a row
+// only at the start of each rendered scanline
+func (s *scanlineDetector) observe(addr uint16) bool {
+	s.idleCycles = 0
+	s.fetch++
+	if !isNametable(addr) || addr != s.lastAddr {
+		s.lastAddr, s.matches = addr, 0
+		return false
+	}
+	s.matches++
+	if s.matches != scanlineMatches {
+		return false
+	}
+	s.startLine()
+	return true
+}
+
+func isNametable(addr uint16) bool {
+	return addr >= nametableAddr && addr < nametableEnd
+}
+
+func (s *scanlineDetector) startLine() {
+	s.fetch = 1
+	if s.isInFrame {
+		s.line++
+	} else {
+		s.isInFrame, s.line = true, 0
+	}
+}
+
+func (s *scanlineDetector) tick() {
+	s.idleCycles++
+	if s.idleCycles >= scanlineIdleLimit {
+		s.leaveFrame()
+	}
+}
+
+func (s *scanlineDetector) leaveFrame() {
+	s.isInFrame, s.matches = false, 0
+}
+
+func (s *scanlineDetector) isSpriteFetch() bool {
+	return s.fetch >= bgFetchesEnd && s.fetch < spriteFetchesEnd
+}
+
+// fetches of the first two tiles happen at the end
+// of the previous scanline
+func (s *scanlineDetector) getTile() (int, int, bool) {
+	switch {
+	case s.fetch < bgFetchesEnd:
+		return s.fetch/fetchesPerTile + prefetchedTiles,
+			s.line, true
+	case s.fetch >= spriteFetchesEnd && s.fetch < prefetchesEnd:
+		return (s.fetch - spriteFetchesEnd) / fetchesPerTile,
+			s.line + 1, true
+	}
+	return 0, 0, false
+}
+
+func (s *scanlineDetector) getFetchKind() int {
+	return s.fetch % fetchesPerTile
+}
+
+func (m *mmc5) ObservePPUAddress(addr uint16) {
+	if !m.scanline.observe(addr) {
+		return
+	}
+	line := m.scanline.line
+	if m.irqCompare != 0 && line == int(m.irqCompare) {
+		m.isIRQPending = true
+	}
+}
+
+// sprite size and rendering state are taken from PPU
+// registers, MMC5 has no own copy of them
+func (m *mmc5) ObservePPURegister(addr uint16, value byte) {
+	switch addr {
+	case mmc5PPUCtrl:
+		m.isLargeSprites = value&mmc5LargeSprites != 0
+	case mmc5PPUMask:
+		m.setRendering(value&mmc5Rendering != 0)
+	}
+}
+
+func (m *mmc5) setRendering(isEnabled bool) {
+	m.isRendering = isEnabled
+	if !isEnabled {
+		m.scanline.leaveFrame()
+	}
+}
+
+func (m *mmc5) isFetching() bool {
+	return m.isRendering && m.scanline.isInFrame
+}
+
+func (m *mmc5) isBackgroundFetch() bool {
+	_, _, ok := m.scanline.getTile()
+	return m.isFetching() && ok
+}
+
+func (m *mmc5) ReadCHR(addr uint16) byte {
+	switch {
+	case m.isFetching() && m.scanline.isSpriteFetch():
+		return m.chr.read(int(addr))
+	case !m.isBackgroundFetch():
+		return m.getLastCHRSet().read(int(addr))
+	case m.isSplitFetch():
+		return m.readSplitPattern(addr)
+	case m.exRAMMode == mmc5ExRAMAttributes:
+		return m.readExtendedPattern(addr)
+	case m.isLargeSprites:
+		return m.chrB.read(int(addr))
+	}
+	return m.getLastCHRSet().read(int(addr))
+}
+
+// with 8x8 sprites the last written set is used for everything
+func (m *mmc5) getLastCHRSet() *banks {
+	if m.isLastB {
+		return &m.chrB
+	}
+	return &m.chr
+}
+
+func (m *mmc5) readCHR4KB(bank int, offset uint16) byte {
+	data := m.chr.data
+	if len(data) == 0 {
+		return 0
+	}
+	index := bank*chr4KBBank + int(offset&chr4KBOffset)
+	return data[index%len(data)]
+}
+
+func (m *mmc5) readExtendedPattern(addr uint16) byte {
+	bank := int(m.exRAM[m.tileOffset]&extendedBank) |
+		int(m.chrUpper)<<6
+	return m.readCHR4KB(bank, addr)
+}
+
+func (m *mmc5) isSplitFetch() bool {
+	if m.splitCtrl&splitEnabled == 0 ||
+		m.exRAMMode > mmc5ExRAMAttributes {
+		return false
+	}
+	column, _, ok := m.scanline.getTile()
+	threshold := int(m.splitCtrl & splitTile)
+	if m.splitCtrl&splitRight != 0 {
+		return ok && column >= threshold
+	}
+	return ok && column < threshold
+}
+
+func (m *mmc5) getSplitY() int {
+	_, line, _ := m.scanline.getTile()
+	return (int(m.splitY) + line) % screenHeight
+}
+
+func (m *mmc5) readSplitPattern(addr uint16) byte {
+	fineY := uint16(m.getSplitY() & 0x07)
+	return m.readCHR4KB(int(m.splitBank), addr&0x0ff8|fineY)
+}
+
+func (m *mmc5) readSplitNametable() byte {
+	column, _, _ := m.scanline.getTile()
+	y := m.getSplitY()
+	if m.scanline.getFetchKind() != attributeFetch {
+		return m.exRAM[y/8*32+column]
+	}
+	attr := m.exRAM[attributeStart+y/32*8+column/4]
+	shift := (y/16&1)*4 + (column/2&1)*2
+	return (attr >> shift & 0x03) * paletteFill
+}
+
+func (m *mmc5) ReadNametable(addr uint16, ciram []byte) byte {
+	offset := addr % nametableSize
+	if offset < attributeStart {
+		m.tileOffset = offset
+	}
+	if m.isBackgroundFetch() {
+		if m.isSplitFetch() {
+			return m.readSplitNametable()
+		}
+		if value, ok := m.readExtendedAttribute(); ok {
+			return value
+		}
+	}
+	switch m.getNametableSource(addr) {
+	case ciramA:
+		return ciram[offset]
+	case ciramB:
+		return ciram[nametableSize+offset]
+	case exRAM:
+		return m.readExRAMNametable(offset)
+	}
+	return m.readFill(offset)
+}
+
+// in extended attribute mode each tile gets its own palette
+// and 4 KB CHR bank from ExRAM byte matching nametable entry
+func (m *mmc5) readExtendedAttribute() (byte, bool) {
+	if m.exRAMMode != mmc5ExRAMAttributes ||
+		m.scanline.getFetchKind() != attributeFetch {
+		return 0, false
+	}
+	palette := m.exRAM[m.tileOffset] >> extendedPalette
+	return palette * paletteFill, true
+}
+
+func (m *mmc5) readExRAMNametable(offset uint16) byte {
+	if m.exRAMMode > mmc5ExRAMAttributes {
+		return 0
+	}
+	return m.exRAM[offset]
+}
+
+func (m *mmc5) readFill(offset uint16) byte {
+	if offset < attributeStart {
+		return m.fillTile
+	}
+	return m.fillAttr * paletteFill
+}
+
+func (m *mmc5) WriteNametable(
+	addr uint16, value byte, ciram []byte) {
+	offset := addr % nametableSize
+	switch m.getNametableSource(addr) {
+	case ciramA:
+		ciram[offset] = value
+	case ciramB:
+		ciram[nametableSize+offset] = value
+	case exRAM:
+		if m.exRAMMode <= mmc5ExRAMAttributes {
+			m.exRAM[offset] = value
+		}
+	}
+}
+
+func (m *mmc5) getNametableSource(addr uint16) byte {
+	table := (addr - nametableAddr) / nametableSize % 4
+	return m.ntMapping >> (table * 2) & 0x03
+}
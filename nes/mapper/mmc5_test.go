@@ -0,0 +1,352 @@
+package mapper_test
+
+import (
+	"github.com/smarkuck/nes/nes"
+	"github.com/smarkuck/nes/nes/cartridge"
+	. "github.com/smarkuck/nes/nes/mapper"
+	"github.com/smarkuck/nes/nes/ppu"
+	. "github.com/smarkuck/unittest"
+)
+
+const (
+	mmc5PRGMode    = 0x5100
+	mmc5CHRMode    = 0x5101
+	mmc5RAMProtect = 0x5102
+	mmc5ExRAMMode  = 0x5104
+	mmc5NTMapping  = 0x5105
+	mmc5FillTile   = 0x5106
+	mmc5FillAttr   = 0x5107
+	mmc5PRGRAMBank = 0x5113
+	mmc5PRGBanks   = 0x5114
+	mmc5CHRBanks   = 0x5120
+	mmc5SplitCtrl  = 0x5200
+	mmc5SplitY     = 0x5201
+	mmc5SplitBank  = 0x5202
+	mmc5IRQCompare = 0x5203
+	mmc5IRQStatus  = 0x5204
+	mmc5Multiplier = 0x5205
+	mmc5ExRAM      = 0x5c00
+
+	ppuMask   = 0x2001
+	ciramSize = 0x0800
+
+	invalidExRAMText  = "invalid ExRAM value"
+	invalidNTText     = "invalid nametable value"
+	invalidStatusText = "invalid status"
+)
+
+// ppuSim fetches tiles in PPU order through its bus
+type ppuSim struct {
+	ppu.Bus
+}
+
+func newMMC5(t *T) Mapper {
+	return newMapper(t, &cartridge.Cartridge{
+		PRG:    newBankedData(16, prg8KBBankSize),
+		CHR:    newBankedData(256, chr1KBBankSize),
+		Mapper: 5,
+	})
+}
+
+func newPPUSim(m Mapper) *ppuSim {
+	m.(PPURegisterObserver).ObservePPURegister(ppuMask, 0x18)
+	return &ppuSim{ppu.NewBus(m)}
+}
+
+func (p *ppuSim) fetch(addr uint16) byte {
+	return p.Read(addr)
+}
+
+func (p *ppuSim) fetchTile(column int) [4]byte {
+	nt := p.fetch(0x2000 + uint16(column%32))
+	at := p.fetch(0x23c0 + uint16(column%32/4))
+	lo := p.fetch(uint16(nt) * 16)
+	hi := p.fetch(uint16(nt)*16 + 8)
+	return [4]byte{nt, at, lo, hi}
+}
+
+func (p *ppuSim) finishLine() [2][4]byte {
+	for i := 0; i < 8; i++ {
+		p.fetch(0x2100)
+		p.fetch(0x2100)
+		p.fetch(0x1000)
+		p.fetch(0x1008)
+	}
+	tiles := [2][4]byte{p.fetchTile(0), p.fetchTile(1)}
+	p.fetch(0x2002)
+	p.fetch(0x2002)
+	return tiles
+}
+
+func (p *ppuSim) renderLine() [32][4]byte {
+	var tiles [32][4]byte
+	for i := range tiles {
+		tiles[i] = p.fetchTile(i + 2)
+	}
+	p.finishLine()
+	return tiles
+}
+
+func writeMMC5Banks(m Mapper, addr uint16, banks ...byte) {
+	for i, bank := range banks {
+		m.Write(addr+uint16(i), bank)
+	}
+}
+
+func Test_MMC5_PRGModes(t *T) {
+	tests := []struct {
+		name  string
+		mode  byte
+		regs  [4]byte
+		banks [4]byte
+	}{
+		{"32KB", 0, [4]byte{0, 0, 0, 0x87}, [4]byte{4, 5, 6, 7}},
+		{"16KB", 1, [4]byte{0, 0x85, 0, 0x8f},
+			[4]byte{4, 5, 14, 15}},
+		{"16KB_8KB", 2, [4]byte{0, 0x83, 0x89, 0x8c},
+			[4]byte{2, 3, 9, 12}},
+		{"8KB", 3, [4]byte{0x81, 0x82, 0x83, 0x84},
+			[4]byte{1, 2, 3, 4}},
+		{"LastBankAlwaysROM", 3, [4]byte{0x81, 0x82, 0x83, 0x04},
+			[4]byte{1, 2, 3, 4}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			m := newMMC5(t)
+			m.Write(mmc5PRGMode, test.mode)
+			writeMMC5Banks(m, mmc5PRGBanks, test.regs[:]...)
+			for i, bank := range test.banks {
+				addr := 0x8000 + uint16(i)*prg8KBBankSize
+				expectPRGEq(t, m, addr, bank)
+			}
+		})
+	}
+}
+
+func Test_MMC5_OnPowerUp_MapLastBankAtE000(t *T) {
+	m := newMMC5(t)
+
+	expectPRGEq(t, m, 0xe000, 15)
+}
+
+func Test_MMC5_PRGRAM(t *T) {
+	tests := []struct {
+		name    string
+		protect [2]byte
+		value   byte
+	}{
+		{"Unlocked", [2]byte{2, 1}, 0x42},
+		{"Locked", [2]byte{2, 0}, 0x00},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			m := newMMC5(t)
+			writeMMC5Banks(m, mmc5RAMProtect, test.protect[:]...)
+			m.Write(mmc5PRGRAMBank, 3)
+			m.Write(0x6000, 0x42)
+			m.Write(mmc5PRGBanks+1, 0x03)
+			ExpectEq(t, m.Read(0xa000), test.value,
+				invalidPRGRAMText)
+		})
+	}
+}
+
+func Test_MMC5_CHRModes(t *T) {
+	tests := []struct {
+		name  string
+		mode  byte
+		banks [8]byte
+	}{
+		{"8KB", 0, [8]byte{56, 57, 58, 59, 60, 61, 62, 63}},
+		{"4KB", 1, [8]byte{12, 13, 14, 15, 28, 29, 30, 31}},
+		{"2KB", 2, [8]byte{2, 3, 6, 7, 10, 11, 14, 15}},
+		{"1KB", 3, [8]byte{0, 1, 2, 3, 4, 5, 6, 7}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			m := newMMC5(t)
+			m.Write(mmc5CHRMode, test.mode)
+			writeMMC5Banks(m, mmc5CHRBanks, 0, 1, 2, 3, 4, 5, 6, 7)
+			for i, bank := range test.banks {
+				addr := uint16(i) * chr1KBBankSize
+				expectCHREq(t, m, addr, bank)
+			}
+		})
+	}
+}
+
+func Test_MMC5_CHRUpperBits(t *T) {
+	chr := make([]byte, 512*chr1KBBankSize)
+	chr[0x105*chr1KBBankSize] = 0xee
+	m := newMapper(t, &cartridge.Cartridge{
+		PRG:    newBankedData(16, prg8KBBankSize),
+		CHR:    chr,
+		Mapper: 5,
+	})
+
+	m.Write(0x5130, 0x01)
+	m.Write(mmc5CHRBanks, 0x05)
+
+	expectCHREq(t, m, 0x0000, 0xee)
+}
+
+func Test_MMC5_Multiplier(t *T) {
+	m := newMMC5(t)
+
+	writeMMC5Banks(m, mmc5Multiplier, 0x12, 0x34)
+
+	ExpectEq(t, m.Read(0x5205), 0xa8)
+	ExpectEq(t, m.Read(0x5206), 0x03)
+}
+
+func Test_MMC5_ScanlineIRQ(t *T) {
+	m := newMMC5(t)
+	ppu := newPPUSim(m)
+	m.Write(mmc5IRQCompare, 3)
+	m.Write(mmc5IRQStatus, 0x80)
+
+	ppu.finishLine()
+	for i := 0; i < 3; i++ {
+		ppu.renderLine()
+		expectIRQEq(t, m, false)
+	}
+	ppu.fetchTile(2)
+
+	expectIRQEq(t, m, true)
+	ExpectEq(t, m.Read(mmc5IRQStatus), 0xc0, invalidStatusText)
+	expectIRQEq(t, m, false)
+}
+
+func Test_MMC5_WhenPPUIdle_LeaveFrame(t *T) {
+	m := newMMC5(t)
+	ppu := newPPUSim(m)
+	ppu.finishLine()
+	ppu.fetchTile(2)
+	ExpectEq(t, m.Read(mmc5IRQStatus), 0x40, invalidStatusText)
+
+	m.Tick()
+	m.Tick()
+	m.Tick()
+
+	ExpectEq(t, m.Read(mmc5IRQStatus), 0x00, invalidStatusText)
+}
+
+func Test_MMC5_NametableMapping(t *T) {
+	m := newMMC5(t)
+	ppu := newPPUSim(m)
+	m.Write(mmc5NTMapping, 0b11_10_01_00)
+	m.Write(mmc5FillTile, 0x44)
+	m.Write(mmc5FillAttr, 0x02)
+	m.Write(mmc5ExRAMMode, 2)
+	m.Write(mmc5ExRAM+5, 0x33)
+	m.Write(mmc5ExRAMMode, 0)
+
+	ppu.Write(0x2005, 0x11)
+	ppu.Write(0x2405, 0x22)
+
+	ExpectEq(t, ppu.Peek(0x2805), 0x33, invalidNTText)
+	ExpectEq(t, ppu.Peek(0x2c05), 0x44, invalidNTText)
+	ExpectEq(t, ppu.Peek(0x2fc5), 0xaa, invalidNTText)
+	m.Write(mmc5NTMapping, 0b01_00_00_00)
+	ExpectEq(t, ppu.Peek(0x2805), 0x11, invalidNTText)
+	ExpectEq(t, ppu.Peek(0x2c05), 0x22, invalidNTText)
+}
+
+func Test_MMC5_ExRAMModes(t *T) {
+	tests := []struct {
+		name  string
+		mode  byte
+		value byte
+	}{
+		{"ReadWrite", 2, 0x42},
+		{"ReadOnly", 3, 0x17},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			m := newMMC5(t)
+			m.Write(mmc5ExRAMMode, 2)
+			m.Write(mmc5ExRAM, 0x17)
+			m.Write(mmc5ExRAMMode, test.mode)
+			m.Write(mmc5ExRAM, 0x42)
+			ExpectEq(t, m.Read(mmc5ExRAM), test.value,
+				invalidExRAMText)
+		})
+	}
+}
+
+func Test_MMC5_OutsideFrame_WriteZeroToExRAM(t *T) {
+	m := newMMC5(t)
+	m.Write(mmc5ExRAMMode, 2)
+	m.Write(mmc5ExRAM, 0x17)
+	m.Write(mmc5ExRAMMode, 0)
+
+	m.Write(mmc5ExRAM, 0x42)
+	m.Write(mmc5ExRAMMode, 2)
+
+	ExpectEq(t, m.Read(mmc5ExRAM), 0x00, invalidExRAMText)
+}
+
+func Test_MMC5_ExtendedAttributes(t *T) {
+	m := newMMC5(t)
+	ppu := newPPUSim(m)
+	m.Write(mmc5ExRAMMode, 2)
+	m.Write(mmc5ExRAM+2, 0b10_000101)
+	m.Write(mmc5ExRAMMode, 1)
+
+	ppu.finishLine()
+	tiles := ppu.renderLine()
+
+	ExpectEq(t, tiles[0], [4]byte{0, 0xaa, 20, 20})
+	ExpectEq(t, tiles[1], [4]byte{0, 0x00, 0, 0})
+}
+
+func Test_MMC5_VerticalSplit(t *T) {
+	m := newMMC5(t)
+	ppu := newPPUSim(m)
+	m.Write(mmc5ExRAMMode, 2)
+	m.Write(mmc5ExRAM+3*32+31, 0x10)
+	m.Write(mmc5ExRAM+0x3c0+7, 0b11_00_00_00)
+	m.Write(mmc5ExRAMMode, 0)
+	m.Write(mmc5SplitCtrl, 0xc0|30)
+	m.Write(mmc5SplitY, 16+8)
+	m.Write(mmc5SplitBank, 3)
+	ppu.Write(0x2000+31, 0x01)
+
+	ppu.finishLine()
+	tiles := ppu.renderLine()
+
+	ExpectEq(t, tiles[29], [4]byte{0x10, 0xff, 12, 12})
+	ExpectEq(t, tiles[27][0], 0x00)
+}
+
+func Test_MMC5_PulseOutput(t *T) {
+	m := newMMC5(t)
+	audio := m.(interface{ GetAudioOutput() float32 })
+	writeMMC5Banks(m, 0x5000, 0x3f, 0x00, 0x00, 0x08)
+	m.Write(0x5015, 0x01)
+	writeMMC5Banks(m, 0x5000, 0x3f, 0x00, 0x00, 0x08)
+
+	m.Tick()
+	m.Tick()
+
+	ExpectTrue(t, audio.GetAudioOutput() > 0, invalidAudioText)
+	ExpectEq(t, m.Read(0x5015), 0x01, invalidStatusText)
+}
+
+func Test_MMC5_PCM(t *T) {
+	m := newMMC5(t)
+	audio := m.(interface{ GetAudioOutput() float32 })
+
+	m.Write(0x5011, 0x80)
+	ExpectTrue(t, audio.GetAudioOutput() > 0, invalidAudioText)
+
+	m.Write(0x5010, 0x81)
+	m.Read(0x8000)
+	ExpectTrue(t, m.(nes.IRQSource).IsIRQ(), invalidIRQText)
+	ExpectEq(t, m.Read(0x5010), 0x80, invalidStatusText)
+	ExpectFalse(t, m.(nes.IRQSource).IsIRQ(), invalidIRQText)
+}
@@ -0,0 +1,216 @@
+package mapper
+
+import "github.com/smarkuck/nes/nes/cartridge"
+
+const (
+	n163PRGBank      = 0x3f
+	n163SoundOff     = 0x40
+	n163LowCHRRAM    = 0x40
+	n163HighCHRRAM   = 0x80
+	n163CIRAMBank    = 0xe0
+	n163IRQEnabled   = 0x80
+	n163IRQMax       = 0x7fff
+	n163RAMEnableKey = 0x40
+	n163RAMProtect   = 0xf0
+	n163RAMSection   = 0x0800
+)
+
+type namco163 struct {
+	base
+	chrBanks     [8]byte
+	ntBanks      [4]byte
+	prgBanks     [3]byte
+	chrControl   byte
+	ramProtect   byte
+	irqCounter   uint16
+	isIRQEnabled bool
+	isIRQPending bool
+	ciram        []byte
+	audio        n163Audio
+}
+
+func newNamco163(c *cartridge.Cartridge) Mapper {
+	m := &namco163{base: newBase(c, getPRGRAMSize(c))}
+	m.mirroring = cartridge.MapperControlled
+	m.updatePRGBanks()
+	return m
+}
+
+func (m *namco163) Read(addr uint16) byte {
+	switch addr & 0xf800 {
+	case 0x4800:
+		return m.audio.read()
+	case 0x5000:
+		return byte(m.irqCounter)
+	case 0x5800:
+		return m.readIRQHigh()
+	}
+	return m.base.Read(addr)
+}
+
+func (m *namco163) readIRQHigh() byte {
+	value := byte(m.irqCounter >> 8)
+	if m.isIRQEnabled {
+		value |= n163IRQEnabled
+	}
+	return value
+}
+
+func (m *namco163) Write(addr uint16, value byte) {
+	switch {
+	case addr >= prgROMAddr:
+		m.writeRegister(addr, value)
+	case addr >= prgRAMAddr:
+		m.writePRGRAM(addr, value)
+	default:
+		m.writeLowRegister(addr, value)
+	}
+}
+
+func (m *namco163) writeLowRegister(addr uint16, value byte) {
+	switch addr & 0xf800 {
+	case 0x4800:
+		m.audio.write(value)
+	case 0x5000:
+		m.irqCounter = m.irqCounter&0x7f00 | uint16(value)
+		m.isIRQPending = false
+	case 0x5800:
+		m.irqCounter = m.irqCounter&0x00ff |
+			uint16(value&^n163IRQEnabled)<<8
+		m.isIRQEnabled = value&n163IRQEnabled != 0
+		m.isIRQPending = false
+	}
+}
+
+// PRG-RAM is writable only with key in upper nibble,
+// lower bits protect its 2 KB sections
+func (m *namco163) writePRGRAM(addr uint16, value byte) {
+	if m.ramProtect&n163RAMProtect != n163RAMEnableKey {
+		return
+	}
+	section := (addr - prgRAMAddr) / n163RAMSection
+	if m.ramProtect&(1<<section) == 0 {
+		m.base.Write(addr, value)
+	}
+}
+
+func (m *namco163) writeRegister(addr uint16, value byte) {
+	index := (addr >> 11) & 0x0f
+	switch {
+	case index < 8:
+		m.chrBanks[index] = value
+	case index < 12:
+		m.ntBanks[index-8] = value
+	case index == 12:
+		m.prgBanks[0] = value
+		m.audio.isDisabled = value&n163SoundOff != 0
+	case index == 13:
+		m.prgBanks[1] = value
+		m.chrControl = value
+	case index == 14:
+		m.prgBanks[2] = value
+	case index == 15:
+		m.ramProtect = value
+		m.audio.setAddress(value)
+	}
+	m.updatePRGBanks()
+}
+
+func (m *namco163) updatePRGBanks() {
+	for i, bank := range m.prgBanks {
+		m.prg.set(i*0x2000, 0x2000, int(bank&n163PRGBank))
+	}
+	m.prg.set(0x6000, 0x2000, -1)
+}
+
+func (m *namco163) ReadCHR(addr uint16) byte {
+	if offset, ok := m.getCIRAMOffset(addr); ok {
+		return m.readCIRAM(offset)
+	}
+	return m.readCHRBank(m.chrBanks[addr/chrWindow], addr)
+}
+
+func (m *namco163) WriteCHR(addr uint16, value byte) {
+	if offset, ok := m.getCIRAMOffset(addr); ok {
+		m.writeCIRAM(offset, value)
+	}
+}
+
+// banks $E0-$FF select nametable RAM as pattern table
+// unless it was disabled for given half
+func (m *namco163) getCIRAMOffset(addr uint16) (int, bool) {
+	bank := m.chrBanks[addr/chrWindow]
+	disable := byte(n163LowCHRRAM)
+	if addr >= 0x1000 {
+		disable = n163HighCHRRAM
+	}
+	if bank < n163CIRAMBank || m.chrControl&disable != 0 {
+		return 0, false
+	}
+	return getCIRAMPage(bank) + int(addr%chrWindow), true
+}
+
+func getCIRAMPage(bank byte) int {
+	return int(bank&0x01) * chrWindow
+}
+
+func (m *namco163) readCHRBank(bank byte, addr uint16) byte {
+	data := m.chr.data
+	if len(data) == 0 {
+		return 0
+	}
+	index := int(bank)*chrWindow + int(addr%chrWindow)
+	return data[index%len(data)]
+}
+
+// nametable RAM is remembered from PPU accesses
+// so it can also serve pattern fetches
+func (m *namco163) readCIRAM(offset int) byte {
+	if m.ciram == nil {
+		return 0
+	}
+	return m.ciram[offset]
+}
+
+func (m *namco163) writeCIRAM(offset int, value byte) {
+	if m.ciram != nil {
+		m.ciram[offset] = value
+	}
+}
+
+func (m *namco163) ReadNametable(addr uint16, ciram []byte) byte {
+	m.ciram = ciram
+	bank := m.ntBanks[(addr>>10)&0x03]
+	if bank >= n163CIRAMBank {
+		return ciram[getCIRAMPage(bank)+int(addr%chrWindow)]
+	}
+	return m.readCHRBank(bank, addr)
+}
+
+func (m *namco163) WriteNametable(
+	addr uint16, value byte, ciram []byte) {
+	m.ciram = ciram
+	bank := m.ntBanks[(addr>>10)&0x03]
+	if bank >= n163CIRAMBank {
+		ciram[getCIRAMPage(bank)+int(addr%chrWindow)] = value
+	}
+}
+
+func (m *namco163) IsIRQ() bool {
+	return m.isIRQPending
+}
+
+func (m *namco163) GetAudioOutput() float32 {
+	return m.audio.getOutput()
+}
+
+func (m *namco163) Tick() {
+	m.audio.tick()
+	if !m.isIRQEnabled || m.irqCounter == n163IRQMax {
+		return
+	}
+	m.irqCounter++
+	if m.irqCounter == n163IRQMax {
+		m.isIRQPending = true
+	}
+}
@@ -0,0 +1,94 @@
+package mapper
+
+const (
+	n163RAMSize       = 0x80
+	n163AddressMask   = 0x7f
+	n163AutoIncrement = 0x80
+	n163ChannelsStart = 0x40
+	n163ChannelSize   = 8
+	n163ChannelCount  = 0x70
+	n163ChannelShift  = 4
+	n163ChannelCycles = 15
+	n163LengthMask    = 0xfc
+	n163FrequencyHigh = 0x03
+	n163Volume        = 0x0f
+	n163SampleCenter  = 8
+	n163MixScale      = 0.00125
+)
+
+// only one channel is updated and heard at a time,
+// more enabled channels mean lower rate and audible whine
+type n163Audio struct {
+	ram        [n163RAMSize]byte
+	address    byte
+	isAutoInc  bool
+	isDisabled bool
+	channel    int
+	cycles     int
+	output     int
+}
+
+func (a *n163Audio) setAddress(value byte) {
+	a.address = value & n163AddressMask
+	a.isAutoInc = value&n163AutoIncrement != 0
+}
+
+func (a *n163Audio) read() byte {
+	value := a.ram[a.address]
+	a.increment()
+	return value
+}
+
+func (a *n163Audio) write(value byte) {
+	a.ram[a.address] = value
+	a.increment()
+}
+
+func (a *n163Audio) increment() {
+	if a.isAutoInc {
+		a.address = (a.address + 1) & n163AddressMask
+	}
+}
+
+func (a *n163Audio) getChannelCount() int {
+	last := a.ram[n163RAMSize-1]
+	return int(last&n163ChannelCount>>n163ChannelShift) + 1
+}
+
+func (a *n163Audio) tick() {
+	if a.isDisabled {
+		return
+	}
+	a.cycles++
+	if a.cycles < n163ChannelCycles {
+		return
+	}
+	a.cycles = 0
+	a.channel--
+	if a.channel < 8-a.getChannelCount() {
+		a.channel = 7
+	}
+	a.output = a.updateChannel(a.channel)
+}
+
+// each channel has 24-bit phase and frequency,
+// samples are 4-bit nibbles in the same RAM
+func (a *n163Audio) updateChannel(channel int) int {
+	r := a.ram[n163ChannelsStart+channel*n163ChannelSize:]
+	frequency := uint32(r[0]) | uint32(r[2])<<8 |
+		uint32(r[4]&n163FrequencyHigh)<<16
+	phase := uint32(r[1]) | uint32(r[3])<<8 | uint32(r[5])<<16
+	length := (256 - uint32(r[4]&n163LengthMask)) << 16
+	phase = (phase + frequency) % length
+	r[1], r[3], r[5] = byte(phase), byte(phase>>8), byte(phase>>16)
+	index := (phase>>16 + uint32(r[6])) & 0xff
+	sample := a.ram[index/2] >> (index % 2 * 4) & 0x0f
+	return (int(sample) - n163SampleCenter) * int(r[7]&n163Volume)
+}
+
+func (a *n163Audio) getOutput() float32 {
+	if a.isDisabled {
+		return 0
+	}
+	return float32(a.output) * n163MixScale
+}
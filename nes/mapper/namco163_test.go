@@ -0,0 +1,168 @@
+package mapper_test
+
+import (
+	. "github.com/smarkuck/nes/nes/mapper"
+	. "github.com/smarkuck/unittest"
+)
+
+const n163MixScale = 0.00125
+
+func newNamco163(t *T) Mapper {
+	return newVRC(t, 19, 0)
+}
+
+func expectN163LevelEq(t *T, m Mapper, level int) {
+	expectAudioEq(t, m, float32(level)*n163MixScale)
+}
+
+func writeN163RAM(m Mapper, addr byte, values ...byte) {
+	m.Write(0xf800, 0x80|addr)
+	for _, value := range values {
+		m.Write(0x4800, value)
+	}
+}
+
+func Test_Namco163_Banks(t *T) {
+	m := newNamco163(t)
+	for i := uint16(0); i < 8; i++ {
+		m.Write(0x8000+i*0x0800, byte(20+i))
+	}
+	m.Write(0xe000, 1)
+	m.Write(0xe800, 2)
+	m.Write(0xf000, 3)
+
+	expectPRGEq(t, m, 0x8000, 1)
+	expectPRGEq(t, m, 0xa000, 2)
+	expectPRGEq(t, m, 0xc000, 3)
+	expectPRGEq(t, m, 0xe000, 15)
+	for i := uint16(0); i < 8; i++ {
+		expectCHREq(t, m, i*chr1KBBankSize, byte(20+i))
+	}
+}
+
+func Test_Namco163_Nametables(t *T) {
+	m := newNamco163(t)
+	nt := m.(NametableProvider)
+	ciram := make([]byte, ciramSize)
+	m.Write(0xc000, 0xe0)
+	m.Write(0xc800, 0xe1)
+	m.Write(0xd000, 0x05)
+
+	nt.WriteNametable(0x2001, 0x11, ciram)
+	nt.WriteNametable(0x2401, 0x22, ciram)
+	nt.WriteNametable(0x2801, 0x33, ciram)
+
+	ExpectEq(t, ciram[0x0001], 0x11, invalidNTText)
+	ExpectEq(t, ciram[0x0401], 0x22, invalidNTText)
+	ExpectEq(t, nt.ReadNametable(0x2801, ciram), 0x05,
+		invalidNTText)
+}
+
+func Test_Namco163_CIRAMAsPatternTable(t *T) {
+	tests := []struct {
+		name    string
+		control byte
+		value   byte
+	}{
+		{"Enabled", 0x00, 0x42},
+		{"Disabled", 0x40, 0xe1 % 64},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			m := newNamco163(t)
+			ciram := make([]byte, ciramSize)
+			ciram[0x0400] = 0x42
+			m.(NametableProvider).ReadNametable(0x2000, ciram)
+			m.Write(0x8000, 0xe1)
+			m.Write(0xe800, test.control)
+			expectCHREq(t, m, 0x0000, test.value)
+		})
+	}
+}
+
+func Test_Namco163_PRGRAMProtect(t *T) {
+	tests := []struct {
+		name    string
+		protect byte
+		value   byte
+	}{
+		{"Enabled", 0x40, 0x42},
+		{"WithoutKey", 0x00, 0x00},
+		{"SectionProtected", 0x42, 0x00},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			m := newNamco163(t)
+			m.Write(0xf800, test.protect)
+			m.Write(0x6800, 0x42)
+			ExpectEq(t, m.Read(0x6800), test.value,
+				invalidPRGRAMText)
+		})
+	}
+}
+
+func Test_Namco163_IRQ(t *T) {
+	m := newNamco163(t)
+	m.Write(0x5000, 0xfd)
+	m.Write(0x5800, 0xff)
+
+	tick(m, 1)
+	expectIRQEq(t, m, false)
+	tick(m, 1)
+	expectIRQEq(t, m, true)
+	ExpectEq(t, m.Read(0x5000), 0xff)
+	ExpectEq(t, m.Read(0x5800), 0xff)
+
+	tick(m, 1)
+	ExpectEq(t, m.Read(0x5000), 0xff)
+
+	m.Write(0x5800, 0x00)
+	expectIRQEq(t, m, false)
+}
+
+func Test_Namco163_SoundRAMPort(t *T) {
+	m := newNamco163(t)
+
+	writeN163RAM(m, 0x10, 0x01, 0x02, 0x03)
+	m.Write(0xf800, 0x91)
+
+	ExpectEq(t, m.Read(0x4800), 0x02)
+	ExpectEq(t, m.Read(0x4800), 0x03)
+}
+
+func Test_Namco163_Wavetable(t *T) {
+	m := newNamco163(t)
+	writeN163RAM(m, 0x00, 0xfe, 0x00)
+	writeN163RAM(m, 0x78, 0x00, 0x00, 0x00, 0x00,
+		0xfc, 0x00, 0x00, 0x0f)
+
+	tick(m, 15)
+	expectN163LevelEq(t, m, 6*15)
+
+	writeN163RAM(m, 0x7c, 0xfd)
+	tick(m, 15)
+	expectN163LevelEq(t, m, 7*15)
+	tick(m, 15)
+	expectN163LevelEq(t, m, -8*15)
+
+	m.Write(0xe000, 0x40)
+	expectAudioEq(t, m, 0)
+}
+
+func Test_Namco163_TimeMultiplexChannels(t *T) {
+	m := newNamco163(t)
+	writeN163RAM(m, 0x00, 0xff)
+	writeN163RAM(m, 0x70, 0x00, 0x00, 0x00, 0x00,
+		0xfc, 0x00, 0x00, 0x0f)
+	writeN163RAM(m, 0x78, 0x00, 0x00, 0x00, 0x00,
+		0xfc, 0x00, 0x02, 0x1f)
+
+	tick(m, 15)
+	expectN163LevelEq(t, m, -8*15)
+	tick(m, 15)
+	expectN163LevelEq(t, m, 7*15)
+	tick(m, 15)
+	expectN163LevelEq(t, m, -8*15)
+}
@@ -0,0 +1,375 @@
+package mapper
+
+import "math"
+
+const (
+	opllChannels     = 6
+	opllSampleCycles = 36
+	opllPhaseScale   = 1 << 19
+	opllSineSize     = 1024
+
+	opllCustomEnd   = 0x08
+	opllFNumLow     = 0x10
+	opllControl     = 0x20
+	opllInstrument  = 0x30
+	opllChannelMask = 0x0f
+
+	opllSustain  = 0x20
+	opllKeyOn    = 0x10
+	opllBlock    = 0x0e
+	opllFNumHigh = 0x01
+
+	opllSilence       = 48
+	opllTLStep        = 0.75
+	opllVolumeStep    = 3
+	opllSustainStep   = 3
+	opllOctaveLevel   = 6
+	opllRateScale     = 1.9e-4
+	opllAttackScale   = 8
+	opllSustainRate   = 5
+	opllReleaseRate   = 7
+	opllModulation    = 4
+	opllFeedback      = 2
+	opllAMDepth       = 4.8
+	opllAMFrequency   = 3.7
+	opllVibratoCents  = 7
+	opllVibratoFreq   = 6.4
+	opllCentsInOctave = 1200
+	opllMixScale      = 0.1
+
+	opllSampleRate = 1789773.0 / opllSampleCycles
+)
+
+const (
+	envelopeOff = iota
+	envelopeAttack
+	envelopeDecay
+	envelopeSustain
+	envelopeRelease
+)
+
+// VRC7 has its own set of 15 built-in instruments
+var vrc7Patches = [15][8]byte{
+	{0x03, 0x21, 0x05, 0x06, 0xe8, 0x81, 0x42, 0x27},
+	{0x13, 0x41, 0x14, 0x0d, 0xd8, 0xf6, 0x23, 0x12},
+	{0x11, 0x11, 0x08, 0x08, 0xfa, 0xb2, 0x20, 0x12},
+	{0x31, 0x61, 0x0c, 0x07, 0xa8, 0x64, 0x61, 0x27},
+	{0x32, 0x21, 0x1e, 0x06, 0xe1, 0x76, 0x01, 0x28},
+	{0x02, 0x01, 0x06, 0x00, 0xa3, 0xe2, 0xf4, 0xf4},
+	{0x21, 0x61, 0x1d, 0x07, 0x82, 0x81, 0x11, 0x07},
+	{0x23, 0x21, 0x22, 0x17, 0xa2, 0x72, 0x01, 0x17},
+	{0x35, 0x11, 0x25, 0x00, 0x40, 0x73, 0x72, 0x01},
+	{0xb5, 0x01, 0x0f, 0x0f, 0xa8, 0xa5, 0x51, 0x02},
+	{0x17, 0xc1, 0x24, 0x07, 0xf8, 0xf8, 0x22, 0x12},
+	{0x71, 0x23, 0x11, 0x06, 0x65, 0x74, 0x18, 0x16},
+	{0x01, 0x02, 0xd3, 0x05, 0xc9, 0x95, 0x03, 0x02},
+	{0x61, 0x63, 0x0c, 0x00, 0x94, 0xc0, 0x33, 0xf6},
+	{0x21, 0x72, 0x0d, 0x00, 0xc1, 0xd5, 0x56, 0x06},
+}
+
+var opllMultipliers = [16]float64{
+	0.5, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 10, 12, 12, 15, 15,
+}
+
+// level drop at block 7 in dB for 6 dB per octave
+var opllKSLLevels = [16]float64{
+	0, 9, 12, 13.875, 15, 16.125, 16.875, 17.625,
+	18, 18.75, 19.125, 19.5, 19.875, 20.25, 20.625, 21,
+}
+
+var opllKSLScales = [4]float64{0, 0.25, 0.5, 1}
+
+var opllSine = newOPLLSine()
+
+func newOPLLSine() [opllSineSize]float64 {
+	var sine [opllSineSize]float64
+	for i := range sine {
+		sine[i] = math.Sin(2 * math.Pi * float64(i) / opllSineSize)
+	}
+	return sine
+}
+
+type opllSlot struct {
+	isAM        bool
+	isVibrato   bool
+	isSustained bool
+	isKSR       bool
+	isRectified bool
+	multiplier  float64
+	ksl         byte
+	level       float64
+	attack      byte
+	decay       byte
+	sustain     byte
+	release     byte
+}
+
+type opllPatch struct {
+	slots    [2]opllSlot
+	feedback byte
+}
+
+func decodeOPLLPatch(data [8]byte) opllPatch {
+	var p opllPatch
+	for i := range p.slots {
+		s := &p.slots[i]
+		s.isAM = data[i]&0x80 != 0
+		s.isVibrato = data[i]&0x40 != 0
+		s.isSustained = data[i]&0x20 != 0
+		s.isKSR = data[i]&0x10 != 0
+		s.multiplier = opllMultipliers[data[i]&0x0f]
+		s.ksl = data[2+i] >> 6
+		s.attack, s.decay = data[4+i]>>4, data[4+i]&0x0f
+		s.sustain, s.release = data[6+i]>>4, data[6+i]&0x0f
+	}
+	p.slots[0].level = float64(data[2]&0x3f) * opllTLStep
+	p.slots[0].isRectified = data[3]&0x08 != 0
+	p.slots[1].isRectified = data[3]&0x10 != 0
+	p.feedback = data[3] & 0x07
+	return p
+}
+
+type opllOperator struct {
+	phase       float64
+	attenuation float64
+	state       int
+	output      float64
+}
+
+func (o *opllOperator) keyOn() {
+	if o.state == envelopeOff {
+		o.attenuation = opllSilence
+	}
+	o.phase, o.state = 0, envelopeAttack
+}
+
+func (o *opllOperator) keyOff() {
+	if o.state != envelopeOff {
+		o.state = envelopeRelease
+	}
+}
+
+func (o *opllOperator) updateEnvelope(
+	s *opllSlot, c *opllChannel) {
+	switch o.state {
+	case envelopeAttack:
+		o.attenuation -= opllAttackScale *
+			c.getRateStep(s.attack, s)
+		if s.attack == 0x0f || o.attenuation <= 0 {
+			o.attenuation, o.state = 0, envelopeDecay
+		}
+	case envelopeDecay:
+		o.attenuation += c.getRateStep(s.decay, s)
+		level := float64(s.sustain) * opllSustainStep
+		if o.attenuation >= level {
+			o.attenuation, o.state = level, envelopeSustain
+		}
+	case envelopeSustain:
+		if !s.isSustained {
+			o.attenuation += c.getRateStep(s.release, s)
+		}
+	case envelopeRelease:
+		o.attenuation += c.getRateStep(c.getReleaseRate(s), s)
+	}
+	if o.attenuation >= opllSilence {
+		o.attenuation, o.state = opllSilence, envelopeOff
+	}
+}
+
+func (o *opllOperator) render(s *opllSlot, c *opllChannel,
+	l *opllLFO, attenuation, modulation float64) float64 {
+	o.updateEnvelope(s, c)
+	o.phase += c.getPhaseStep(s, l)
+	o.phase -= math.Floor(o.phase)
+	if o.state == envelopeOff {
+		o.output = 0
+		return 0
+	}
+	attenuation += o.attenuation + c.getKSL(s)
+	if s.isAM {
+		attenuation += l.am
+	}
+	phase := o.phase + modulation
+	phase -= math.Floor(phase)
+	wave := opllSine[int(phase*opllSineSize)%opllSineSize]
+	if s.isRectified && wave < 0 {
+		wave = 0
+	}
+	o.output = wave * math.Pow(10, -attenuation/20)
+	return o.output
+}
+
+type opllLFO struct {
+	amPhase      float64
+	vibratoPhase float64
+	am           float64
+	vibrato      float64
+}
+
+func (l *opllLFO) clock() {
+	l.amPhase = math.Mod(l.amPhase+
+		opllAMFrequency/opllSampleRate, 1)
+	l.vibratoPhase = math.Mod(l.vibratoPhase+
+		opllVibratoFreq/opllSampleRate, 1)
+	l.am = opllAMDepth * (1 - math.Abs(2*l.amPhase-1))
+	cents := opllVibratoCents *
+		math.Sin(2*math.Pi*l.vibratoPhase)
+	l.vibrato = math.Pow(2, cents/opllCentsInOctave)
+}
+
+type opllChannel struct {
+	fnum        uint16
+	block       byte
+	isKeyOn     bool
+	isSustain   bool
+	instrument  byte
+	volume      byte
+	operators   [2]opllOperator
+	lastOutputs [2]float64
+}
+
+func (c *opllChannel) writeControl(value byte) {
+	c.fnum = c.fnum&0xff | uint16(value&opllFNumHigh)<<8
+	c.block = value & opllBlock >> 1
+	c.isSustain = value&opllSustain != 0
+	isKeyOn := value&opllKeyOn != 0
+	for i := range c.operators {
+		if isKeyOn && !c.isKeyOn {
+			c.operators[i].keyOn()
+		} else if !isKeyOn {
+			c.operators[i].keyOff()
+		}
+	}
+	c.isKeyOn = isKeyOn
+}
+
+// envelope rates double every 4 steps of effective rate,
+// higher notes get faster rates with KSR
+func (c *opllChannel) getRateStep(rate byte, s *opllSlot) float64 {
+	if rate == 0 {
+		return 0
+	}
+	scaling := int(c.block)<<1 | int(c.fnum>>8)
+	if !s.isKSR {
+		scaling >>= 2
+	}
+	effective := int(rate)*4 + scaling
+	if effective > 63 {
+		effective = 63
+	}
+	power := float64(4+effective&3) / 4 *
+		float64(int(1)<<(effective>>2))
+	return power * opllRateScale
+}
+
+func (c *opllChannel) getReleaseRate(s *opllSlot) byte {
+	switch {
+	case c.isSustain:
+		return opllSustainRate
+	case s.isSustained:
+		return s.release
+	}
+	return opllReleaseRate
+}
+
+func (c *opllChannel) getPhaseStep(
+	s *opllSlot, l *opllLFO) float64 {
+	step := float64(uint32(c.fnum)<<c.block) * s.multiplier /
+		opllPhaseScale
+	if s.isVibrato {
+		step *= l.vibrato
+	}
+	return step
+}
+
+func (c *opllChannel) getKSL(s *opllSlot) float64 {
+	level := opllKSLLevels[c.fnum>>5] -
+		opllOctaveLevel*float64(7-c.block)
+	if level < 0 {
+		return 0
+	}
+	return level * opllKSLScales[s.ksl]
+}
+
+// modulator output shifts carrier phase, modulator
+// also feeds back average of its last two outputs
+func (c *opllChannel) render(p *opllPatch, l *opllLFO) float64 {
+	var feedback float64
+	if p.feedback != 0 {
+		average := (c.lastOutputs[0] + c.lastOutputs[1]) / 2
+		feedback = average * opllFeedback /
+			float64(int(1)<<(7-p.feedback))
+	}
+	modulator := c.operators[0].render(&p.slots[0], c, l,
+		p.slots[0].level, feedback)
+	c.lastOutputs[1], c.lastOutputs[0] =
+		c.lastOutputs[0], modulator
+	volume := float64(c.volume) * opllVolumeStep
+	return c.operators[1].render(&p.slots[1], c, l,
+		volume, modulator*opllModulation)
+}
+
+type opll struct {
+	address  byte
+	custom   [8]byte
+	channels [opllChannels]opllChannel
+	lfo      opllLFO
+	cycles   int
+	output   float32
+}
+
+func (o *opll) selectRegister(value byte) {
+	o.address = value
+}
+
+func (o *opll) writeRegister(value byte) {
+	if o.address < opllCustomEnd {
+		o.custom[o.address] = value
+		return
+	}
+	index := int(o.address & opllChannelMask)
+	if index >= opllChannels {
+		return
+	}
+	c := &o.channels[index]
+	switch o.address &^ opllChannelMask {
+	case opllFNumLow:
+		c.fnum = c.fnum&0x100 | uint16(value)
+	case opllControl:
+		c.writeControl(value)
+	case opllInstrument:
+		c.instrument, c.volume = value>>4, value&0x0f
+	}
+}
+
+func (o *opll) reset() {
+	*o = opll{}
+}
+
+func (o *opll) getPatch(instrument byte) opllPatch {
+	if instrument == 0 {
+		return decodeOPLLPatch(o.custom)
+	}
+	return decodeOPLLPatch(vrc7Patches[instrument-1])
+}
+
+// chip produces one sample every 36 CPU cycles
+func (o *opll) tick() {
+	o.cycles++
+	if o.cycles < opllSampleCycles {
+		return
+	}
+	o.cycles = 0
+	o.lfo.clock()
+	var sum float64
+	for i := range o.channels {
+		c := &o.channels[i]
+		patch := o.getPatch(c.instrument)
+		sum += c.render(&patch, &o.lfo)
+	}
+	o.output = float32(sum * opllMixScale)
+}
+
+func (o *opll) getOutput() float32 {
+	return o.output
+}
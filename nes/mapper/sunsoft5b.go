@@ -0,0 +1,208 @@
+package mapper
+
+import "math"
+
+const (
+	ayRegisterMask = 0x0f
+	ayChannels     = 3
+	ayPrescaler    = 16
+	ayNoisePeriod  = 0x1f
+	ayToneOff      = 0x01
+	ayNoiseOff     = 0x08
+	ayEnvelopeMode = 0x10
+	ayVolume       = 0x0f
+	ayNoiseSeed    = 1
+	ayNoiseTap     = 3
+	ayNoiseBits    = 16
+	ayVolumeStep   = 3
+	ayMixScale     = 0.2
+
+	envelopeHold      = 0x01
+	envelopeAlternate = 0x02
+	envelopeAttackBit = 0x04
+	envelopeContinue  = 0x08
+	envelopeSteps     = 16
+)
+
+var ayLevels = newAYLevels()
+
+// each volume step is 3 dB, zero is silence
+func newAYLevels() [16]float32 {
+	var levels [16]float32
+	for i := 1; i < len(levels); i++ {
+		db := float64(i-15) * ayVolumeStep
+		levels[i] = float32(math.Pow(10, db/20))
+	}
+	return levels
+}
+
+type ayTone struct {
+	period  uint16
+	counter uint16
+	output  bool
+}
+
+func (t *ayTone) clock() {
+	t.counter++
+	if t.counter >= t.period {
+		t.counter, t.output = 0, !t.output
+	}
+}
+
+type ayEnvelope struct {
+	period      uint16
+	counter     uint16
+	shape       byte
+	step        byte
+	isAttacking bool
+	isHolding   bool
+}
+
+func (e *ayEnvelope) setShape(shape byte) {
+	e.shape, e.step, e.counter = shape, 0, 0
+	e.isAttacking = shape&envelopeAttackBit != 0
+	e.isHolding = false
+}
+
+func (e *ayEnvelope) clock() {
+	e.counter++
+	if e.counter < e.period || e.isHolding {
+		return
+	}
+	e.counter = 0
+	e.step++
+	if e.step < envelopeSteps {
+		return
+	}
+	e.finishCycle()
+}
+
+func (e *ayEnvelope) finishCycle() {
+	switch {
+	case e.shape&envelopeContinue == 0:
+		e.step, e.isHolding, e.isAttacking =
+			envelopeSteps-1, true, false
+	case e.shape&envelopeHold != 0:
+		e.step, e.isHolding = envelopeSteps-1, true
+		e.alternate()
+	default:
+		e.step = 0
+		e.alternate()
+	}
+}
+
+func (e *ayEnvelope) alternate() {
+	if e.shape&envelopeAlternate != 0 {
+		e.isAttacking = !e.isAttacking
+	}
+}
+
+func (e *ayEnvelope) getVolume() byte {
+	if e.isAttacking {
+		return e.step
+	}
+	return envelopeSteps - 1 - e.step
+}
+
+// Sunsoft 5B contains YM2149F compatible with AY-3-8910
+type sunsoft5B struct {
+	address    byte
+	tones      [ayChannels]ayTone
+	volumes    [ayChannels]byte
+	mixer      byte
+	envelope   ayEnvelope
+	noise      uint32
+	noiseTimer ayTone
+	isNoiseOn  bool
+	prescaler  int
+}
+
+func (a *sunsoft5B) selectRegister(value byte) {
+	a.address = value
+}
+
+func (a *sunsoft5B) writeRegister(value byte) {
+	switch r := a.address & ayRegisterMask; {
+	case r < 6:
+		t := &a.tones[r/2]
+		value &= ayTimerMask(r)
+		t.period = setPeriodByte(t.period, r%2, value)
+	case r == 6:
+		a.noiseTimer.period = uint16(value & ayNoisePeriod)
+	case r == 7:
+		a.mixer = value
+	case r < 11:
+		a.volumes[r-8] = value
+	case r < 13:
+		e := &a.envelope
+		e.period = setPeriodByte(e.period, r-11, value)
+	case r == 13:
+		a.envelope.setShape(value)
+	}
+}
+
+func ayTimerMask(register byte) byte {
+	if register%2 == 0 {
+		return 0xff
+	}
+	return 0x0f
+}
+
+func setPeriodByte(period uint16, high, value byte) uint16 {
+	if high == 0 {
+		return period&0xff00 | uint16(value)
+	}
+	return period&0x00ff | uint16(value)<<8
+}
+
+func (a *sunsoft5B) tick() {
+	a.prescaler++
+	if a.prescaler < ayPrescaler {
+		return
+	}
+	a.prescaler = 0
+	for i := range a.tones {
+		a.tones[i].clock()
+	}
+	a.envelope.clock()
+	previous := a.noiseTimer.output
+	a.noiseTimer.clock()
+	if previous && !a.noiseTimer.output {
+		a.clockNoise()
+	}
+}
+
+// noise is 17-bit LFSR clocked at half of its timer rate
+func (a *sunsoft5B) clockNoise() {
+	if a.noise == 0 {
+		a.noise = ayNoiseSeed
+	}
+	bit := (a.noise ^ a.noise>>ayNoiseTap) & 0x01
+	a.noise = a.noise>>1 | bit<<ayNoiseBits
+	a.isNoiseOn = a.noise&0x01 != 0
+}
+
+func (a *sunsoft5B) getOutput() float32 {
+	var sum float32
+	for i := range a.tones {
+		if a.isChannelHigh(i) {
+			sum += ayLevels[a.getVolume(i)]
+		}
+	}
+	return sum * ayMixScale
+}
+
+func (a *sunsoft5B) isChannelHigh(channel int) bool {
+	isTone := a.tones[channel].output ||
+		a.mixer&(ayToneOff<<channel) != 0
+	isNoise := a.isNoiseOn || a.mixer&(ayNoiseOff<<channel) != 0
+	return isTone && isNoise
+}
+
+func (a *sunsoft5B) getVolume(channel int) byte {
+	volume := a.volumes[channel]
+	if volume&ayEnvelopeMode != 0 {
+		return a.envelope.getVolume()
+	}
+	return volume & ayVolume
+}
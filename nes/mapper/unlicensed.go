@@ -0,0 +1,100 @@
+package mapper
+
+import "github.com/smarkuck/nes/nes/cartridge"
+
+const (
+	lowRegisterMask   = 0xe100
+	lowRegisterAddr   = 0x4100
+	lowRegisterPRGBit = 0x01
+	ninaPRGShift      = 3
+	ninaCHRBank       = 0x07
+	sachenPRGShift    = 2
+	sachenCHRBank     = 0x03
+
+	multicartBank   = 0xff
+	prg16KBWindow   = 0x4000
+	gk192PRGBank    = 0x07
+	gk192CHRBank    = 0x07
+	gk192CHRShift   = 3
+	gk192PRG16KB    = 0x40
+	gk192Horizontal = 0x80
+)
+
+// lowRegister boards latch writes to $4100-$5FFF with A8
+// set and switch 32 KB PRG and 8 KB CHR, AVE NINA-03/06
+// and Sachen boards differ only by bit layout
+type lowRegister struct {
+	base
+	prgShift byte
+	chrMask  byte
+}
+
+func newNINA(c *cartridge.Cartridge) Mapper {
+	return &lowRegister{newBase(c, c.PRGRAMSize),
+		ninaPRGShift, ninaCHRBank}
+}
+
+func newSachen72008(c *cartridge.Cartridge) Mapper {
+	return &lowRegister{newBase(c, c.PRGRAMSize),
+		sachenPRGShift, sachenCHRBank}
+}
+
+func (l *lowRegister) Write(addr uint16, value byte) {
+	if addr&lowRegisterMask != lowRegisterAddr {
+		l.base.Write(addr, value)
+		return
+	}
+	prgBank := value >> l.prgShift & lowRegisterPRGBit
+	l.prg.set(0x0000, prgROMSize, int(prgBank))
+	l.chr.set(0x0000, chrSize, int(value&l.chrMask))
+}
+
+// multicarts latch address of the write instead of data
+type novelDiamond struct {
+	base
+}
+
+func newNovelDiamond(c *cartridge.Cartridge) Mapper {
+	return &novelDiamond{newBase(c, c.PRGRAMSize)}
+}
+
+func (n *novelDiamond) Write(addr uint16, value byte) {
+	if addr < prgROMAddr {
+		n.base.Write(addr, value)
+		return
+	}
+	bank := int(addr & multicartBank)
+	n.prg.set(0x0000, prgROMSize, bank)
+	n.chr.set(0x0000, chrSize, bank)
+}
+
+type gk192 struct {
+	base
+}
+
+func newGK192(c *cartridge.Cartridge) Mapper {
+	g := &gk192{newBase(c, c.PRGRAMSize)}
+	g.mirroring = cartridge.Vertical
+	return g
+}
+
+// 16 KB bank is mirrored in both halves of PRG window
+func (g *gk192) Write(addr uint16, value byte) {
+	if addr < prgROMAddr {
+		g.base.Write(addr, value)
+		return
+	}
+	prgBank := int(addr & gk192PRGBank)
+	if addr&gk192PRG16KB != 0 {
+		g.prg.set(0x0000, prg16KBWindow, prgBank)
+		g.prg.set(0x4000, prg16KBWindow, prgBank)
+	} else {
+		g.prg.set(0x0000, prgROMSize, prgBank>>1)
+	}
+	chrBank := addr >> gk192CHRShift & gk192CHRBank
+	g.chr.set(0x0000, chrSize, int(chrBank))
+	g.mirroring = cartridge.Vertical
+	if addr&gk192Horizontal != 0 {
+		g.mirroring = cartridge.Horizontal
+	}
+}
@@ -0,0 +1,84 @@
+package mapper_test
+
+import (
+	"github.com/smarkuck/nes/nes/cartridge"
+	. "github.com/smarkuck/unittest"
+)
+
+func Test_LowRegister(t *T) {
+	tests := []struct {
+		name   string
+		mapper uint16
+		addr   uint16
+		value  byte
+		prg    byte
+		chr    byte
+	}{
+		{"OnPowerUp_SelectFirstBanks", 79, 0x8000, 0x0f, 0, 0},
+		{"NINA_SwitchBanks", 79, 0x4100, 0x0d, 1, 5},
+		{"NINA_IgnoreA8Low", 79, 0x4000, 0x0d, 0, 0},
+		{"NINA_MirrorRegister", 146, 0x5f00, 0x0b, 1, 3},
+		{"Sachen_SwitchBanks", 133, 0x4100, 0x06, 1, 2},
+		{"Sachen_IgnoreHighBits", 133, 0x4100, 0x08, 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			m := newMapper(t, &cartridge.Cartridge{
+				PRG:    newBankedData(2, prg32KBBankSize),
+				CHR:    newBankedData(8, chr8KBBankSize),
+				Mapper: test.mapper,
+			})
+			m.Write(test.addr, test.value)
+			expectPRGEq(t, m, 0x8000, test.prg)
+			expectPRGEq(t, m, 0xffff, test.prg)
+			expectCHREq(t, m, 0x1fff, test.chr)
+		})
+	}
+}
+
+func Test_NovelDiamond(t *T) {
+	m := newMapper(t, &cartridge.Cartridge{
+		PRG:    newBankedData(4, prg32KBBankSize),
+		CHR:    newBankedData(4, chr8KBBankSize),
+		Mapper: 201,
+	})
+	expectPRGEq(t, m, 0x8000, 0)
+
+	m.Write(0x8002, 0xff)
+
+	expectPRGEq(t, m, 0x8000, 2)
+	expectPRGEq(t, m, 0xffff, 2)
+	expectCHREq(t, m, 0x0000, 2)
+}
+
+func Test_GK192(t *T) {
+	tests := []struct {
+		name      string
+		addr      uint16
+		low       byte
+		high      byte
+		chr       byte
+		mirroring cartridge.Mirroring
+	}{
+		{"OnPowerUp_SelectFirstBanks", 0x6000, 0, 1, 0,
+			cartridge.Vertical},
+		{"Switch32KB", 0x801b, 2, 3, 3, cartridge.Vertical},
+		{"Switch16KB", 0x80c5, 5, 5, 0, cartridge.Horizontal},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			m := newMapper(t, &cartridge.Cartridge{
+				PRG:    newBankedData(8, prgBankSize),
+				CHR:    newBankedData(8, chr8KBBankSize),
+				Mapper: 58,
+			})
+			m.Write(test.addr, 0)
+			expectPRGEq(t, m, 0x8000, test.low)
+			expectPRGEq(t, m, 0xc000, test.high)
+			expectCHREq(t, m, 0x0000, test.chr)
+			expectMirroringEq(t, m, test.mirroring)
+		})
+	}
+}
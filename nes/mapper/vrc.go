@@ -0,0 +1,130 @@
+package mapper
+
+import "github.com/smarkuck/nes/nes/cartridge"
+
+const (
+	vrcRegisterBlock = 0xf000
+
+	vrcIRQEnableOnAck = 0x01
+	vrcIRQEnabled     = 0x02
+	vrcIRQCycleMode   = 0x04
+	vrcPrescaler      = 341
+	vrcPrescalerStep  = 3
+)
+
+var vrcMirrorings = [...]cartridge.Mirroring{
+	cartridge.Vertical,
+	cartridge.Horizontal,
+	cartridge.SingleScreenA,
+	cartridge.SingleScreenB,
+}
+
+type vrcLines [2]uint16
+
+var (
+	vrcA0A1 = vrcLines{0x01, 0x02}
+	vrcA1A0 = vrcLines{0x02, 0x01}
+	vrcA1A2 = vrcLines{0x02, 0x04}
+	vrcA2A3 = vrcLines{0x04, 0x08}
+	vrcA3A2 = vrcLines{0x08, 0x04}
+	vrcA6A7 = vrcLines{0x40, 0x80}
+	vrcA3   = vrcLines{0x08, 0x00}
+	vrcA4   = vrcLines{0x10, 0x00}
+)
+
+// boards differ in CPU address lines wired to register select,
+// without submapper all known variants are combined
+type vrcWiring []vrcLines
+
+type vrcVariant struct {
+	mapper    uint16
+	subMapper uint8
+}
+
+var vrcWirings = map[vrcVariant]vrcWiring{
+	{21, 0}: {vrcA1A2, vrcA6A7},
+	{21, 1}: {vrcA1A2},
+	{21, 2}: {vrcA6A7},
+	{22, 0}: {vrcA1A0},
+	{23, 0}: {vrcA0A1, vrcA2A3},
+	{23, 1}: {vrcA0A1},
+	{23, 2}: {vrcA2A3},
+	{23, 3}: {vrcA0A1},
+	{24, 0}: {vrcA0A1},
+	{25, 0}: {vrcA1A0, vrcA3A2},
+	{25, 1}: {vrcA1A0},
+	{25, 2}: {vrcA3A2},
+	{25, 3}: {vrcA1A0},
+	{26, 0}: {vrcA1A0},
+	{85, 0}: {vrcA3, vrcA4},
+	{85, 1}: {vrcA3},
+	{85, 2}: {vrcA4},
+}
+
+func getVRCWiring(c *cartridge.Cartridge) vrcWiring {
+	variant := vrcVariant{c.Mapper, c.SubMapper}
+	if wiring, ok := vrcWirings[variant]; ok {
+		return wiring
+	}
+	return vrcWirings[vrcVariant{c.Mapper, 0}]
+}
+
+func (w vrcWiring) translate(addr uint16) uint16 {
+	register := addr & vrcRegisterBlock
+	for _, lines := range w {
+		if addr&lines[0] != 0 {
+			register |= 0x01
+		}
+		if addr&lines[1] != 0 {
+			register |= 0x02
+		}
+	}
+	return register
+}
+
+type vrcIRQ struct {
+	latch     byte
+	counter   byte
+	control   byte
+	prescaler int
+	isPending bool
+}
+
+func (i *vrcIRQ) writeControl(value byte) {
+	i.control, i.isPending = value, false
+	if value&vrcIRQEnabled != 0 {
+		i.counter, i.prescaler = i.latch, vrcPrescaler
+	}
+}
+
+func (i *vrcIRQ) acknowledge() {
+	i.isPending = false
+	i.control &^= vrcIRQEnabled
+	if i.control&vrcIRQEnableOnAck != 0 {
+		i.control |= vrcIRQEnabled
+	}
+}
+
+// in scanline mode prescaler divides CPU clock by 113.667
+func (i *vrcIRQ) tick() {
+	if i.control&vrcIRQEnabled == 0 {
+		return
+	}
+	if i.control&vrcIRQCycleMode != 0 {
+		i.clock()
+		return
+	}
+	i.prescaler -= vrcPrescalerStep
+	if i.prescaler <= 0 {
+		i.prescaler += vrcPrescaler
+		i.clock()
+	}
+}
+
+func (i *vrcIRQ) clock() {
+	if i.counter == 0xff {
+		i.counter, i.isPending = i.latch, true
+	} else {
+		i.counter++
+	}
+}
@@ -0,0 +1,134 @@
+package mapper
+
+import "github.com/smarkuck/nes/nes/cartridge"
+
+const (
+	vrcPRGBank     = 0x1f
+	vrc2Mirroring  = 0x01
+	vrc4Mirroring  = 0x03
+	vrc4PRGSwap    = 0x02
+	vrc4PRGSwapped = 0x4000
+	vrc2CHRHigh    = 0x0f
+	vrc4CHRHigh    = 0x1f
+	vrcNibble      = 0x0f
+
+	vrc2SubMapper = 3
+)
+
+type vrc24 struct {
+	base
+	wiring    vrcWiring
+	isVRC2    bool
+	isVRC2a   bool
+	prgBanks  [2]byte
+	chrBanks  [8]int
+	isPRGSwap bool
+	irq       vrcIRQ
+}
+
+func newVRC24(c *cartridge.Cartridge) Mapper {
+	m := &vrc24{
+		base:    newBase(c, getPRGRAMSize(c)),
+		wiring:  getVRCWiring(c),
+		isVRC2:  c.Mapper == 22 || c.SubMapper == vrc2SubMapper,
+		isVRC2a: c.Mapper == 22,
+	}
+	m.mirroring = cartridge.Vertical
+	m.updateBanks()
+	return m
+}
+
+func (m *vrc24) Write(addr uint16, value byte) {
+	if addr < prgROMAddr {
+		m.base.Write(addr, value)
+		return
+	}
+	register := m.wiring.translate(addr)
+	switch register & vrcRegisterBlock {
+	case 0x8000:
+		m.prgBanks[0] = value & vrcPRGBank
+	case 0x9000:
+		m.writeControl(register, value)
+	case 0xa000:
+		m.prgBanks[1] = value & vrcPRGBank
+	case 0xf000:
+		m.writeIRQ(register, value)
+		return
+	default:
+		m.writeCHRBank(register, value)
+	}
+	m.updateBanks()
+}
+
+func (m *vrc24) writeControl(register uint16, value byte) {
+	switch {
+	case m.isVRC2:
+		m.mirroring = vrcMirrorings[value&vrc2Mirroring]
+	case register&0x02 == 0:
+		m.mirroring = vrcMirrorings[value&vrc4Mirroring]
+	default:
+		m.isPRGSwap = value&vrc4PRGSwap != 0
+	}
+}
+
+// each 1 KB bank number is written in two nibbles
+func (m *vrc24) writeCHRBank(register uint16, value byte) {
+	index := int(register>>12-0xb)*2 + int(register&0x02)>>1
+	bank := m.chrBanks[index]
+	if register&0x01 == 0 {
+		bank = bank&^vrcNibble | int(value&vrcNibble)
+	} else {
+		bank = bank&vrcNibble | int(value&m.getCHRHigh())<<4
+	}
+	m.chrBanks[index] = bank
+}
+
+func (m *vrc24) getCHRHigh() byte {
+	if m.isVRC2 {
+		return vrc2CHRHigh
+	}
+	return vrc4CHRHigh
+}
+
+func (m *vrc24) writeIRQ(register uint16, value byte) {
+	if m.isVRC2 {
+		return
+	}
+	irq := &m.irq
+	switch register & 0x03 {
+	case 0:
+		irq.latch = irq.latch&0xf0 | value&vrcNibble
+	case 1:
+		irq.latch = irq.latch&vrcNibble | value<<4
+	case 2:
+		irq.writeControl(value)
+	case 3:
+		irq.acknowledge()
+	}
+}
+
+func (m *vrc24) updateBanks() {
+	var swap int
+	if m.isPRGSwap {
+		swap = vrc4PRGSwapped
+	}
+	m.prg.set(0x0000^swap, 0x2000, int(m.prgBanks[0]))
+	m.prg.set(0x2000, 0x2000, int(m.prgBanks[1]))
+	m.prg.set(0x4000^swap, 0x2000, -2)
+	m.prg.set(0x6000, 0x2000, -1)
+	for i, bank := range m.chrBanks {
+		// VRC2a ignores the lowest bit of CHR bank number
+		if m.isVRC2a {
+			bank >>= 1
+		}
+		m.chr.set(i*chrWindow, chrWindow, bank)
+	}
+}
+
+func (m *vrc24) IsIRQ() bool {
+	return m.irq.isPending
+}
+
+func (m *vrc24) Tick() {
+	m.irq.tick()
+}
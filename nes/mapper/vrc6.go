@@ -0,0 +1,131 @@
+package mapper
+
+import "github.com/smarkuck/nes/nes/cartridge"
+
+const (
+	vrc6PRG16KBBank = 0x0f
+	vrc6CHRMode     = 0x03
+	vrc6Mirroring   = 0x0c
+	vrc6MirrorShift = 2
+	vrc6RAMEnabled  = 0x80
+)
+
+type vrc6 struct {
+	base
+	wiring   vrcWiring
+	prgBanks [2]byte
+	chrBanks [8]byte
+	ppuMode  byte
+	irq      vrcIRQ
+	audio    vrc6Audio
+}
+
+func newVRC6(c *cartridge.Cartridge) Mapper {
+	m := &vrc6{
+		base:   newBase(c, getPRGRAMSize(c)),
+		wiring: getVRCWiring(c),
+	}
+	m.updateBanks()
+	return m
+}
+
+func (m *vrc6) Read(addr uint16) byte {
+	if addr < prgROMAddr && m.ppuMode&vrc6RAMEnabled == 0 {
+		return 0
+	}
+	return m.base.Read(addr)
+}
+
+func (m *vrc6) Write(addr uint16, value byte) {
+	if addr < prgROMAddr {
+		m.writePRGRAM(addr, value)
+		return
+	}
+	register := m.wiring.translate(addr)
+	index := register & 0x03
+	switch register & vrcRegisterBlock {
+	case 0x8000:
+		m.prgBanks[0] = value & vrc6PRG16KBBank
+	case 0xb000:
+		m.writeSawOrMode(index, value)
+	case 0xc000:
+		m.prgBanks[1] = value & vrcPRGBank
+	case 0xd000:
+		m.chrBanks[index] = value
+	case 0xe000:
+		m.chrBanks[4+index] = value
+	case 0xf000:
+		m.writeIRQ(index, value)
+	default:
+		m.audio.write(register, value)
+	}
+	m.updateBanks()
+}
+
+func (m *vrc6) writePRGRAM(addr uint16, value byte) {
+	if m.ppuMode&vrc6RAMEnabled != 0 {
+		m.base.Write(addr, value)
+	}
+}
+
+func (m *vrc6) writeSawOrMode(index uint16, value byte) {
+	if index == 3 {
+		m.ppuMode = value
+	} else {
+		m.audio.write(0xb000|index, value)
+	}
+}
+
+func (m *vrc6) writeIRQ(index uint16, value byte) {
+	switch index {
+	case 0:
+		m.irq.latch = value
+	case 1:
+		m.irq.writeControl(value)
+	case 2:
+		m.irq.acknowledge()
+	}
+}
+
+func (m *vrc6) updateBanks() {
+	m.prg.set(0x0000, 0x4000, int(m.prgBanks[0]))
+	m.prg.set(0x4000, 0x2000, int(m.prgBanks[1]))
+	m.prg.set(0x6000, 0x2000, -1)
+	m.updateCHRBanks()
+	mirroring := m.ppuMode & vrc6Mirroring >> vrc6MirrorShift
+	m.mirroring = vrcMirrorings[mirroring]
+}
+
+// only modes used by released games are supported,
+// in 2 KB banks the lowest bit comes from PPU A10
+func (m *vrc6) updateCHRBanks() {
+	switch m.ppuMode & vrc6CHRMode {
+	case 0:
+		for i, bank := range m.chrBanks {
+			m.chr.set(i*0x0400, 0x0400, int(bank))
+		}
+	case 1:
+		for i, bank := range m.chrBanks[:4] {
+			m.chr.set(i*0x0800, 0x0800, int(bank>>1))
+		}
+	default:
+		for i, bank := range m.chrBanks[:4] {
+			m.chr.set(i*0x0400, 0x0400, int(bank))
+		}
+		m.chr.set(0x1000, 0x0800, int(m.chrBanks[4]>>1))
+		m.chr.set(0x1800, 0x0800, int(m.chrBanks[5]>>1))
+	}
+}
+
+func (m *vrc6) IsIRQ() bool {
+	return m.irq.isPending
+}
+
+func (m *vrc6) GetAudioOutput() float32 {
+	return m.audio.getOutput()
+}
+
+func (m *vrc6) Tick() {
+	m.irq.tick()
+	m.audio.tick()
+}
@@ -0,0 +1,169 @@
+package mapper
+
+const (
+	vrc6Halt     = 0x01
+	vrc6Shift4   = 0x02
+	vrc6Shift8   = 0x04
+	vrc6Enabled  = 0x80
+	vrc6PeriodHi = 0x0f
+
+	vrc6PulseMode   = 0x80
+	vrc6PulseDuty   = 0x70
+	vrc6DutyShift   = 4
+	vrc6PulseVolume = 0x0f
+	vrc6PulseSteps  = 16
+
+	vrc6SawRate  = 0x3f
+	vrc6SawSteps = 14
+	vrc6SawShift = 3
+	vrc6MixScale = 0.00996
+)
+
+type vrc6Timer struct {
+	period    uint16
+	timer     uint16
+	isEnabled bool
+}
+
+func (t *vrc6Timer) write(register uint16, value byte) {
+	switch register {
+	case 1:
+		t.period = t.period&0x0f00 | uint16(value)
+	case 2:
+		t.period = t.period&0x00ff |
+			uint16(value&vrc6PeriodHi)<<8
+		t.isEnabled = value&vrc6Enabled != 0
+	}
+}
+
+func (t *vrc6Timer) clock(shift uint) bool {
+	if !t.isEnabled {
+		return false
+	}
+	if t.timer > 0 {
+		t.timer--
+		return false
+	}
+	t.timer = t.period >> shift
+	return true
+}
+
+type vrc6Pulse struct {
+	vrc6Timer
+	volume     byte
+	duty       byte
+	step       byte
+	isConstant bool
+}
+
+func (p *vrc6Pulse) write(register uint16, value byte) {
+	if register == 0 {
+		p.isConstant = value&vrc6PulseMode != 0
+		p.duty = value & vrc6PulseDuty >> vrc6DutyShift
+		p.volume = value & vrc6PulseVolume
+		return
+	}
+	p.vrc6Timer.write(register, value)
+	if !p.isEnabled {
+		p.step = 0
+	}
+}
+
+func (p *vrc6Pulse) clock(shift uint) {
+	if p.vrc6Timer.clock(shift) {
+		p.step = (p.step + 1) % vrc6PulseSteps
+	}
+}
+
+func (p *vrc6Pulse) getOutput() byte {
+	if !p.isEnabled {
+		return 0
+	}
+	if p.isConstant || p.step <= p.duty {
+		return p.volume
+	}
+	return 0
+}
+
+// accumulator grows on every other step and is cleared
+// after seven additions
+type vrc6Saw struct {
+	vrc6Timer
+	rate        byte
+	accumulator byte
+	step        byte
+}
+
+func (s *vrc6Saw) write(register uint16, value byte) {
+	if register == 0 {
+		s.rate = value & vrc6SawRate
+		return
+	}
+	s.vrc6Timer.write(register, value)
+	if !s.isEnabled {
+		s.accumulator, s.step = 0, 0
+	}
+}
+
+func (s *vrc6Saw) clock(shift uint) {
+	if !s.vrc6Timer.clock(shift) {
+		return
+	}
+	s.step++
+	switch {
+	case s.step == vrc6SawSteps:
+		s.accumulator, s.step = 0, 0
+	case s.step%2 == 0:
+		s.accumulator += s.rate
+	}
+}
+
+func (s *vrc6Saw) getOutput() byte {
+	return s.accumulator >> vrc6SawShift
+}
+
+type vrc6Audio struct {
+	pulses    [2]vrc6Pulse
+	saw       vrc6Saw
+	frequency byte
+}
+
+func (a *vrc6Audio) write(addr uint16, value byte) {
+	register := addr & 0x03
+	switch {
+	case addr == 0x9003:
+		a.frequency = value
+	case addr < 0xa000:
+		a.pulses[0].write(register, value)
+	case addr < 0xb000:
+		a.pulses[1].write(register, value)
+	default:
+		a.saw.write(register, value)
+	}
+}
+
+func (a *vrc6Audio) tick() {
+	if a.frequency&vrc6Halt != 0 {
+		return
+	}
+	shift := a.getShift()
+	a.pulses[0].clock(shift)
+	a.pulses[1].clock(shift)
+	a.saw.clock(shift)
+}
+
+func (a *vrc6Audio) getShift() uint {
+	switch {
+	case a.frequency&vrc6Shift8 != 0:
+		return 8
+	case a.frequency&vrc6Shift4 != 0:
+		return 4
+	}
+	return 0
+}
+
+func (a *vrc6Audio) getOutput() float32 {
+	sum := a.pulses[0].getOutput() + a.pulses[1].getOutput() +
+		a.saw.getOutput()
+	return float32(sum) * vrc6MixScale
+}
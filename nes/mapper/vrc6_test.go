@@ -0,0 +1,143 @@
+package mapper_test
+
+import (
+	"github.com/smarkuck/nes/nes/cartridge"
+	. "github.com/smarkuck/nes/nes/mapper"
+	. "github.com/smarkuck/unittest"
+)
+
+const vrc6MixScale = 0.00996
+
+func expectVRC6LevelEq(t *T, m Mapper, level byte) {
+	expectAudioEq(t, m, float32(level)*vrc6MixScale)
+}
+
+func Test_VRC6_Banks(t *T) {
+	tests := []struct {
+		name   string
+		mapper uint16
+		reg1   uint16
+		reg2   uint16
+	}{
+		{"VRC6a", 24, 0x01, 0x02},
+		{"VRC6b", 26, 0x02, 0x01},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			m := newVRC(t, test.mapper, 0)
+			m.Write(0x8000, 2)
+			m.Write(0xc000, 9)
+			m.Write(0xd000|test.reg1, 11)
+			m.Write(0xe000|test.reg2|test.reg1, 22)
+			expectPRGEq(t, m, 0x8000, 4)
+			expectPRGEq(t, m, 0xa000, 5)
+			expectPRGEq(t, m, 0xc000, 9)
+			expectPRGEq(t, m, 0xe000, 15)
+			expectCHREq(t, m, 0x0400, 11)
+			expectCHREq(t, m, 0x1c00, 22)
+		})
+	}
+}
+
+func Test_VRC6_PPUModes(t *T) {
+	tests := []struct {
+		name      string
+		mode      byte
+		banks     [8]byte
+		mirroring cartridge.Mirroring
+	}{
+		{"1KB", 0x20, [8]byte{1, 2, 3, 4, 5, 6, 7, 8},
+			cartridge.Vertical},
+		{"2KB", 0x25, [8]byte{0, 1, 2, 3, 2, 3, 4, 5},
+			cartridge.Horizontal},
+		{"Mixed", 0x2a, [8]byte{1, 2, 3, 4, 4, 5, 6, 7},
+			cartridge.SingleScreenA},
+		{"MixedAlt", 0x2f, [8]byte{1, 2, 3, 4, 4, 5, 6, 7},
+			cartridge.SingleScreenB},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			m := newVRC(t, 24, 0)
+			writeVRC6CHRBanks(m, 1, 2, 3, 4, 5, 6, 7, 8)
+			m.Write(0xb003, test.mode)
+			for i, bank := range test.banks {
+				addr := uint16(i) * chr1KBBankSize
+				expectCHREq(t, m, addr, bank)
+			}
+			expectMirroringEq(t, m, test.mirroring)
+		})
+	}
+}
+
+func writeVRC6CHRBanks(m Mapper, banks ...byte) {
+	for i, bank := range banks {
+		addr := 0xd000 + uint16(i/4)*0x1000 + uint16(i%4)
+		m.Write(addr, bank)
+	}
+}
+
+func Test_VRC6_PRGRAMEnable(t *T) {
+	m := newVRC(t, 24, 0)
+
+	m.Write(0x6000, 0x42)
+	ExpectEq(t, m.Read(0x6000), 0x00, invalidPRGRAMText)
+
+	m.Write(0xb003, 0x80)
+	m.Write(0x6000, 0x42)
+	ExpectEq(t, m.Read(0x6000), 0x42, invalidPRGRAMText)
+}
+
+func Test_VRC6_IRQ(t *T) {
+	m := newVRC(t, 26, 0)
+	m.Write(0xf000, 0xfe)
+	m.Write(0xf002, 0x07)
+
+	tick(m, 1)
+	expectIRQEq(t, m, false)
+	tick(m, 1)
+	expectIRQEq(t, m, true)
+
+	m.Write(0xf001, 0x00)
+	expectIRQEq(t, m, false)
+}
+
+func Test_VRC6_Pulse(t *T) {
+	m := newVRC(t, 24, 0)
+	m.Write(0x9000, 0x1f)
+	m.Write(0x9002, 0x80)
+
+	expectVRC6LevelEq(t, m, 15)
+	tick(m, 1)
+	expectVRC6LevelEq(t, m, 15)
+	tick(m, 1)
+	expectVRC6LevelEq(t, m, 0)
+
+	m.Write(0x9000, 0x8f)
+	expectVRC6LevelEq(t, m, 15)
+}
+
+func Test_VRC6_Saw(t *T) {
+	m := newVRC(t, 24, 0)
+	m.Write(0xb000, 0x20)
+	m.Write(0xb002, 0x80)
+
+	tick(m, 2)
+	expectVRC6LevelEq(t, m, 4)
+	tick(m, 10)
+	expectVRC6LevelEq(t, m, 24)
+	tick(m, 2)
+	expectVRC6LevelEq(t, m, 0)
+}
+
+func Test_VRC6_HaltAudio(t *T) {
+	m := newVRC(t, 24, 0)
+	m.Write(0x9003, 0x01)
+	m.Write(0xa000, 0x0f)
+	m.Write(0xa002, 0x80)
+
+	tick(m, 8)
+
+	expectVRC6LevelEq(t, m, 15)
+}
@@ -0,0 +1,131 @@
+package mapper
+
+import "github.com/smarkuck/nes/nes/cartridge"
+
+const (
+	vrc7PRGBank    = 0x3f
+	vrc7Mirroring  = 0x03
+	vrc7AudioReset = 0x40
+	vrc7RAMEnabled = 0x80
+
+	vrc7AudioPort   = 0x0030
+	vrc7AudioSelect = 0x0010
+	vrc7AudioData   = 0x0030
+)
+
+type vrc7 struct {
+	base
+	wiring   vrcWiring
+	prgBanks [3]byte
+	chrBanks [8]byte
+	control  byte
+	irq      vrcIRQ
+	audio    opll
+}
+
+func newVRC7(c *cartridge.Cartridge) Mapper {
+	m := &vrc7{
+		base:   newBase(c, getPRGRAMSize(c)),
+		wiring: getVRCWiring(c),
+	}
+	m.updateBanks()
+	return m
+}
+
+func (m *vrc7) Read(addr uint16) byte {
+	if addr < prgROMAddr && m.control&vrc7RAMEnabled == 0 {
+		return 0
+	}
+	return m.base.Read(addr)
+}
+
+func (m *vrc7) Write(addr uint16, value byte) {
+	if addr < prgROMAddr {
+		m.writePRGRAM(addr, value)
+		return
+	}
+	register := m.wiring.translate(addr)
+	index := register & 0x01
+	switch block := register & vrcRegisterBlock; block {
+	case 0x8000:
+		m.prgBanks[index] = value & vrc7PRGBank
+	case 0x9000:
+		m.writeAudio(addr, index, value)
+	case 0xe000:
+		m.writeControl(index, value)
+	case 0xf000:
+		m.writeIRQ(index, value)
+	default:
+		m.chrBanks[int(block>>12-0xa)*2+int(index)] = value
+	}
+	m.updateBanks()
+}
+
+func (m *vrc7) writePRGRAM(addr uint16, value byte) {
+	if m.control&vrc7RAMEnabled != 0 {
+		m.base.Write(addr, value)
+	}
+}
+
+// sound chip ports are decoded from A4 and A5
+// regardless of board wiring
+func (m *vrc7) writeAudio(addr, index uint16, value byte) {
+	switch addr & vrc7AudioPort {
+	case vrc7AudioSelect:
+		m.audio.selectRegister(value)
+	case vrc7AudioData:
+		m.audio.writeRegister(value)
+	default:
+		if index == 0 {
+			m.prgBanks[2] = value & vrc7PRGBank
+		}
+	}
+}
+
+func (m *vrc7) writeControl(index uint16, value byte) {
+	if index == 1 {
+		m.irq.latch = value
+		return
+	}
+	m.control = value
+	m.mirroring = vrcMirrorings[value&vrc7Mirroring]
+	if value&vrc7AudioReset != 0 {
+		m.audio.reset()
+	}
+}
+
+func (m *vrc7) writeIRQ(index uint16, value byte) {
+	if index == 0 {
+		m.irq.writeControl(value)
+	} else {
+		m.irq.acknowledge()
+	}
+}
+
+func (m *vrc7) updateBanks() {
+	for i, bank := range m.prgBanks {
+		m.prg.set(i*0x2000, 0x2000, int(bank))
+	}
+	m.prg.set(0x6000, 0x2000, -1)
+	for i, bank := range m.chrBanks {
+		m.chr.set(i*chrWindow, chrWindow, int(bank))
+	}
+}
+
+func (m *vrc7) IsIRQ() bool {
+	return m.irq.isPending
+}
+
+func (m *vrc7) GetAudioOutput() float32 {
+	if m.control&vrc7AudioReset != 0 {
+		return 0
+	}
+	return m.audio.getOutput()
+}
+
+func (m *vrc7) Tick() {
+	m.irq.tick()
+	if m.control&vrc7AudioReset == 0 {
+		m.audio.tick()
+	}
+}
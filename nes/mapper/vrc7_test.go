@@ -0,0 +1,149 @@
+package mapper_test
+
+import (
+	"github.com/smarkuck/nes/nes"
+	"github.com/smarkuck/nes/nes/cartridge"
+	. "github.com/smarkuck/nes/nes/mapper"
+	. "github.com/smarkuck/unittest"
+)
+
+const (
+	vrc7a = 2
+	vrc7b = 1
+
+	opllSampleCycles = 36
+)
+
+func writeOPLL(m Mapper, register, value byte) {
+	m.Write(0x9010, register)
+	m.Write(0x9030, value)
+}
+
+func playOPLLNote(m Mapper, instrument byte) {
+	writeOPLL(m, 0x30, instrument<<4)
+	writeOPLL(m, 0x10, 0xac)
+	writeOPLL(m, 0x20, 0x18)
+}
+
+func getAudioPeak(m Mapper, samples int) float32 {
+	var peak float32
+	for i := 0; i < samples; i++ {
+		tick(m, opllSampleCycles)
+		output := m.(nes.AudioSource).GetAudioOutput()
+		if output < 0 {
+			output = -output
+		}
+		if output > peak {
+			peak = output
+		}
+	}
+	return peak
+}
+
+func Test_VRC7_Banks(t *T) {
+	tests := []struct {
+		name      string
+		subMapper uint8
+		reg       uint16
+	}{
+		{"VRC7a", vrc7a, 0x10},
+		{"VRC7b", vrc7b, 0x08},
+		{"Unknown", 0, 0x10},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			m := newVRC(t, 85, test.subMapper)
+			m.Write(0x8000, 1)
+			m.Write(0x8000|test.reg, 2)
+			m.Write(0x9000, 3)
+			for i := uint16(0); i < 8; i++ {
+				addr := 0xa000 + i/2*0x1000 + i%2*test.reg
+				m.Write(addr, byte(4+i))
+			}
+			expectPRGEq(t, m, 0x8000, 1)
+			expectPRGEq(t, m, 0xa000, 2)
+			expectPRGEq(t, m, 0xc000, 3)
+			expectPRGEq(t, m, 0xe000, 15)
+			for i := uint16(0); i < 8; i++ {
+				expectCHREq(t, m, i*chr1KBBankSize, byte(4+i))
+			}
+		})
+	}
+}
+
+func Test_VRC7_Control(t *T) {
+	m := newVRC(t, 85, vrc7a)
+	m.Write(0x6000, 0x42)
+	ExpectEq(t, m.Read(0x6000), 0x00, invalidPRGRAMText)
+
+	m.Write(0xe000, 0x81)
+	m.Write(0x6000, 0x42)
+
+	ExpectEq(t, m.Read(0x6000), 0x42, invalidPRGRAMText)
+	expectMirroringEq(t, m, cartridge.Horizontal)
+}
+
+func Test_VRC7_IRQ(t *T) {
+	m := newVRC(t, 85, vrc7a)
+	m.Write(0xe010, 0xff)
+	m.Write(0xf000, 0x06)
+
+	tick(m, 1)
+	expectIRQEq(t, m, true)
+
+	m.Write(0xf010, 0x00)
+	expectIRQEq(t, m, false)
+}
+
+func Test_VRC7_WithoutKeyOn_OutputSilence(t *T) {
+	m := newVRC(t, 85, vrc7a)
+	writeOPLL(m, 0x30, 0x10)
+	writeOPLL(m, 0x10, 0xac)
+
+	ExpectEq(t, getAudioPeak(m, 100), 0, invalidAudioText)
+}
+
+func Test_VRC7_PlayInstruments(t *T) {
+	for instrument := byte(1); instrument < 16; instrument++ {
+		m := newVRC(t, 85, vrc7a)
+		playOPLLNote(m, instrument)
+		ExpectTrue(t, getAudioPeak(m, 1000) > 0.01,
+			invalidAudioText)
+	}
+}
+
+func Test_VRC7_PlayCustomInstrument(t *T) {
+	m := newVRC(t, 85, vrc7a)
+	patch := []byte{0x01, 0x01, 0x00, 0x00, 0xf0, 0xf0, 0x0f, 0x0f}
+	for i, value := range patch {
+		writeOPLL(m, byte(i), value)
+	}
+
+	playOPLLNote(m, 0)
+
+	ExpectTrue(t, getAudioPeak(m, 200) > 0.09, invalidAudioText)
+}
+
+func Test_VRC7_AfterKeyOff_Release(t *T) {
+	m := newVRC(t, 85, vrc7a)
+	playOPLLNote(m, 3)
+	getAudioPeak(m, 1000)
+
+	writeOPLL(m, 0x20, 0x08)
+	getAudioPeak(m, 20000)
+
+	ExpectEq(t, getAudioPeak(m, 100), 0, invalidAudioText)
+}
+
+func Test_VRC7_AudioReset_Silence(t *T) {
+	m := newVRC(t, 85, vrc7a)
+	playOPLLNote(m, 3)
+	getAudioPeak(m, 1000)
+
+	m.Write(0xe000, 0x40)
+	ExpectEq(t, getAudioPeak(m, 100), 0, invalidAudioText)
+
+	m.Write(0xe000, 0x00)
+	ExpectEq(t, getAudioPeak(m, 100), 0, invalidAudioText)
+}
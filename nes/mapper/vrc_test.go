@@ -0,0 +1,138 @@
+package mapper_test
+
+import (
+	"github.com/smarkuck/nes/nes/cartridge"
+	. "github.com/smarkuck/nes/nes/mapper"
+	. "github.com/smarkuck/unittest"
+)
+
+func newVRC(t *T, mapper uint16, subMapper uint8) Mapper {
+	return newMapper(t, &cartridge.Cartridge{
+		PRG:       newBankedData(16, prg8KBBankSize),
+		CHR:       newBankedData(64, chr1KBBankSize),
+		Mapper:    mapper,
+		SubMapper: subMapper,
+	})
+}
+
+func tick(m Mapper, cycles int) {
+	for i := 0; i < cycles; i++ {
+		m.Tick()
+	}
+}
+
+func Test_VRC4_AddressLineWiring(t *T) {
+	tests := []struct {
+		name      string
+		mapper    uint16
+		subMapper uint8
+		reg1      uint16
+		reg2      uint16
+	}{
+		{"VRC4a", 21, 1, 0x02, 0x04},
+		{"VRC4c", 21, 2, 0x40, 0x80},
+		{"VRC4ac", 21, 0, 0x40, 0x04},
+		{"VRC4f", 23, 1, 0x01, 0x02},
+		{"VRC4e", 23, 2, 0x04, 0x08},
+		{"VRC4ef", 23, 0, 0x01, 0x08},
+		{"VRC4b", 25, 1, 0x02, 0x01},
+		{"VRC4d", 25, 2, 0x08, 0x04},
+		{"VRC4bd", 25, 0, 0x02, 0x04},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			m := newVRC(t, test.mapper, test.subMapper)
+			m.Write(0x8000, 3)
+			m.Write(0x9000|test.reg2, 0x02)
+			m.Write(0xb000, 0x05)
+			m.Write(0xb000|test.reg1, 0x02)
+			m.Write(0xb000|test.reg2, 0x07)
+			expectPRGEq(t, m, 0x8000, 14)
+			expectPRGEq(t, m, 0xc000, 3)
+			expectCHREq(t, m, 0x0000, 0x25%64)
+			expectCHREq(t, m, 0x0400, 0x07)
+		})
+	}
+}
+
+func Test_VRC2a_IgnoresLowestCHRBit(t *T) {
+	m := newVRC(t, 22, 0)
+
+	m.Write(0xb000, 0x0b)
+	m.Write(0xb002, 0x01)
+
+	expectCHREq(t, m, 0x0000, 0x0d)
+}
+
+func Test_VRC_Mirroring(t *T) {
+	tests := []struct {
+		name      string
+		mapper    uint16
+		subMapper uint8
+		mirroring cartridge.Mirroring
+	}{
+		{"VRC2", 23, 3, cartridge.Horizontal},
+		{"VRC4", 23, 1, cartridge.SingleScreenB},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			m := newVRC(t, test.mapper, test.subMapper)
+			expectMirroringEq(t, m, cartridge.Vertical)
+			m.Write(0x9000, 0x03)
+			expectMirroringEq(t, m, test.mirroring)
+		})
+	}
+}
+
+func Test_VRC4_CycleIRQ(t *T) {
+	m := newVRC(t, 23, 1)
+	m.Write(0xf000, 0x0d)
+	m.Write(0xf001, 0x0f)
+	m.Write(0xf002, 0x07)
+
+	tick(m, 2)
+	expectIRQEq(t, m, false)
+	tick(m, 1)
+	expectIRQEq(t, m, true)
+
+	m.Write(0xf003, 0x00)
+	expectIRQEq(t, m, false)
+	tick(m, 3)
+	expectIRQEq(t, m, true)
+}
+
+func Test_VRC4_ScanlineIRQ(t *T) {
+	m := newVRC(t, 23, 1)
+	m.Write(0xf000, 0x0f)
+	m.Write(0xf001, 0x0f)
+	m.Write(0xf002, 0x02)
+
+	tick(m, 113)
+	expectIRQEq(t, m, false)
+	tick(m, 1)
+	expectIRQEq(t, m, true)
+}
+
+func Test_VRC4_AcknowledgeWithoutReenable_StopIRQ(t *T) {
+	m := newVRC(t, 23, 1)
+	m.Write(0xf001, 0x0f)
+	m.Write(0xf000, 0x0f)
+	m.Write(0xf002, 0x06)
+	tick(m, 1)
+
+	m.Write(0xf003, 0x00)
+	tick(m, 512)
+
+	expectIRQEq(t, m, false)
+}
+
+func Test_VRC2_HasNoIRQ(t *T) {
+	m := newVRC(t, 22, 0)
+
+	m.Write(0xf002, 0x06)
+	tick(m, 512)
+
+	expectIRQEq(t, m, false)
+}
@@ -0,0 +1,206 @@
+package nsf
+
+import (
+	"github.com/smarkuck/nes/nes"
+	"github.com/smarkuck/nes/nes/apu"
+	"github.com/smarkuck/nes/nes/cartridge"
+	"github.com/smarkuck/nes/nes/fds"
+	"github.com/smarkuck/nes/nes/mapper"
+)
+
+const (
+	ramSize      = 0x0800
+	ramMirror    = 0x2000
+	memoryAddr   = 0x6000
+	memorySize   = 0xa000
+	romAddr      = 0x8000
+	bankSize     = 0x1000
+	bankMask     = 0x0fff
+	bankAddr     = 0x5ff8
+	fdsBankAddr  = 0x5ff6
+	apuLastAddr  = 0x4017
+	driverAddr   = 0x3fe0
+	fdsSoundOn   = 0x02
+	chipROMSize  = 0x8000
+	fdsBIOSSize  = 0x2000
+	fdsImageSize = 65500
+)
+
+type addrRange struct {
+	start, end uint16
+}
+
+func (r addrRange) contains(addr uint16) bool {
+	return addr >= r.start && addr <= r.end
+}
+
+type soundChip interface {
+	nes.Bus
+	nes.AudioSource
+	Tick()
+}
+
+type expansion struct {
+	soundChip
+	writes []addrRange
+	reads  []addrRange
+}
+
+type register struct {
+	addr  uint16
+	value byte
+}
+
+type chipInfo struct {
+	chip   byte
+	mapper uint16
+	writes []addrRange
+	reads  []addrRange
+	setup  []register
+}
+
+// expansion chips reuse mapper implementations, only
+// their sound registers are wired to the bus, MMC5 ExRAM
+// is used as plain RAM by players. Fixed order keeps
+// mixing and so rendered audio reproducible
+var chipInfos = []chipInfo{
+	{VRC6, 24, []addrRange{{0x9000, 0xb002}}, nil, nil},
+	{VRC7, 85, []addrRange{{0x9010, 0x9010}, {0x9030, 0x9030}},
+		nil, nil},
+	{MMC5, 5, []addrRange{{0x5000, 0x5015}, {0x5205, 0x5206},
+		{0x5c00, 0x5ff5}}, []addrRange{{0x5205, 0x5206},
+		{0x5c00, 0x5ff5}}, []register{{0x5104, 0x02}}},
+	{Namco163, 19, []addrRange{{0x4800, 0x4fff},
+		{0xf800, 0xffff}}, []addrRange{{0x4800, 0x4fff}}, nil},
+	{Sunsoft5B, 69, []addrRange{{0xc000, 0xffff}}, nil, nil},
+}
+
+var fdsRanges = []addrRange{{0x4040, 0x4092}}
+
+type bus struct {
+	ram        [ramSize]byte
+	memory     [memorySize]byte
+	rom        []byte
+	driver     []byte
+	apu        apu.APU
+	expansions []expansion
+	isFDS      bool
+}
+
+func newBus(n *NSF) *bus {
+	b := &bus{isFDS: n.Chips&FDS != 0}
+	b.apu = apu.NewAPU(b, n.Region)
+	b.loadData(n)
+	for _, info := range chipInfos {
+		if n.Chips&info.chip != 0 {
+			b.expansions = append(b.expansions, newExpansion(info))
+		}
+	}
+	if b.isFDS {
+		b.expansions = append(b.expansions, newFDSExpansion())
+	}
+	return b
+}
+
+func newExpansion(info chipInfo) expansion {
+	m, _ := mapper.New(&cartridge.Cartridge{
+		PRG:    make([]byte, chipROMSize),
+		Mapper: info.mapper,
+	})
+	for _, r := range info.setup {
+		m.Write(r.addr, r.value)
+	}
+	return expansion{m.(soundChip), info.writes, info.reads}
+}
+
+func newFDSExpansion() expansion {
+	d, _ := fds.LoadDisk(make([]byte, fdsImageSize), nil)
+	f, _ := fds.New(make([]byte, fdsBIOSSize), d)
+	f.Write(0x4023, fdsSoundOn)
+	return expansion{f, fdsRanges, fdsRanges}
+}
+
+// banked data is aligned to 4KB banks by load address
+func (b *bus) loadData(n *NSF) {
+	if !n.IsBanked() {
+		b.rom = make([]byte, memorySize)
+		copy(b.rom[n.LoadAddr-memoryAddr:], n.Data)
+		copy(b.memory[:], b.rom)
+		return
+	}
+	padding := int(n.LoadAddr & bankMask)
+	b.rom = append(make([]byte, padding), n.Data...)
+	for i, bank := range n.Banks {
+		b.switchBank(bankAddr+uint16(i), bank)
+	}
+	if b.isFDS {
+		b.switchBank(fdsBankAddr, n.Banks[6])
+		b.switchBank(fdsBankAddr+1, n.Banks[7])
+	}
+}
+
+func (b *bus) switchBank(addr uint16, bank byte) {
+	window := int(addr-fdsBankAddr) * bankSize
+	start := int(bank) * bankSize
+	dst := b.memory[window : window+bankSize]
+	for i := range dst {
+		dst[i] = 0
+	}
+	if start < len(b.rom) {
+		copy(dst, b.rom[start:])
+	}
+}
+
+func (b *bus) Read(addr uint16) byte {
+	switch {
+	case addr < ramMirror:
+		return b.ram[addr%ramSize]
+	case addr >= driverAddr && addr < driverAddr+uint16(len(b.driver)):
+		return b.driver[addr-driverAddr]
+	case addr <= apuLastAddr:
+		return b.apu.Read(addr)
+	case addr >= memoryAddr:
+		return b.memory[addr-memoryAddr]
+	}
+	for _, e := range b.expansions {
+		if isInRanges(e.reads, addr) {
+			return e.Read(addr)
+		}
+	}
+	return 0
+}
+
+func (b *bus) Write(addr uint16, value byte) {
+	for _, e := range b.expansions {
+		if isInRanges(e.writes, addr) {
+			e.Write(addr, value)
+		}
+	}
+	switch {
+	case addr < ramMirror:
+		b.ram[addr%ramSize] = value
+	case addr <= apuLastAddr:
+		b.apu.Write(addr, value)
+	case addr >= bankAddr && addr < memoryAddr,
+		addr >= fdsBankAddr && addr < bankAddr && b.isFDS:
+		b.switchBank(addr, value)
+	case addr >= memoryAddr && (addr < romAddr || b.isFDS):
+		b.memory[addr-memoryAddr] = value
+	}
+}
+
+func (b *bus) tick() {
+	b.apu.Tick()
+	for _, e := range b.expansions {
+		e.Tick()
+	}
+}
+
+func isInRanges(ranges []addrRange, addr uint16) bool {
+	for _, r := range ranges {
+		if r.contains(addr) {
+			return true
+		}
+	}
+	return false
+}
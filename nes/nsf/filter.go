@@ -0,0 +1,21 @@
+package nsf
+
+import "math"
+
+type highPass struct {
+	alpha      float32
+	prevInput  float32
+	prevOutput float32
+}
+
+func newHighPass(cutoff, sampleRate int) highPass {
+	rc := 1 / (2 * math.Pi * float64(cutoff))
+	dt := 1 / float64(sampleRate)
+	return highPass{alpha: float32(rc / (rc + dt))}
+}
+
+func (h *highPass) filter(input float32) float32 {
+	h.prevOutput = h.alpha * (h.prevOutput + input - h.prevInput)
+	h.prevInput = input
+	return h.prevOutput
+}
@@ -0,0 +1,157 @@
+package nsf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/smarkuck/nes/nes"
+)
+
+const (
+	nsfMagic      = "NESM\x1a"
+	nsfHeaderSize = 0x80
+	nsfStringSize = 32
+	bankCount     = 8
+
+	defaultSpeed    = 16639
+	defaultPALSpeed = 19997
+	palTune         = 0x01
+	dualRegionTune  = 0x02
+
+	invalidHeaderText = "invalid NSF header"
+	truncatedText     = "truncated NSF file"
+	invalidSongFormat = "invalid song %d, file has %d songs"
+)
+
+const (
+	VRC6 = 1 << iota
+	VRC7
+	FDS
+	MMC5
+	Namco163
+	Sunsoft5B
+)
+
+const UnknownDuration time.Duration = -1
+
+var (
+	errInvalidHeader = errors.New(invalidHeaderText)
+	errTruncated     = errors.New(truncatedText)
+)
+
+type Track struct {
+	Name     string
+	Duration time.Duration
+	Fade     time.Duration
+}
+
+type NSF struct {
+	Name      string
+	Artist    string
+	Copyright string
+	Ripper    string
+	LoadAddr  uint16
+	InitAddr  uint16
+	PlayAddr  uint16
+	StartSong int
+	Banks     [bankCount]byte
+	// play routine periods in microseconds
+	Speed    uint16
+	PALSpeed uint16
+	// dual region tunes can be played in both regions
+	Region       nes.Region
+	IsDualRegion bool
+	Chips        byte
+	Data         []byte
+	Tracks       []Track
+}
+
+// Load reads NSF or NSFe file, songs are numbered from 0
+func Load(data []byte) (*NSF, error) {
+	if bytes.HasPrefix(data, []byte(nsfeMagic)) {
+		return loadNSFe(data)
+	}
+	return loadNSF(data)
+}
+
+func loadNSF(data []byte) (*NSF, error) {
+	if !bytes.HasPrefix(data, []byte(nsfMagic)) {
+		return nil, errInvalidHeader
+	}
+	if len(data) <= nsfHeaderSize {
+		return nil, errTruncated
+	}
+	n := &NSF{
+		Name:      readString(data[0x0e : 0x0e+nsfStringSize]),
+		Artist:    readString(data[0x2e : 0x2e+nsfStringSize]),
+		Copyright: readString(data[0x4e : 0x4e+nsfStringSize]),
+		LoadAddr:  readWord(data[0x08:]),
+		InitAddr:  readWord(data[0x0a:]),
+		PlayAddr:  readWord(data[0x0c:]),
+		StartSong: int(data[0x07]) - 1,
+		Speed:     readWord(data[0x6e:]),
+		PALSpeed:  readWord(data[0x78:]),
+		Chips:     data[0x7b],
+		Data:      data[nsfHeaderSize:],
+		Tracks:    newTracks(int(data[0x06])),
+	}
+	copy(n.Banks[:], data[0x70:0x78])
+	n.setRegion(data[0x7a])
+	return n, n.validate()
+}
+
+func (n *NSF) setRegion(flags byte) {
+	n.Region = nes.NTSC
+	if flags&palTune != 0 {
+		n.Region = nes.PAL
+	}
+	n.IsDualRegion = flags&dualRegionTune != 0
+}
+
+func newTracks(count int) []Track {
+	tracks := make([]Track, count)
+	for i := range tracks {
+		tracks[i].Duration = UnknownDuration
+		tracks[i].Fade = UnknownDuration
+	}
+	return tracks
+}
+
+func (n *NSF) validate() error {
+	if len(n.Tracks) == 0 || n.StartSong < 0 ||
+		n.StartSong >= len(n.Tracks) || n.LoadAddr < memoryAddr {
+		return errInvalidHeader
+	}
+	if n.Speed == 0 {
+		n.Speed = defaultSpeed
+	}
+	if n.PALSpeed == 0 {
+		n.PALSpeed = defaultPALSpeed
+	}
+	return nil
+}
+
+func (n *NSF) IsBanked() bool {
+	return n.Banks != [bankCount]byte{}
+}
+
+func (n *NSF) checkSong(song int) error {
+	if song < 0 || song >= len(n.Tracks) {
+		return fmt.Errorf(invalidSongFormat, song, len(n.Tracks))
+	}
+	return nil
+}
+
+func readWord(data []byte) uint16 {
+	return uint16(data[0]) | uint16(data[1])<<8
+}
+
+func readString(data []byte) string {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		data = data[:i]
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,119 @@
+package nsf_test
+
+import (
+	"encoding/binary"
+
+	"github.com/smarkuck/nes/nes"
+	. "github.com/smarkuck/nes/nes/nsf"
+	. "github.com/smarkuck/unittest"
+)
+
+const (
+	headerSize = 0x80
+
+	invalidErrorText = "invalid error"
+	invalidNSFText   = "invalid NSF"
+)
+
+type header struct {
+	songs, start     byte
+	load, init, play uint16
+	speed, palSpeed  uint16
+	banks            [8]byte
+	region, chips    byte
+}
+
+var defaultHeader = header{
+	songs: 3, start: 2, load: 0x8000, init: 0x8000,
+	play: 0x8020, speed: 16666,
+}
+
+func newNSF(h header, data ...byte) []byte {
+	file := make([]byte, headerSize)
+	copy(file, "NESM\x1a\x01")
+	file[0x06], file[0x07] = h.songs, h.start
+	binary.LittleEndian.PutUint16(file[0x08:], h.load)
+	binary.LittleEndian.PutUint16(file[0x0a:], h.init)
+	binary.LittleEndian.PutUint16(file[0x0c:], h.play)
+	copy(file[0x0e:], "Song")
+	copy(file[0x2e:], "Artist")
+	copy(file[0x4e:], "2024 Copyright")
+	binary.LittleEndian.PutUint16(file[0x6e:], h.speed)
+	copy(file[0x70:], h.banks[:])
+	binary.LittleEndian.PutUint16(file[0x78:], h.palSpeed)
+	file[0x7a], file[0x7b] = h.region, h.chips
+	return append(file, data...)
+}
+
+func Test_NSF_LoadHeader(t *T) {
+	h := defaultHeader
+	h.banks = [8]byte{0, 1}
+	h.chips = VRC6 | Namco163
+	h.palSpeed, h.region = 20000, 0x01
+	n, err := Load(newNSF(h, 1, 2, 3))
+
+	ExpectTrue(t, err == nil, invalidErrorText)
+	ExpectEq(t, n.Name, "Song", invalidNSFText)
+	ExpectEq(t, n.Artist, "Artist", invalidNSFText)
+	ExpectEq(t, n.Copyright, "2024 Copyright", invalidNSFText)
+	ExpectEq(t, n.LoadAddr, 0x8000, invalidNSFText)
+	ExpectEq(t, n.InitAddr, 0x8000, invalidNSFText)
+	ExpectEq(t, n.PlayAddr, 0x8020, invalidNSFText)
+	ExpectEq(t, n.StartSong, 1, invalidNSFText)
+	ExpectEq(t, n.Speed, 16666, invalidNSFText)
+	ExpectEq(t, n.PALSpeed, 20000, invalidNSFText)
+	ExpectEq(t, n.Region, nes.PAL, invalidNSFText)
+	ExpectFalse(t, n.IsDualRegion, invalidNSFText)
+	ExpectEq(t, n.Chips, VRC6|Namco163, invalidNSFText)
+	ExpectEq(t, n.Banks, h.banks, invalidNSFText)
+	ExpectDeepEq(t, n.Data, []byte{1, 2, 3}, invalidNSFText)
+	ExpectTrue(t, n.IsBanked(), invalidNSFText)
+	ExpectEq(t, len(n.Tracks), 3, invalidNSFText)
+}
+
+func Test_NSF_TracksHaveUnknownDuration(t *T) {
+	n, _ := Load(newNSF(defaultHeader, 0x60))
+
+	for _, track := range n.Tracks {
+		ExpectEq(t, track.Duration, UnknownDuration, invalidNSFText)
+		ExpectEq(t, track.Fade, UnknownDuration, invalidNSFText)
+	}
+}
+
+func Test_NSF_WhenSpeedZero_UseNTSCRate(t *T) {
+	h := defaultHeader
+	h.speed = 0
+	n, _ := Load(newNSF(h, 0x60))
+
+	ExpectEq(t, n.Speed, 16639, invalidNSFText)
+	ExpectEq(t, n.PALSpeed, 19997, invalidNSFText)
+	ExpectEq(t, n.Region, nes.NTSC, invalidNSFText)
+	ExpectFalse(t, n.IsBanked(), invalidNSFText)
+}
+
+func Test_NSF_WhenFileInvalid_ReturnError(t *T) {
+	noSongs, badStart, badLoad := defaultHeader, defaultHeader,
+		defaultHeader
+	noSongs.songs = 0
+	badStart.start = 4
+	badLoad.load = 0x5000
+	tests := map[string]struct {
+		file []byte
+		err  string
+	}{
+		"magic":     {[]byte("NESM\x00"), "invalid NSF header"},
+		"truncated": {newNSF(defaultHeader), "truncated NSF file"},
+		"no songs":  {newNSF(noSongs, 0x60), "invalid NSF header"},
+		"start":     {newNSF(badStart, 0x60), "invalid NSF header"},
+		"load":      {newNSF(badLoad, 0x60), "invalid NSF header"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *T) {
+			_, err := Load(test.file)
+
+			ExpectTrue(t, err != nil, invalidErrorText)
+			ExpectEq(t, err.Error(), test.err, invalidErrorText)
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package nsf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+const (
+	nsfeMagic     = "NSFE"
+	chunkIDSize   = 4
+	chunkHeadSize = 8
+	minInfoSize   = 8
+
+	missingChunkFormat = "missing NSFe chunk %q"
+	unknownChunkFormat = "unknown required NSFe chunk %q"
+)
+
+type chunkParser = func(n *NSF, data []byte)
+
+// chunks starting with capital letter must be understood
+// by the player, other ones can be skipped
+var chunkParsers = map[string]chunkParser{
+	"INFO": parseInfo,
+	"DATA": func(n *NSF, data []byte) { n.Data = data },
+	"BANK": func(n *NSF, data []byte) { copy(n.Banks[:], data) },
+	"RATE": parseRate,
+	"NEND": func(*NSF, []byte) {},
+	"auth": parseAuthors,
+	"tlbl": parseTrackNames,
+	"time": func(n *NSF, data []byte) {
+		parseDurations(n, data, func(t *Track) *time.Duration {
+			return &t.Duration
+		})
+	},
+	"fade": func(n *NSF, data []byte) {
+		parseDurations(n, data, func(t *Track) *time.Duration {
+			return &t.Fade
+		})
+	},
+}
+
+func loadNSFe(data []byte) (*NSF, error) {
+	chunks, err := readChunks(data[len(nsfeMagic):])
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range []string{"INFO", "DATA"} {
+		if _, ok := chunks[id]; !ok {
+			return nil, fmt.Errorf(missingChunkFormat, id)
+		}
+	}
+	if len(chunks["INFO"]) < minInfoSize {
+		return nil, errInvalidHeader
+	}
+	n := new(NSF)
+	// INFO defines number of tracks used by other chunks
+	parseInfo(n, chunks["INFO"])
+	for id, chunk := range chunks {
+		chunkParsers[id](n, chunk)
+	}
+	return n, n.validate()
+}
+
+func readChunks(data []byte) (map[string][]byte, error) {
+	chunks := make(map[string][]byte)
+	for len(data) >= chunkHeadSize {
+		size := int(binary.LittleEndian.Uint32(data))
+		id := string(data[4:chunkHeadSize])
+		data = data[chunkHeadSize:]
+		if size > len(data) {
+			return nil, errTruncated
+		}
+		if _, ok := chunkParsers[id]; ok {
+			chunks[id] = data[:size]
+		} else if isRequired(id) {
+			return nil, fmt.Errorf(unknownChunkFormat, id)
+		}
+		data = data[size:]
+		if id == "NEND" {
+			break
+		}
+	}
+	return chunks, nil
+}
+
+func isRequired(id string) bool {
+	return id[0] >= 'A' && id[0] <= 'Z'
+}
+
+func parseInfo(n *NSF, data []byte) {
+	n.LoadAddr = readWord(data[0:])
+	n.InitAddr = readWord(data[2:])
+	n.PlayAddr = readWord(data[4:])
+	n.setRegion(data[6])
+	n.Chips = data[7]
+	songs := 1
+	if len(data) > 8 {
+		songs = int(data[8])
+	}
+	if len(data) > 9 {
+		n.StartSong = int(data[9])
+	}
+	if len(n.Tracks) != songs {
+		n.Tracks = newTracks(songs)
+	}
+}
+
+func parseRate(n *NSF, data []byte) {
+	if len(data) >= 2 {
+		n.Speed = readWord(data)
+	}
+	if len(data) >= 4 {
+		n.PALSpeed = readWord(data[2:])
+	}
+}
+
+func parseAuthors(n *NSF, data []byte) {
+	fields := []*string{&n.Name, &n.Artist, &n.Copyright, &n.Ripper}
+	for i, s := range splitStrings(data) {
+		if i < len(fields) {
+			*fields[i] = s
+		}
+	}
+}
+
+func parseTrackNames(n *NSF, data []byte) {
+	for i, s := range splitStrings(data) {
+		if i < len(n.Tracks) {
+			n.Tracks[i].Name = s
+		}
+	}
+}
+
+func parseDurations(n *NSF, data []byte,
+	field func(*Track) *time.Duration) {
+	for i := 0; i < len(n.Tracks) && len(data) >= 4; i++ {
+		ms := int32(binary.LittleEndian.Uint32(data))
+		if ms >= 0 {
+			*field(&n.Tracks[i]) = time.Duration(ms) * time.Millisecond
+		}
+		data = data[4:]
+	}
+}
+
+func splitStrings(data []byte) []string {
+	data = bytes.TrimSuffix(data, []byte{0})
+	var result []string
+	for _, s := range bytes.Split(data, []byte{0}) {
+		result = append(result, string(s))
+	}
+	return result
+}
@@ -0,0 +1,124 @@
+package nsf_test
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/smarkuck/nes/nes"
+	. "github.com/smarkuck/nes/nes/nsf"
+	. "github.com/smarkuck/unittest"
+)
+
+var info = []byte{
+	0x00, 0x80, 0x00, 0x80, 0x10, 0x80, 0x03, FDS, 2, 1,
+}
+
+func newNSFe(chunks ...[]byte) []byte {
+	file := []byte("NSFE")
+	for _, c := range chunks {
+		file = append(file, c...)
+	}
+	return file
+}
+
+func chunk(id string, data ...byte) []byte {
+	c := make([]byte, 4, 8+len(data))
+	binary.LittleEndian.PutUint32(c, uint32(len(data)))
+	return append(append(c, id...), data...)
+}
+
+func millis(values ...int32) []byte {
+	data := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(data[4*i:], uint32(v))
+	}
+	return data
+}
+
+func Test_NSFe_LoadChunks(t *T) {
+	n, err := Load(newNSFe(
+		chunk("INFO", info...),
+		chunk("DATA", 0x60),
+		chunk("BANK", 3, 4),
+		chunk("RATE", 0x1a, 0x41, 0x1d, 0x4e),
+		chunk("auth", []byte("Song\x00Artist\x00Copy\x00Ripper\x00")...),
+		chunk("tlbl", []byte("First\x00Second\x00")...),
+		chunk("time", millis(1500, -1)...),
+		chunk("fade", millis(-1, 2000)...),
+		chunk("NEND"),
+	))
+
+	ExpectTrue(t, err == nil, invalidErrorText)
+	ExpectEq(t, n.Name, "Song", invalidNSFText)
+	ExpectEq(t, n.Artist, "Artist", invalidNSFText)
+	ExpectEq(t, n.Copyright, "Copy", invalidNSFText)
+	ExpectEq(t, n.Ripper, "Ripper", invalidNSFText)
+	ExpectEq(t, n.LoadAddr, 0x8000, invalidNSFText)
+	ExpectEq(t, n.InitAddr, 0x8000, invalidNSFText)
+	ExpectEq(t, n.PlayAddr, 0x8010, invalidNSFText)
+	ExpectEq(t, n.Chips, FDS, invalidNSFText)
+	ExpectEq(t, n.StartSong, 1, invalidNSFText)
+	ExpectEq(t, n.Speed, 0x411a, invalidNSFText)
+	ExpectEq(t, n.PALSpeed, 0x4e1d, invalidNSFText)
+	ExpectEq(t, n.Region, nes.PAL, invalidNSFText)
+	ExpectTrue(t, n.IsDualRegion, invalidNSFText)
+	ExpectEq(t, n.Banks, [8]byte{3, 4}, invalidNSFText)
+	ExpectDeepEq(t, n.Data, []byte{0x60}, invalidNSFText)
+	ExpectDeepEq(t, n.Tracks, []Track{
+		{"First", 1500 * time.Millisecond, UnknownDuration},
+		{"Second", UnknownDuration, 2 * time.Second},
+	}, invalidNSFText)
+}
+
+func Test_NSFe_SkipUnknownOptionalChunks(t *T) {
+	n, err := Load(newNSFe(
+		chunk("INFO", info[:8]...),
+		chunk("text", 'a'),
+		chunk("DATA", 0x60),
+	))
+
+	ExpectTrue(t, err == nil, invalidErrorText)
+	ExpectEq(t, len(n.Tracks), 1, invalidNSFText)
+	ExpectEq(t, n.Speed, 16639, invalidNSFText)
+	ExpectEq(t, n.PALSpeed, 19997, invalidNSFText)
+}
+
+func Test_NSFe_IgnoreDataAfterEnd(t *T) {
+	n, err := Load(newNSFe(
+		chunk("INFO", info...),
+		chunk("DATA", 0x60),
+		chunk("NEND"),
+		chunk("DATA", 0x40),
+	))
+
+	ExpectTrue(t, err == nil, invalidErrorText)
+	ExpectDeepEq(t, n.Data, []byte{0x60}, invalidNSFText)
+}
+
+func Test_NSFe_WhenFileInvalid_ReturnError(t *T) {
+	tests := map[string]struct {
+		file []byte
+		err  string
+	}{
+		"no info": {newNSFe(chunk("DATA", 0x60)),
+			`missing NSFe chunk "INFO"`},
+		"no data": {newNSFe(chunk("INFO", info...)),
+			`missing NSFe chunk "DATA"`},
+		"short info": {newNSFe(chunk("INFO", info[:7]...),
+			chunk("DATA", 0x60)), "invalid NSF header"},
+		"unknown": {newNSFe(chunk("INFO", info...),
+			chunk("DATA", 0x60), chunk("VRC7", 1)),
+			`unknown required NSFe chunk "VRC7"`},
+		"truncated": {newNSFe(chunk("INFO", info...))[:12],
+			"truncated NSF file"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *T) {
+			_, err := Load(test.file)
+
+			ExpectTrue(t, err != nil, invalidErrorText)
+			ExpectEq(t, err.Error(), test.err, invalidErrorText)
+		})
+	}
+}
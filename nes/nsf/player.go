@@ -0,0 +1,132 @@
+package nsf
+
+import (
+	"github.com/smarkuck/nes/nes"
+	"github.com/smarkuck/nes/nes/apu"
+	"github.com/smarkuck/nes/nes/cpu"
+)
+
+const (
+	microsecond = 1e-6
+
+	lda       = 0xa9
+	ldx       = 0xa2
+	jsr       = 0x20
+	jmp       = 0x4c
+	ntscFlag  = 0x00
+	palFlag   = 0x01
+	idleAddr  = driverAddr + 7
+	playEntry = driverAddr + 10
+
+	apuStatus     = 0x4015
+	apuFrame      = 0x4017
+	apuChannelsOn = 0x0f
+	irqInhibit    = 0x40
+
+	// output stage of the console removes DC offset
+	highPassCutoff = 90
+)
+
+var cpuFrequencies = map[nes.Region]float64{
+	nes.NTSC:  1789773,
+	nes.PAL:   1662607,
+	nes.Dendy: 1773448,
+}
+
+type Player interface {
+	GetSample() float32
+}
+
+type player struct {
+	cpu             cpu.CPU
+	bus             *bus
+	mixer           apu.Mixer
+	playPeriod      float64
+	playTimer       float64
+	cyclesPerSample float64
+	sampleTimer     float64
+	highPass        highPass
+}
+
+func NewPlayer(n *NSF, song, sampleRate int) (Player, error) {
+	if err := n.checkSong(song); err != nil {
+		return nil, err
+	}
+	frequency := cpuFrequencies[n.Region]
+	p := &player{
+		bus:             newBus(n),
+		mixer:           apu.NewMixer(),
+		playPeriod:      float64(getSpeed(n)) * microsecond * frequency,
+		cyclesPerSample: frequency / float64(sampleRate),
+		highPass:        newHighPass(highPassCutoff, sampleRate),
+	}
+	for _, e := range p.bus.expansions {
+		p.mixer.AddSource(e)
+	}
+	p.bus.driver = newDriver(n, song)
+	p.cpu = cpu.NewCPU6502(p.bus)
+	p.init()
+	return p, nil
+}
+
+// Dendy plays at PAL rate
+func getSpeed(n *NSF) uint16 {
+	if n.Region == nes.NTSC {
+		return n.Speed
+	}
+	return n.PALSpeed
+}
+
+// driver loads song number and region, calls INIT or PLAY
+// with JSR and then waits in a loop for next call
+func newDriver(n *NSF, song int) []byte {
+	idle := uint16(idleAddr)
+	region := byte(palFlag)
+	if n.Region == nes.NTSC {
+		region = ntscFlag
+	}
+	return []byte{
+		lda, byte(song),
+		ldx, region,
+		jsr, byte(n.InitAddr), byte(n.InitAddr >> 8),
+		jmp, byte(idle), byte(idle >> 8),
+		jsr, byte(n.PlayAddr), byte(n.PlayAddr >> 8),
+		jmp, byte(idle), byte(idle >> 8),
+	}
+}
+
+func (p *player) init() {
+	for addr := uint16(0x4000); addr < apuStatus-1; addr++ {
+		p.bus.Write(addr, 0)
+	}
+	p.bus.Write(apuStatus, 0)
+	p.bus.Write(apuStatus, apuChannelsOn)
+	p.bus.Write(apuFrame, irqInhibit)
+	p.cpu.Jump(driverAddr)
+}
+
+func (p *player) GetSample() float32 {
+	var sum float32
+	cycles := 0
+	for p.sampleTimer += p.cyclesPerSample; p.sampleTimer >= 1; p.sampleTimer-- {
+		p.tick()
+		sum += p.mixer.Mix(p.bus.apu.GetChannels())
+		cycles++
+	}
+	if cycles == 0 {
+		return p.highPass.filter(0)
+	}
+	return p.highPass.filter(sum / float32(cycles))
+}
+
+// PLAY is skipped when previous call has not finished yet
+func (p *player) tick() {
+	if p.playTimer++; p.playTimer >= p.playPeriod {
+		p.playTimer -= p.playPeriod
+		if p.cpu.GetState().ProgramCounter == idleAddr {
+			p.cpu.Jump(playEntry)
+		}
+	}
+	p.cpu.Tick()
+	p.bus.tick()
+}
@@ -0,0 +1,171 @@
+package nsf_test
+
+import (
+	"math"
+
+	"github.com/smarkuck/nes/nes"
+	. "github.com/smarkuck/nes/nes/nsf"
+	. "github.com/smarkuck/unittest"
+)
+
+const (
+	sampleRate   = 44100
+	settleTime   = 2000
+	measureTime  = 2000
+	silenceLevel = 0.001
+	toneLevel    = 0.05
+
+	invalidSampleText = "invalid sample"
+)
+
+// init plays tone only for song 1, play always plays it
+var program = []byte{
+	0xc9, 0x01, // $8000 CMP #1
+	0xd0, 0x03, //       BNE +3
+	0x20, 0x30, 0x80, // JSR $8030
+	0x60, //             RTS
+}
+
+var pulseTone = []byte{
+	0xa9, 0xbf, 0x8d, 0x00, 0x40, // $8030 pulse 1 50% duty
+	0xa9, 0x40, 0x8d, 0x02, 0x40, //       period low
+	0xa9, 0x08, 0x8d, 0x03, 0x40, //       period high, length
+	0x60,
+}
+
+var vrc6Tone = []byte{
+	0xa9, 0x7f, 0x8d, 0x00, 0x90, // $8030 pulse 1 50% duty
+	0xa9, 0x40, 0x8d, 0x01, 0x90, //       period low
+	0xa9, 0x80, 0x8d, 0x02, 0x90, //       enable
+	0x60,
+}
+
+var playTone = []byte{0x20, 0x30, 0x80, 0x60} // $8020
+
+func newProgram(init []byte, tone []byte) []byte {
+	data := make([]byte, 0x40)
+	copy(data, init)
+	copy(data[0x20:], playTone)
+	copy(data[0x30:], tone)
+	return data
+}
+
+func newPlayerNSF(t *T, h header, data []byte) *NSF {
+	n, err := Load(newNSF(h, data...))
+	ExpectTrue(t, err == nil, invalidErrorText)
+	return n
+}
+
+func getPeak(p Player) float64 {
+	for i := 0; i < settleTime; i++ {
+		p.GetSample()
+	}
+	peak := 0.0
+	for i := 0; i < measureTime; i++ {
+		peak = math.Max(peak, math.Abs(float64(p.GetSample())))
+	}
+	return peak
+}
+
+func Test_Player_InitSelectedSong(t *T) {
+	h := defaultHeader
+	h.play = 0x8007
+	n := newPlayerNSF(t, h, newProgram(program, pulseTone))
+	silent, _ := NewPlayer(n, 0, sampleRate)
+	tone, _ := NewPlayer(n, 1, sampleRate)
+
+	ExpectTrue(t, getPeak(silent) < silenceLevel, invalidSampleText)
+	ExpectTrue(t, getPeak(tone) > toneLevel, invalidSampleText)
+}
+
+func Test_Player_CallPlayRoutine(t *T) {
+	n := newPlayerNSF(t, defaultHeader,
+		newProgram([]byte{0x60}, pulseTone))
+	p, _ := NewPlayer(n, 0, sampleRate)
+
+	ExpectTrue(t, getPeak(p) > toneLevel, invalidSampleText)
+}
+
+func Test_Player_SwitchBanks(t *T) {
+	h := defaultHeader
+	h.banks = [8]byte{1, 0}
+	data := make([]byte, 0x1000)
+	data = append(data, newProgram(program, pulseTone)...)
+	n := newPlayerNSF(t, h, data)
+	p, _ := NewPlayer(n, 1, sampleRate)
+
+	ExpectTrue(t, getPeak(p) > toneLevel, invalidSampleText)
+}
+
+func Test_Player_MixExpansionAudio(t *T) {
+	h := defaultHeader
+	h.chips = VRC6
+	n := newPlayerNSF(t, h, newProgram(program, vrc6Tone))
+	p, _ := NewPlayer(n, 1, sampleRate)
+
+	ExpectTrue(t, getPeak(p) > toneLevel, invalidSampleText)
+}
+
+// init plays tone only when MMC5 ExRAM keeps written value
+func Test_Player_UseMMC5ExRAM(t *T) {
+	h := defaultHeader
+	h.chips, h.play = MMC5, 0x800f
+	n := newPlayerNSF(t, h, newProgram([]byte{
+		0xa9, 0x42, //       $8000 LDA #$42
+		0x8d, 0x00, 0x5c, //       STA $5C00
+		0xad, 0x00, 0x5c, //       LDA $5C00
+		0xc9, 0x42, //             CMP #$42
+		0xd0, 0x03, //             BNE +3
+		0x20, 0x30, 0x80, //       JSR $8030
+		0x60, //                   RTS
+	}, pulseTone))
+	p, _ := NewPlayer(n, 0, sampleRate)
+
+	ExpectTrue(t, getPeak(p) > toneLevel, invalidSampleText)
+}
+
+// init plays tone only when X tells PAL region
+func Test_Player_PassRegionToInit(t *T) {
+	h := defaultHeader
+	h.play = 0x8007
+	n := newPlayerNSF(t, h, newProgram([]byte{
+		0xe0, 0x01, //       $8000 CPX #1
+		0xd0, 0x03, //             BNE +3
+		0x20, 0x30, 0x80, //       JSR $8030
+		0x60, //                   RTS
+	}, pulseTone))
+	ntsc, _ := NewPlayer(n, 0, sampleRate)
+	n.Region = nes.PAL
+	pal, _ := NewPlayer(n, 0, sampleRate)
+
+	ExpectTrue(t, getPeak(ntsc) < silenceLevel, invalidSampleText)
+	ExpectTrue(t, getPeak(pal) > toneLevel, invalidSampleText)
+}
+
+// play counts calls in X and plays tone on the second one,
+// so registers must survive between calls
+func Test_Player_KeepRegistersBetweenPlayCalls(t *T) {
+	h := defaultHeader
+	h.play = 0x8001
+	n := newPlayerNSF(t, h, newProgram([]byte{
+		0x60,       //       $8000 RTS
+		0xe8,       //       $8001 INX
+		0xe0, 0x02, //             CPX #2
+		0xd0, 0x03, //             BNE +3
+		0x20, 0x30, 0x80, //       JSR $8030
+		0x60, //                   RTS
+	}, pulseTone))
+	p, _ := NewPlayer(n, 0, sampleRate)
+
+	ExpectTrue(t, getPeak(p) > toneLevel, invalidSampleText)
+}
+
+func Test_Player_WhenSongInvalid_ReturnError(t *T) {
+	n := newPlayerNSF(t, defaultHeader,
+		newProgram(program, pulseTone))
+	_, err := NewPlayer(n, 3, sampleRate)
+
+	ExpectTrue(t, err != nil, invalidErrorText)
+	ExpectEq(t, err.Error(), "invalid song 3, file has 3 songs",
+		invalidErrorText)
+}
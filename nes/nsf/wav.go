@@ -0,0 +1,95 @@
+package nsf
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+const (
+	wavHeaderSize  = 44
+	wavFormatSize  = 16
+	wavPCM         = 1
+	wavChannels    = 1
+	bitsPerSample  = 16
+	bytesPerSample = bitsPerSample / 8
+	maxAmplitude   = 32767
+
+	DefaultSampleRate = 44100
+	DefaultLength     = 2 * time.Minute
+	DefaultFade       = 8 * time.Second
+)
+
+// Options length and fade are used for tracks without
+// durations from NSFe
+type Options struct {
+	SampleRate int
+	Length     time.Duration
+	Fade       time.Duration
+}
+
+func DefaultOptions() Options {
+	return Options{DefaultSampleRate, DefaultLength, DefaultFade}
+}
+
+func WriteWAV(w io.Writer, n *NSF, song int, o Options) error {
+	p, err := NewPlayer(n, song, o.SampleRate)
+	if err != nil {
+		return err
+	}
+	length, fade := getTrackTiming(n.Tracks[song], o)
+	playSamples := toSamples(length, o.SampleRate)
+	fadeSamples := toSamples(fade, o.SampleRate)
+	bw := bufio.NewWriter(w)
+	writeWAVHeader(bw, playSamples+fadeSamples, o.SampleRate)
+	for i := 0; i < playSamples+fadeSamples; i++ {
+		volume := float32(1)
+		if i >= playSamples {
+			volume -= float32(i-playSamples) / float32(fadeSamples)
+		}
+		writeSample(bw, p.GetSample()*volume)
+	}
+	return bw.Flush()
+}
+
+func getTrackTiming(t Track, o Options) (time.Duration,
+	time.Duration) {
+	length, fade := o.Length, o.Fade
+	if t.Duration != UnknownDuration {
+		length = t.Duration
+	}
+	if t.Fade != UnknownDuration {
+		fade = t.Fade
+	}
+	return length, fade
+}
+
+func toSamples(d time.Duration, sampleRate int) int {
+	return int(d.Seconds() * float64(sampleRate))
+}
+
+func writeWAVHeader(w io.Writer, samples, sampleRate int) {
+	dataSize := uint32(samples * bytesPerSample * wavChannels)
+	header := []interface{}{
+		[]byte("RIFF"), uint32(wavHeaderSize - 8 + dataSize),
+		[]byte("WAVEfmt "), uint32(wavFormatSize),
+		uint16(wavPCM), uint16(wavChannels), uint32(sampleRate),
+		uint32(sampleRate * bytesPerSample * wavChannels),
+		uint16(bytesPerSample * wavChannels),
+		uint16(bitsPerSample), []byte("data"), dataSize,
+	}
+	for _, field := range header {
+		binary.Write(w, binary.LittleEndian, field)
+	}
+}
+
+func writeSample(w io.Writer, sample float32) {
+	value := sample * maxAmplitude
+	if value > maxAmplitude {
+		value = maxAmplitude
+	} else if value < -maxAmplitude {
+		value = -maxAmplitude
+	}
+	binary.Write(w, binary.LittleEndian, int16(value))
+}
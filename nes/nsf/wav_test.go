@@ -0,0 +1,104 @@
+package nsf_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+
+	. "github.com/smarkuck/nes/nes/nsf"
+	. "github.com/smarkuck/unittest"
+)
+
+const (
+	wavHeaderSize = 44
+	wavRate       = 8000
+
+	invalidWAVText = "invalid WAV"
+)
+
+var options = Options{wavRate, 200 * time.Millisecond,
+	100 * time.Millisecond}
+
+func writeWAV(t *T, n *NSF, o Options) []byte {
+	var b bytes.Buffer
+	err := WriteWAV(&b, n, 1, o)
+	ExpectTrue(t, err == nil, invalidErrorText)
+	return b.Bytes()
+}
+
+func getSample(wav []byte, i int) int16 {
+	return int16(binary.LittleEndian.Uint16(wav[wavHeaderSize+2*i:]))
+}
+
+func Test_WAV_WriteHeader(t *T) {
+	n := newPlayerNSF(t, defaultHeader,
+		newProgram(program, pulseTone))
+	wav := writeWAV(t, n, options)
+	u32 := binary.LittleEndian.Uint32
+	u16 := binary.LittleEndian.Uint16
+
+	ExpectEq(t, len(wav), wavHeaderSize+4800, invalidWAVText)
+	ExpectEq(t, string(wav[0:4]), "RIFF", invalidWAVText)
+	ExpectEq(t, u32(wav[4:]), 36+4800, invalidWAVText)
+	ExpectEq(t, string(wav[8:16]), "WAVEfmt ", invalidWAVText)
+	ExpectEq(t, u32(wav[16:]), 16, invalidWAVText)
+	ExpectEq(t, u16(wav[20:]), 1, invalidWAVText)
+	ExpectEq(t, u16(wav[22:]), 1, invalidWAVText)
+	ExpectEq(t, u32(wav[24:]), wavRate, invalidWAVText)
+	ExpectEq(t, u32(wav[28:]), 2*wavRate, invalidWAVText)
+	ExpectEq(t, u16(wav[32:]), 2, invalidWAVText)
+	ExpectEq(t, u16(wav[34:]), 16, invalidWAVText)
+	ExpectEq(t, string(wav[36:40]), "data", invalidWAVText)
+	ExpectEq(t, u32(wav[40:]), 4800, invalidWAVText)
+}
+
+func Test_WAV_UseTrackDuration(t *T) {
+	n := newPlayerNSF(t, defaultHeader,
+		newProgram(program, pulseTone))
+	n.Tracks[1].Duration = 50 * time.Millisecond
+	n.Tracks[1].Fade = 0
+	wav := writeWAV(t, n, options)
+
+	ExpectEq(t, len(wav), wavHeaderSize+800, invalidWAVText)
+}
+
+func Test_WAV_FadeOut(t *T) {
+	n := newPlayerNSF(t, defaultHeader,
+		newProgram(program, pulseTone))
+	wav := writeWAV(t, n, options)
+	peak := func(from, to int) int16 {
+		result := int16(0)
+		for i := from; i < to; i++ {
+			if s := getSample(wav, i); s > result {
+				result = s
+			}
+		}
+		return result
+	}
+
+	ExpectTrue(t, peak(1500, 1600) > 2*peak(2200, 2300),
+		invalidWAVText)
+	ExpectTrue(t, peak(2390, 2400) < 100, invalidWAVText)
+}
+
+func Test_WAV_WithManyChips_IsReproducible(t *T) {
+	h := defaultHeader
+	h.chips = VRC6 | VRC7 | MMC5 | Namco163 | Sunsoft5B
+	n := newPlayerNSF(t, h, newProgram(program, vrc6Tone))
+	wav := writeWAV(t, n, options)
+
+	for i := 0; i < 5; i++ {
+		ExpectDeepEq(t, writeWAV(t, n, options), wav,
+			invalidWAVText)
+	}
+}
+
+func Test_WAV_WhenSongInvalid_ReturnError(t *T) {
+	n := newPlayerNSF(t, defaultHeader,
+		newProgram(program, pulseTone))
+	err := WriteWAV(&bytes.Buffer{}, n, 5, DefaultOptions())
+
+	ExpectTrue(t, err != nil, invalidErrorText)
+	ExpectEq(t, err.Error(), "invalid song 5, file has 3 songs",
+		invalidErrorText)
+}
@@ -0,0 +1,108 @@
+package patch
+
+const bpsMagic = "BPS1"
+
+const (
+	bpsSourceRead = iota
+	bpsTargetRead
+	bpsSourceCopy
+	bpsTargetCopy
+)
+
+func ApplyBPS(rom, patch []byte) ([]byte, error) {
+	if len(patch) < len(bpsMagic)+checksumsSize {
+		return nil, errTruncated
+	}
+	end := len(patch) - checksumsSize
+	if err := verifyPatch(patch); err != nil {
+		return nil, err
+	}
+	r := &reader{data: patch[:end], offset: len(bpsMagic)}
+	sourceSize, targetSize := r.readNumber(), r.readNumber()
+	r.read(r.readNumber())
+	footer := &reader{data: patch, offset: end}
+	if err := verifySource(rom, sourceSize,
+		footer.readUint32()); err != nil {
+		return nil, err
+	}
+	target, err := applyBPSActions(r, rom, targetSize)
+	if err != nil {
+		return nil, err
+	}
+	return target, verifyChecksum("target", target,
+		footer.readUint32())
+}
+
+type bpsState struct {
+	source       []byte
+	target       []byte
+	sourceOffset int
+	targetOffset int
+}
+
+func applyBPSActions(r *reader, source []byte,
+	targetSize int) ([]byte, error) {
+	s := &bpsState{
+		source: source,
+		target: make([]byte, 0, targetSize),
+	}
+	for !r.isAtEnd(len(r.data)) {
+		action := r.readNumber()
+		length := action>>2 + 1
+		if length > targetSize-len(s.target) {
+			r.err = errRange
+			break
+		}
+		s.apply(r, action&0x03, length)
+	}
+	if r.err == nil && len(s.target) != targetSize {
+		r.err = errTruncated
+	}
+	return s.target, r.err
+}
+
+func (s *bpsState) apply(r *reader, command, length int) {
+	switch command {
+	case bpsSourceRead:
+		s.copy(r, s.source, len(s.target), length)
+	case bpsTargetRead:
+		s.target = append(s.target, r.read(length)...)
+	case bpsSourceCopy:
+		s.sourceOffset += readSignedNumber(r)
+		s.copy(r, s.source, s.sourceOffset, length)
+		s.sourceOffset += length
+	case bpsTargetCopy:
+		s.targetOffset += readSignedNumber(r)
+		s.copyTarget(r, s.targetOffset, length)
+		s.targetOffset += length
+	}
+}
+
+func (s *bpsState) copy(r *reader, data []byte,
+	offset, length int) {
+	if offset < 0 || length > len(data)-offset {
+		r.err = errTruncated
+		return
+	}
+	s.target = append(s.target, data[offset:offset+length]...)
+}
+
+// target copy may read bytes it has just written,
+// so it goes byte by byte
+func (s *bpsState) copyTarget(r *reader, offset, length int) {
+	if offset < 0 || offset >= len(s.target) {
+		r.err = errTruncated
+		return
+	}
+	for i := 0; i < length; i++ {
+		s.target = append(s.target, s.target[offset+i])
+	}
+}
+
+func readSignedNumber(r *reader) int {
+	number := r.readNumber()
+	if number&0x01 != 0 {
+		return -(number >> 1)
+	}
+	return number >> 1
+}
@@ -0,0 +1,119 @@
+package patch_test
+
+import (
+	. "github.com/smarkuck/nes/nes/patch"
+	. "github.com/smarkuck/unittest"
+)
+
+const (
+	sourceRead = iota
+	targetRead
+	sourceCopy
+	targetCopy
+)
+
+func Test_BPS_ApplyActions(t *T) {
+	rom := []byte{1, 2, 3, 4}
+	expected := []byte{1, 2, 9, 3, 4, 4, 4, 4, 2}
+	patch := newBPS(rom, expected,
+		bpsAction(sourceRead, 2),
+		bpsAction(targetRead, 1), []byte{9},
+		bpsAction(sourceCopy, 2), signed(2),
+		bpsAction(targetCopy, 3), signed(4),
+		bpsAction(sourceCopy, 1), signed(-3))
+
+	target, err := ApplyBPS(rom, patch)
+
+	ExpectTrue(t, err == nil, invalidErrorText)
+	ExpectDeepEq(t, target, expected, invalidROMText)
+}
+
+func Test_BPS_SkipMetadata(t *T) {
+	rom := []byte{1, 2}
+	patch := []byte("BPS1")
+	patch = append(patch, encodeNumber(2)...)
+	patch = append(patch, encodeNumber(2)...)
+	patch = append(patch, encodeNumber(3)...)
+	patch = append(patch, "xml"...)
+	patch = append(patch, bpsAction(sourceRead, 2)...)
+	patch = appendFooter(patch, rom, rom)
+
+	target, err := ApplyBPS(rom, patch)
+
+	ExpectTrue(t, err == nil, invalidErrorText)
+	ExpectDeepEq(t, target, rom, invalidROMText)
+}
+
+func Test_BPS_WhenPatchInvalid_ReturnError(t *T) {
+	rom := []byte{1, 2}
+	valid := newBPS(rom, rom, bpsAction(sourceRead, 2))
+	corrupted := append([]byte(nil), valid...)
+	corrupted[6] ^= 0xff
+	cases := map[string]struct {
+		rom, patch []byte
+	}{
+		"source": {[]byte{1, 3}, valid},
+		"target": {rom, newBPS(rom, []byte{1, 3},
+			bpsAction(sourceRead, 2))},
+		"patch": {rom, corrupted},
+		"target size": {rom, newBPS(rom, rom,
+			bpsAction(sourceRead, 1))},
+		"copy range": {rom, newBPS(rom, rom,
+			bpsAction(sourceCopy, 2), signed(1))},
+		"truncated": {rom, []byte("BPS1")},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *T) {
+			_, err := ApplyBPS(c.rom, c.patch)
+
+			ExpectTrue(t, err != nil, invalidErrorText)
+		})
+	}
+}
+
+func Test_BPS_WhenNumberOutOfRange_ReturnError(t *T) {
+	rom := []byte{1, 2}
+	cases := map[string][]byte{
+		"target size": newSizedPatch("BPS1", rom,
+			encodeNumber(1<<62)),
+		"endless number": newSizedPatch("BPS1", rom,
+			endlessNumber),
+		"action length": newBPS(rom, rom,
+			bpsAction(targetRead, 1<<20)),
+		"copy offset": newBPS(rom, rom,
+			bpsAction(sourceCopy, 2), endlessNumber),
+	}
+
+	for name, patch := range cases {
+		t.Run(name, func(t *T) {
+			_, err := ApplyBPS(rom, patch)
+
+			ExpectTrue(t, err != nil, invalidErrorText)
+			ExpectEq(t, err.Error(), "patch number out of range",
+				invalidErrorText)
+		})
+	}
+}
+
+func newBPS(source, target []byte, actions ...[]byte) []byte {
+	patch := []byte("BPS1")
+	patch = append(patch, encodeNumber(len(source))...)
+	patch = append(patch, encodeNumber(len(target))...)
+	patch = append(patch, encodeNumber(0)...)
+	for _, a := range actions {
+		patch = append(patch, a...)
+	}
+	return appendFooter(patch, source, target)
+}
+
+func bpsAction(command, length int) []byte {
+	return encodeNumber((length-1)<<2 | command)
+}
+
+func signed(offset int) []byte {
+	if offset < 0 {
+		return encodeNumber(-offset<<1 | 1)
+	}
+	return encodeNumber(offset << 1)
+}
@@ -0,0 +1,125 @@
+package patch
+
+import "fmt"
+
+const (
+	ipsMagic     = "PATCH"
+	ipsEOF       = "EOF"
+	ipsEOFOffset = 0x454f46
+	ipsMaxSize   = 0x1000000
+	ipsMaxRecord = 0xffff
+
+	ipsTooLargeFormat = "ROM too large for IPS patch: %d bytes"
+)
+
+// ApplyIPS supports RLE records and truncation offset
+// stored after EOF marker
+func ApplyIPS(rom, patch []byte) ([]byte, error) {
+	r := &reader{data: patch, offset: len(ipsMagic)}
+	target := append([]byte(nil), rom...)
+	for {
+		offset := readIPSOffset(r)
+		if r.err != nil {
+			return nil, r.err
+		}
+		if offset == ipsEOFOffset {
+			break
+		}
+		target = applyIPSRecord(r, target, offset)
+	}
+	if len(patch)-r.offset >= 3 {
+		target = resize(target, readIPSOffset(r))
+	}
+	return target, r.err
+}
+
+func readIPSOffset(r *reader) int {
+	b := r.read(3)
+	return int(b[0])<<16 | int(b[1])<<8 | int(b[2])
+}
+
+func readIPSSize(r *reader) int {
+	b := r.read(2)
+	return int(b[0])<<8 | int(b[1])
+}
+
+func applyIPSRecord(r *reader, target []byte, offset int) []byte {
+	size := readIPSSize(r)
+	var data []byte
+	if size == 0 {
+		size = readIPSSize(r)
+		data = repeat(r.readByte(), size)
+	} else {
+		data = r.read(size)
+	}
+	if offset+size > len(target) {
+		target = resize(target, offset+size)
+	}
+	copy(target[offset:], data)
+	return target
+}
+
+func repeat(value byte, size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = value
+	}
+	return data
+}
+
+func resize(data []byte, size int) []byte {
+	if size <= len(data) {
+		return data[:size]
+	}
+	return append(data, make([]byte, size-len(data))...)
+}
+
+// CreateIPS returns patch that turns source into target,
+// shorter target is stored as truncation offset
+func CreateIPS(source, target []byte) ([]byte, error) {
+	if len(target) > ipsMaxSize {
+		return nil, fmt.Errorf(ipsTooLargeFormat, len(target))
+	}
+	patch := []byte(ipsMagic)
+	for offset := 0; offset < len(target); {
+		if !isChanged(source, target, offset) {
+			offset++
+			continue
+		}
+		if offset == ipsEOFOffset {
+			offset--
+		}
+		end := changedRunEnd(source, target, offset)
+		patch = appendIPSRecord(patch, offset, target[offset:end])
+		offset = end
+	}
+	patch = append(patch, ipsEOF...)
+	if len(target) < len(source) {
+		patch = appendIPSOffset(patch, len(target))
+	}
+	return patch, nil
+}
+
+func isChanged(source, target []byte, offset int) bool {
+	return offset >= len(source) || source[offset] != target[offset]
+}
+
+func changedRunEnd(source, target []byte, offset int) int {
+	end := offset + 1
+	for end < len(target) && end-offset < ipsMaxRecord &&
+		isChanged(source, target, end) {
+		end++
+	}
+	return end
+}
+
+func appendIPSRecord(patch []byte, offset int, data []byte) []byte {
+	patch = appendIPSOffset(patch, offset)
+	patch = append(patch, byte(len(data)>>8), byte(len(data)))
+	return append(patch, data...)
+}
+
+func appendIPSOffset(patch []byte, offset int) []byte {
+	return append(patch, byte(offset>>16), byte(offset>>8),
+		byte(offset))
+}
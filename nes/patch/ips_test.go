@@ -0,0 +1,122 @@
+package patch_test
+
+import (
+	. "github.com/smarkuck/nes/nes/patch"
+	. "github.com/smarkuck/unittest"
+)
+
+func Test_IPS_ApplyRecords(t *T) {
+	rom := []byte{0, 0, 0, 0, 0, 0}
+	patch := newIPS(ipsRecord(1, 1, 2), ipsRecord(4, 3))
+
+	target, err := ApplyIPS(rom, patch)
+
+	ExpectTrue(t, err == nil, invalidErrorText)
+	ExpectDeepEq(t, target, []byte{0, 1, 2, 0, 3, 0},
+		invalidROMText)
+	ExpectDeepEq(t, rom, []byte{0, 0, 0, 0, 0, 0},
+		"source modified")
+}
+
+func Test_IPS_ApplyRLERecord(t *T) {
+	patch := newIPS([]byte{0, 0, 1, 0, 0, 0, 3, 0xaa})
+
+	target, _ := ApplyIPS([]byte{0, 0, 0, 0, 0}, patch)
+
+	ExpectDeepEq(t, target, []byte{0, 0xaa, 0xaa, 0xaa, 0},
+		invalidROMText)
+}
+
+func Test_IPS_ExtendROMPastEnd(t *T) {
+	target, _ := ApplyIPS([]byte{1}, newIPS(ipsRecord(2, 5)))
+
+	ExpectDeepEq(t, target, []byte{1, 0, 5}, invalidROMText)
+}
+
+func Test_IPS_TruncateROMAfterEOF(t *T) {
+	patch := append(newIPS(ipsRecord(0, 9)), 0, 0, 2)
+
+	target, _ := ApplyIPS([]byte{1, 2, 3, 4}, patch)
+
+	ExpectDeepEq(t, target, []byte{9, 2}, invalidROMText)
+}
+
+func Test_IPS_WhenPatchTruncated_ReturnError(t *T) {
+	patches := map[string][]byte{
+		"missing EOF": []byte("PATCH\x00\x00\x01\x00\x01\x05"),
+		"record data": []byte("PATCH\x00\x00\x01\x00\x02\x05"),
+		"rle value":   []byte("PATCH\x00\x00\x01\x00\x00\x00\x02"),
+	}
+
+	for name, patch := range patches {
+		t.Run(name, func(t *T) {
+			_, err := ApplyIPS([]byte{0, 0, 0}, patch)
+
+			ExpectTrue(t, err != nil, invalidErrorText)
+		})
+	}
+}
+
+func newIPS(records ...[]byte) []byte {
+	patch := []byte("PATCH")
+	for _, r := range records {
+		patch = append(patch, r...)
+	}
+	return append(patch, "EOF"...)
+}
+
+func ipsRecord(offset int, data ...byte) []byte {
+	record := []byte{byte(offset >> 16), byte(offset >> 8),
+		byte(offset), byte(len(data) >> 8), byte(len(data))}
+	return append(record, data...)
+}
+
+func Test_IPS_CreatePatch(t *T) {
+	tests := map[string]struct {
+		source, target []byte
+	}{
+		"same":      {[]byte{1, 2, 3}, []byte{1, 2, 3}},
+		"changed":   {[]byte{1, 2, 3, 4}, []byte{0, 2, 5, 6}},
+		"extended":  {[]byte{1}, []byte{1, 0, 7}},
+		"truncated": {[]byte{1, 2, 3}, []byte{4}},
+		"long run": {make([]byte, 0x10002),
+			repeatByte(1, 0x10002)},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *T) {
+			patch, err := CreateIPS(test.source, test.target)
+			ExpectTrue(t, err == nil, invalidErrorText)
+
+			target, err := ApplyIPS(test.source, patch)
+
+			ExpectTrue(t, err == nil, invalidErrorText)
+			ExpectDeepEq(t, target, test.target, invalidROMText)
+		})
+	}
+}
+
+func Test_IPS_CreatePatchWithoutEOFOffset(t *T) {
+	source := make([]byte, 0x454f48)
+	target := append([]byte(nil), source...)
+	target[0x454f46] = 1
+
+	patch, _ := CreateIPS(source, target)
+
+	ExpectDeepEq(t, patch, newIPS(ipsRecord(0x454f45, 0, 1)),
+		invalidROMText)
+}
+
+func Test_IPS_WhenROMTooLarge_ReturnError(t *T) {
+	_, err := CreateIPS(nil, make([]byte, 0x1000001))
+
+	ExpectTrue(t, err != nil, invalidErrorText)
+}
+
+func repeatByte(value byte, size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = value
+	}
+	return data
+}
@@ -0,0 +1,142 @@
+package patch
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	checksumSize   = 4
+	checksumsSize  = 3 * checksumSize
+	unknownFormat  = "unknown patch format"
+	truncatedText  = "truncated patch"
+	rangeText      = "patch number out of range"
+	checksumFormat = "%s checksum mismatch: " +
+		"expected %08x, got %08x"
+	sizeMismatchFormat = "%s size mismatch: " +
+		"expected %d, got %d"
+)
+
+// no NES image comes close, bigger numbers come
+// from corrupted patches
+const maxNumber = 16 << 20
+
+var (
+	errTruncated = errors.New(truncatedText)
+	errRange     = errors.New(rangeText)
+)
+
+var softPatchExtensions = []string{".ips", ".ups", ".bps"}
+
+func Apply(rom, patch []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(patch, []byte(ipsMagic)):
+		return ApplyIPS(rom, patch)
+	case bytes.HasPrefix(patch, []byte(upsMagic)):
+		return ApplyUPS(rom, patch)
+	case bytes.HasPrefix(patch, []byte(bpsMagic)):
+		return ApplyBPS(rom, patch)
+	}
+	return nil, errors.New(unknownFormat)
+}
+
+// LoadFile reads ROM and applies patch with the same name
+// lying next to it, if there is one
+func LoadFile(path string) ([]byte, error) {
+	rom, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	for _, ext := range softPatchExtensions {
+		patch, err := os.ReadFile(base + ext)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			continue
+		case err != nil:
+			return nil, err
+		}
+		return Apply(rom, patch)
+	}
+	return rom, nil
+}
+
+func verifyChecksum(name string, data []byte,
+	expected uint32) error {
+	if actual := crc32.ChecksumIEEE(data); actual != expected {
+		return fmt.Errorf(checksumFormat, name, expected, actual)
+	}
+	return nil
+}
+
+func verifyPatch(patch []byte) error {
+	end := len(patch) - checksumSize
+	r := &reader{data: patch, offset: end}
+	return verifyChecksum("patch", patch[:end], r.readUint32())
+}
+
+func verifySource(rom []byte, size int, checksum uint32) error {
+	if len(rom) != size {
+		return fmt.Errorf(sizeMismatchFormat, "source",
+			size, len(rom))
+	}
+	return verifyChecksum("source", rom, checksum)
+}
+
+type reader struct {
+	data   []byte
+	offset int
+	err    error
+}
+
+func (r *reader) read(size int) []byte {
+	if r.err == nil && size > len(r.data)-r.offset {
+		r.err = errTruncated
+	}
+	if r.err != nil {
+		return make([]byte, size)
+	}
+	data := r.data[r.offset : r.offset+size]
+	r.offset += size
+	return data
+}
+
+func (r *reader) readByte() byte {
+	return r.read(1)[0]
+}
+
+// number encoding used by UPS and BPS, each byte holds
+// 7 bits and continuation adds implicit offset
+func (r *reader) readNumber() int {
+	number, shift := 0, 1
+	for r.err == nil && number <= maxNumber {
+		b := r.readByte()
+		number += int(b&0x7f) * shift
+		if b&0x80 != 0 {
+			break
+		}
+		shift <<= 7
+		number += shift
+	}
+	if number > maxNumber {
+		r.err = errRange
+		return 0
+	}
+	return number
+}
+
+func (r *reader) readUint32() uint32 {
+	b := r.read(checksumSize)
+	return uint32(b[0]) | uint32(b[1])<<8 |
+		uint32(b[2])<<16 | uint32(b[3])<<24
+}
+
+func (r *reader) isAtEnd(end int) bool {
+	return r.err != nil || r.offset >= end
+}
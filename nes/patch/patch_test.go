@@ -0,0 +1,115 @@
+package patch_test
+
+import (
+	"hash/crc32"
+	"os"
+	"path/filepath"
+
+	. "github.com/smarkuck/nes/nes/patch"
+	. "github.com/smarkuck/unittest"
+)
+
+const (
+	invalidErrorText = "invalid error"
+	invalidROMText   = "invalid patched ROM"
+)
+
+func Test_Apply_DetectPatchFormat(t *T) {
+	rom := []byte{1, 2, 3, 4}
+	expected := []byte{1, 9, 3, 4}
+	patches := map[string][]byte{
+		"IPS": newIPS(ipsRecord(1, 9)),
+		"UPS": newUPS(rom, expected, 1, 2^9, 0),
+		"BPS": newBPS(rom, expected,
+			bpsAction(sourceRead, 1),
+			bpsAction(targetRead, 1), []byte{9},
+			bpsAction(sourceRead, 2)),
+	}
+
+	for name, patch := range patches {
+		t.Run(name, func(t *T) {
+			target, err := Apply(rom, patch)
+
+			ExpectTrue(t, err == nil, invalidErrorText)
+			ExpectDeepEq(t, target, expected, invalidROMText)
+		})
+	}
+}
+
+func Test_Apply_WhenFormatUnknown_ReturnError(t *T) {
+	_, err := Apply([]byte{1}, []byte("PAT"))
+
+	ExpectTrue(t, err != nil, invalidErrorText)
+}
+
+func Test_LoadFile_ApplySamePatchNextToROM(t *T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "game.nes")
+	os.WriteFile(path, []byte{1, 2, 3}, 0644)
+	os.WriteFile(filepath.Join(dir, "game.ips"),
+		newIPS(ipsRecord(2, 7)), 0644)
+
+	rom, err := LoadFile(path)
+
+	ExpectTrue(t, err == nil, invalidErrorText)
+	ExpectDeepEq(t, rom, []byte{1, 2, 7}, invalidROMText)
+}
+
+func Test_LoadFile_WhenNoPatch_ReturnROM(t *T) {
+	path := filepath.Join(t.TempDir(), "game.nes")
+	os.WriteFile(path, []byte{1, 2, 3}, 0644)
+
+	rom, err := LoadFile(path)
+
+	ExpectTrue(t, err == nil, invalidErrorText)
+	ExpectDeepEq(t, rom, []byte{1, 2, 3}, invalidROMText)
+}
+
+func Test_LoadFile_WhenPatchInvalid_ReturnError(t *T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "game.nes")
+	os.WriteFile(path, []byte{1, 2, 3}, 0644)
+	os.WriteFile(filepath.Join(dir, "game.bps"),
+		[]byte("BPS1"), 0644)
+
+	_, err := LoadFile(path)
+
+	ExpectTrue(t, err != nil, invalidErrorText)
+}
+
+func encodeNumber(number int) []byte {
+	var data []byte
+	for {
+		b := byte(number & 0x7f)
+		number >>= 7
+		if number == 0 {
+			return append(data, b|0x80)
+		}
+		data = append(data, b)
+		number--
+	}
+}
+
+// number is never terminated, all bytes continue it
+var endlessNumber = make([]byte, 10)
+
+func newSizedPatch(magic string, source []byte,
+	targetSize []byte, rest ...byte) []byte {
+	patch := []byte(magic)
+	patch = append(patch, encodeNumber(len(source))...)
+	patch = append(patch, targetSize...)
+	patch = append(patch, rest...)
+	return appendFooter(patch, source, nil)
+}
+
+func appendChecksum(data, checksummed []byte) []byte {
+	c := crc32.ChecksumIEEE(checksummed)
+	return append(data, byte(c), byte(c>>8),
+		byte(c>>16), byte(c>>24))
+}
+
+func appendFooter(patch, source, target []byte) []byte {
+	patch = appendChecksum(patch, source)
+	patch = appendChecksum(patch, target)
+	return appendChecksum(patch, patch)
+}
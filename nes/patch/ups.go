@@ -0,0 +1,46 @@
+package patch
+
+const upsMagic = "UPS1"
+
+func ApplyUPS(rom, patch []byte) ([]byte, error) {
+	if len(patch) < len(upsMagic)+checksumsSize {
+		return nil, errTruncated
+	}
+	end := len(patch) - checksumsSize
+	if err := verifyPatch(patch); err != nil {
+		return nil, err
+	}
+	r := &reader{data: patch[:end], offset: len(upsMagic)}
+	sourceSize, targetSize := r.readNumber(), r.readNumber()
+	footer := &reader{data: patch, offset: end}
+	if err := verifySource(rom, sourceSize,
+		footer.readUint32()); err != nil {
+		return nil, err
+	}
+	target := resize(append([]byte(nil), rom...), targetSize)
+	for offset := 0; !r.isAtEnd(end); offset++ {
+		offset += r.readNumber()
+		offset = xorUntilZero(r, target, offset)
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return target, verifyChecksum("target", target,
+		footer.readUint32())
+}
+
+// XOR block ends with zero byte which also
+// consumes one position of target
+func xorUntilZero(r *reader, target []byte, offset int) int {
+	for !r.isAtEnd(len(r.data)) {
+		b := r.readByte()
+		if b == 0 {
+			break
+		}
+		if offset < len(target) {
+			target[offset] ^= b
+		}
+		offset++
+	}
+	return offset
+}
@@ -0,0 +1,91 @@
+package patch_test
+
+import (
+	. "github.com/smarkuck/nes/nes/patch"
+	. "github.com/smarkuck/unittest"
+)
+
+func Test_UPS_ApplyXORHunks(t *T) {
+	rom := []byte{1, 2, 3, 4, 5, 6}
+	expected := []byte{1, 7, 3, 4, 5, 9}
+	patch := newUPS(rom, expected,
+		1, 2^7, 0,
+		2, 6^9, 0)
+
+	target, err := ApplyUPS(rom, patch)
+
+	ExpectTrue(t, err == nil, invalidErrorText)
+	ExpectDeepEq(t, target, expected, invalidROMText)
+}
+
+func Test_UPS_ResizeTarget(t *T) {
+	rom := []byte{1, 2}
+	expected := []byte{1, 2, 0, 8}
+	patch := newUPS(rom, expected, 3, 8, 0)
+
+	target, err := ApplyUPS(rom, patch)
+
+	ExpectTrue(t, err == nil, invalidErrorText)
+	ExpectDeepEq(t, target, expected, invalidROMText)
+}
+
+func Test_UPS_WhenChecksumInvalid_ReturnError(t *T) {
+	rom := []byte{1, 2}
+	valid := newUPS(rom, []byte{1, 3}, 1, 2^3, 0)
+	wrongTarget := newUPS(rom, []byte{1, 4}, 1, 2^3, 0)
+	corrupted := append([]byte(nil), valid...)
+	corrupted[7] ^= 0xff
+	cases := map[string]struct {
+		rom, patch []byte
+	}{
+		"source":      {[]byte{1, 5}, valid},
+		"source size": {[]byte{1, 2, 3}, valid},
+		"target":      {rom, wrongTarget},
+		"patch":       {rom, corrupted},
+		"truncated":   {rom, []byte("UPS1")},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *T) {
+			_, err := ApplyUPS(c.rom, c.patch)
+
+			ExpectTrue(t, err != nil, invalidErrorText)
+		})
+	}
+}
+
+func Test_UPS_WhenNumberOutOfRange_ReturnError(t *T) {
+	rom := []byte{1, 2}
+	cases := map[string][]byte{
+		"target size": newSizedPatch("UPS1", rom,
+			encodeNumber(1<<62)),
+		"endless number": newSizedPatch("UPS1", rom,
+			endlessNumber),
+		"offset": newSizedPatch("UPS1", rom,
+			encodeNumber(2), endlessNumber...),
+	}
+
+	for name, patch := range cases {
+		t.Run(name, func(t *T) {
+			_, err := ApplyUPS(rom, patch)
+
+			ExpectTrue(t, err != nil, invalidErrorText)
+			ExpectEq(t, err.Error(), "patch number out of range",
+				invalidErrorText)
+		})
+	}
+}
+
+func newUPS(source, target []byte, hunks ...int) []byte {
+	patch := []byte("UPS1")
+	patch = append(patch, encodeNumber(len(source))...)
+	patch = append(patch, encodeNumber(len(target))...)
+	for i := 0; i < len(hunks); i++ {
+		patch = append(patch, encodeNumber(hunks[i])...)
+		for i++; hunks[i] != 0; i++ {
+			patch = append(patch, byte(hunks[i]))
+		}
+		patch = append(patch, 0)
+	}
+	return appendFooter(patch, source, target)
+}
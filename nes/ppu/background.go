@@ -0,0 +1,124 @@
+package ppu
+
+const (
+	nametableAddr   = 0x2000
+	attributeOffset = 0x03c0
+	tileBytes       = 16
+	planeOffset     = 8
+	tileWidth       = 8
+
+	ctrlBackgroundTable = 0x10
+	backgroundTableAddr = 0x1000
+	maskLeftBackground  = 0x02
+)
+
+// tiles are fetched 2 tiles ahead, shift registers hold
+// 16 pixels of current and next tile
+type background struct {
+	nametable    byte
+	attribute    byte
+	patternLow   byte
+	patternHigh  byte
+	shiftLow     uint16
+	shiftHigh    uint16
+	shiftAttrLow uint16
+	shiftAttrHi  uint16
+}
+
+// each fetch takes 2 dots, tile is complete after 8 dots
+func (p *ppu) fetchBackground() {
+	switch (p.dot - 1) % tileWidth {
+	case 0:
+		p.bg.nametable = p.fetchNametable()
+	case 2:
+		p.bg.attribute = p.fetchAttribute()
+	case 4:
+		p.bg.patternLow = p.vram.Read(p.getPatternAddr())
+	case 6:
+		p.bg.patternHigh = p.vram.Read(p.getPatternAddr() +
+			planeOffset)
+	case 7:
+		p.incrementX()
+	}
+}
+
+func (p *ppu) fetchNametable() byte {
+	return p.vram.Read(nametableAddr |
+		p.V&(nametableMask|coarseYMask|coarseXMask))
+}
+
+// attribute byte covers 4x4 tiles, each 2x2 tile quadrant
+// has its own 2-bit palette
+func (p *ppu) fetchAttribute() byte {
+	addr := nametableAddr | attributeOffset |
+		p.V&nametableMask | p.V>>4&0x38 | p.V>>2&0x07
+	shift := p.V>>4&0x04 | p.V&0x02
+	return p.vram.Read(addr) >> shift & 0x03
+}
+
+func (p *ppu) getPatternAddr() uint16 {
+	addr := uint16(p.bg.nametable)*tileBytes | p.V>>12
+	if p.ctrl&ctrlBackgroundTable != 0 {
+		addr |= backgroundTableAddr
+	}
+	return addr
+}
+
+func (p *ppu) loadShifters() {
+	p.bg.shiftLow = p.bg.shiftLow&0xff00 | uint16(p.bg.patternLow)
+	p.bg.shiftHigh = p.bg.shiftHigh&0xff00 |
+		uint16(p.bg.patternHigh)
+	p.bg.shiftAttrLow = p.bg.shiftAttrLow&0xff00 |
+		fillByte(p.bg.attribute&0x01 != 0)
+	p.bg.shiftAttrHi = p.bg.shiftAttrHi&0xff00 |
+		fillByte(p.bg.attribute&0x02 != 0)
+}
+
+func fillByte(isSet bool) uint16 {
+	if isSet {
+		return 0xff
+	}
+	return 0
+}
+
+// shifters are reloaded every 8 dots with tile fetched
+// during previous 8 dots
+func (p *ppu) updateShifters() {
+	if p.dot == 1 || p.dot == prefetchStart {
+		return
+	}
+	p.shiftBackground()
+	if (p.dot-1)%tileWidth == 0 {
+		p.loadShifters()
+	}
+}
+
+func (p *ppu) shiftBackground() {
+	p.bg.shiftLow <<= 1
+	p.bg.shiftHigh <<= 1
+	p.bg.shiftAttrLow <<= 1
+	p.bg.shiftAttrHi <<= 1
+}
+
+// returns palette RAM index, 0 means transparent pixel
+func (p *ppu) getBackgroundPixel(x int) byte {
+	if p.mask&maskBackground == 0 ||
+		x < tileWidth && p.mask&maskLeftBackground == 0 {
+		return 0
+	}
+	bit := uint16(0x8000) >> p.X
+	pixel := getBit(p.bg.shiftLow, bit) |
+		getBit(p.bg.shiftHigh, bit)<<1
+	if pixel == 0 {
+		return 0
+	}
+	return pixel | getBit(p.bg.shiftAttrLow, bit)<<2 |
+		getBit(p.bg.shiftAttrHi, bit)<<3
+}
+
+func getBit(value, bit uint16) byte {
+	if value&bit != 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,105 @@
+package ppu
+
+import (
+	"github.com/smarkuck/nes/nes"
+	"github.com/smarkuck/nes/nes/cartridge"
+	"github.com/smarkuck/nes/nes/mapper"
+)
+
+const (
+	patternEnd    = 0x2000
+	nametableSize = 0x0400
+	ciramSize     = 2 * nametableSize
+	// four screen boards add 2 KB of VRAM on cartridge
+	fourScreenSize = 4 * nametableSize
+	nametablesSize = 0x1000
+)
+
+// Bus is PPU address space with pattern tables on
+// cartridge and nametables in console CIRAM
+type Bus interface {
+	nes.Bus
+	Peeker
+}
+
+type bus struct {
+	mapper   mapper.Mapper
+	observer mapper.PPUObserver
+	provider mapper.NametableProvider
+	vram     [fourScreenSize]byte
+}
+
+func NewBus(m mapper.Mapper) Bus {
+	b := &bus{mapper: m}
+	b.observer, _ = m.(mapper.PPUObserver)
+	b.provider, _ = m.(mapper.NametableProvider)
+	return b
+}
+
+// mappers see every address put on PPU bus
+func (b *bus) Read(addr uint16) byte {
+	value := b.Peek(addr)
+	b.observe(addr & vramMask)
+	return value
+}
+
+func (b *bus) Peek(addr uint16) byte {
+	addr &= vramMask
+	if addr < patternEnd {
+		return b.mapper.ReadCHR(addr)
+	}
+	addr = getNametableAddr(addr)
+	if b.isMapperControlled() {
+		return b.provider.ReadNametable(addr, b.vram[:ciramSize])
+	}
+	return b.vram[b.getVRAMIndex(addr)]
+}
+
+func (b *bus) Write(addr uint16, value byte) {
+	addr &= vramMask
+	switch {
+	case addr < patternEnd:
+		b.mapper.WriteCHR(addr, value)
+	case b.isMapperControlled():
+		b.provider.WriteNametable(getNametableAddr(addr), value,
+			b.vram[:ciramSize])
+	default:
+		b.vram[b.getVRAMIndex(getNametableAddr(addr))] = value
+	}
+	b.observe(addr)
+}
+
+func (b *bus) observe(addr uint16) {
+	if b.observer != nil {
+		b.observer.ObservePPUAddress(addr)
+	}
+}
+
+// $3000-$3EFF mirrors nametables
+func getNametableAddr(addr uint16) uint16 {
+	return nametableAddr | addr%nametablesSize
+}
+
+func (b *bus) isMapperControlled() bool {
+	return b.provider != nil &&
+		b.mapper.GetMirroring() == cartridge.MapperControlled
+}
+
+func (b *bus) getVRAMIndex(addr uint16) int {
+	table := int(addr-nametableAddr) / nametableSize
+	offset := int(addr % nametableSize)
+	switch b.mapper.GetMirroring() {
+	case cartridge.Horizontal:
+		table >>= 1
+	case cartridge.SingleScreenA:
+		table = 0
+	case cartridge.SingleScreenB:
+		table = 1
+	case cartridge.FourScreen:
+	default:
+		// vertical, also used when mapper does not
+		// provide its nametables
+		table &= 1
+	}
+	return table*nametableSize + offset
+}
@@ -0,0 +1,172 @@
+package ppu_test
+
+import (
+	"github.com/smarkuck/nes/nes"
+	"github.com/smarkuck/nes/nes/cartridge"
+	"github.com/smarkuck/nes/nes/mapper"
+	. "github.com/smarkuck/nes/nes/ppu"
+	. "github.com/smarkuck/unittest"
+)
+
+const invalidMirroringText = "invalid mirroring"
+
+type fakeMapper struct {
+	chr       [0x2000]byte
+	mirroring cartridge.Mirroring
+	observed  []uint16
+}
+
+func (f *fakeMapper) Read(uint16) byte                 { return 0 }
+func (f *fakeMapper) Write(uint16, byte)               {}
+func (f *fakeMapper) Tick()                            {}
+func (f *fakeMapper) ReadCHR(addr uint16) byte         { return f.chr[addr] }
+func (f *fakeMapper) WriteCHR(addr uint16, value byte) { f.chr[addr] = value }
+
+func (f *fakeMapper) GetMirroring() cartridge.Mirroring {
+	return f.mirroring
+}
+
+func (f *fakeMapper) ObservePPUAddress(addr uint16) {
+	f.observed = append(f.observed, addr)
+}
+
+type nametableMapper struct {
+	fakeMapper
+	addr      uint16
+	ciramSize int
+}
+
+func (n *nametableMapper) ReadNametable(addr uint16,
+	ciram []byte) byte {
+	n.addr, n.ciramSize = addr, len(ciram)
+	return 0xaa
+}
+
+func (n *nametableMapper) WriteNametable(addr uint16, value byte,
+	ciram []byte) {
+	n.addr, n.ciramSize = addr, len(ciram)
+	ciram[0] = value
+}
+
+// returns which of 4 nametables share memory with first one
+// and third one
+func getAliases(b Bus) [4]byte {
+	var result [4]byte
+	for table := uint16(0); table < 4; table++ {
+		b.Write(0x2000+table*0x400, byte(table))
+	}
+	for table := uint16(0); table < 4; table++ {
+		result[table] = b.Read(0x2000 + table*0x400)
+	}
+	return result
+}
+
+func Test_Bus_RoutePatternTablesToMapper(t *T) {
+	m := new(fakeMapper)
+	b := NewBus(m)
+	m.chr[0x1234] = 0x56
+	b.Write(0x0010, 0x78)
+
+	ExpectEq(t, b.Read(0x1234), 0x56, invalidVRAMText)
+	ExpectEq(t, m.chr[0x0010], 0x78, invalidVRAMText)
+}
+
+func Test_Bus_Mirroring(t *T) {
+	tests := []struct {
+		name      string
+		mirroring cartridge.Mirroring
+		aliases   [4]byte
+	}{
+		{"Horizontal", cartridge.Horizontal, [4]byte{1, 1, 3, 3}},
+		{"Vertical", cartridge.Vertical, [4]byte{2, 3, 2, 3}},
+		{"SingleScreenA", cartridge.SingleScreenA, [4]byte{3, 3, 3, 3}},
+		{"SingleScreenB", cartridge.SingleScreenB, [4]byte{3, 3, 3, 3}},
+		{"FourScreen", cartridge.FourScreen, [4]byte{0, 1, 2, 3}},
+		{"NoProvider", cartridge.MapperControlled, [4]byte{2, 3, 2, 3}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			b := NewBus(&fakeMapper{mirroring: test.mirroring})
+			ExpectEq(t, getAliases(b), test.aliases,
+				invalidMirroringText)
+		})
+	}
+}
+
+func Test_Bus_SingleScreensAreSeparate(t *T) {
+	m := &fakeMapper{mirroring: cartridge.SingleScreenA}
+	b := NewBus(m)
+	b.Write(0x2000, 0x12)
+	m.mirroring = cartridge.SingleScreenB
+	b.Write(0x2000, 0x34)
+	m.mirroring = cartridge.SingleScreenA
+
+	ExpectEq(t, b.Read(0x2c00), 0x12, invalidVRAMText)
+}
+
+func Test_Bus_MirrorNametablesAbove3000(t *T) {
+	b := NewBus(&fakeMapper{mirroring: cartridge.FourScreen})
+	b.Write(0x3123, 0x12)
+	b.Write(0x3f00, 0x34)
+
+	ExpectEq(t, b.Read(0x2123), 0x12, invalidVRAMText)
+	ExpectEq(t, b.Read(0x2f00), 0x34, invalidVRAMText)
+	ExpectEq(t, b.Read(0x6123), 0x12, invalidVRAMText)
+}
+
+func Test_Bus_SwitchMirroringAtRuntime(t *T) {
+	c := &cartridge.Cartridge{PRG: make([]byte, 0x8000), Mapper: 7}
+	m, _ := mapper.New(c)
+	b := NewBus(m)
+	b.Write(0x2000, 0x12)
+	m.Write(0x8000, 0x10)
+	b.Write(0x2000, 0x34)
+
+	ExpectEq(t, b.Read(0x2400), 0x34, invalidVRAMText)
+	m.Write(0x8000, 0x00)
+	ExpectEq(t, b.Read(0x2400), 0x12, invalidVRAMText)
+}
+
+func Test_Bus_MapperProvidedNametables(t *T) {
+	m := &nametableMapper{}
+	m.mirroring = cartridge.MapperControlled
+	b := NewBus(m)
+
+	ExpectEq(t, b.Read(0x3456), 0xaa, invalidVRAMText)
+	ExpectEq(t, m.addr, 0x2456, invalidVRAMText)
+	b.Write(0x2c01, 0x12)
+	ExpectEq(t, m.addr, 0x2c01, invalidVRAMText)
+	ExpectEq(t, m.ciramSize, 0x800, invalidVRAMText)
+
+	m.mirroring = cartridge.Vertical
+	ExpectEq(t, b.Read(0x2000), 0x12, invalidVRAMText)
+}
+
+func Test_Bus_ObserveAccessesButNotPeeks(t *T) {
+	m := new(fakeMapper)
+	b := NewBus(m)
+	b.Read(0x1000)
+	b.Write(0x6400, 0)
+	b.Peek(0x0fff)
+
+	ExpectDeepEq(t, m.observed, []uint16{0x1000, 0x2400},
+		invalidVRAMText)
+}
+
+func Test_Bus_RenderWithPPU(t *T) {
+	m := &fakeMapper{mirroring: cartridge.Horizontal}
+	p := NewPPU(NewBus(m), nes.NTSC)
+	for row := 0; row < 8; row++ {
+		m.chr[0x10+row] = 0xff
+	}
+	setAddress(p, 0x2400)
+	p.Write(ppuData, 1)
+	setAddress(p, 0x3f01)
+	p.Write(ppuData, white)
+	f := renderFrame(p, background|leftColumn, 0, 0)
+
+	ExpectEq(t, f[0], white, invalidPixelText)
+	ExpectEq(t, f[8], 0, invalidPixelText)
+	ExpectTrue(t, len(m.observed) > 0, invalidVRAMText)
+}
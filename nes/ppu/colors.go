@@ -0,0 +1,74 @@
+package ppu
+
+import "fmt"
+
+const (
+	ColorCount = 64
+	IndexCount = ColorCount * 8
+
+	rgbSize  = 3
+	indexMax = IndexCount - 1
+	// emphasis darkens other color channels
+	emphasisAttenuation = 0.746
+
+	invalidPaletteFormat = "invalid palette size %d, " +
+		"expected %d or %d bytes"
+)
+
+type RGB struct {
+	R, G, B byte
+}
+
+// Palette maps 9-bit frame indices to RGB colors
+type Palette [IndexCount]RGB
+
+var defaultPalette = GeneratePalette(DefaultNTSC)
+
+func DefaultPalette() *Palette {
+	p := *defaultPalette
+	return &p
+}
+
+func (p *Palette) GetRGB(index uint16) RGB {
+	return p[index&indexMax]
+}
+
+// LoadPalette reads .pal file with 64 colors or with
+// all 512 emphasis variants
+func LoadPalette(data []byte) (*Palette, error) {
+	p := new(Palette)
+	switch len(data) {
+	case IndexCount * rgbSize:
+		for i := range p {
+			p[i] = readRGB(data[i*rgbSize:])
+		}
+	case ColorCount * rgbSize:
+		for i := range p {
+			p[i] = emphasize(readRGB(data[i%ColorCount*rgbSize:]),
+				i/ColorCount)
+		}
+	default:
+		return nil, fmt.Errorf(invalidPaletteFormat, len(data),
+			ColorCount*rgbSize, IndexCount*rgbSize)
+	}
+	return p, nil
+}
+
+func readRGB(data []byte) RGB {
+	return RGB{data[0], data[1], data[2]}
+}
+
+// emphasis bits select red, green and blue
+func emphasize(c RGB, emphasis int) RGB {
+	r, g, b := float64(c.R), float64(c.G), float64(c.B)
+	if emphasis&1 != 0 {
+		g, b = g*emphasisAttenuation, b*emphasisAttenuation
+	}
+	if emphasis&2 != 0 {
+		r, b = r*emphasisAttenuation, b*emphasisAttenuation
+	}
+	if emphasis&4 != 0 {
+		r, g = r*emphasisAttenuation, g*emphasisAttenuation
+	}
+	return RGB{byte(r), byte(g), byte(b)}
+}
@@ -0,0 +1,71 @@
+package ppu_test
+
+import (
+	. "github.com/smarkuck/nes/nes/ppu"
+	. "github.com/smarkuck/unittest"
+)
+
+const invalidColorText = "invalid color"
+
+func newPALFile(colors int) []byte {
+	data := make([]byte, 3*colors)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	return data
+}
+
+func Test_Colors_LoadFullPalette(t *T) {
+	p, err := LoadPalette(newPALFile(512))
+
+	ExpectTrue(t, err == nil, invalidErrorText)
+	ExpectEq(t, p.GetRGB(0x001), RGB{3, 4, 5}, invalidColorText)
+	ExpectEq(t, p.GetRGB(0x1ff), RGB{0xfd, 0xfe, 0xff},
+		invalidColorText)
+}
+
+func Test_Colors_LoadPaletteWithoutEmphasis(t *T) {
+	data := newPALFile(64)
+	copy(data[3:], []byte{100, 200, 50})
+	p, err := LoadPalette(data)
+
+	ExpectTrue(t, err == nil, invalidErrorText)
+	tests := []struct {
+		name  string
+		index uint16
+		color RGB
+	}{
+		{"None", 0x001, RGB{100, 200, 50}},
+		{"Red", 0x041, RGB{100, 149, 37}},
+		{"Green", 0x081, RGB{74, 200, 37}},
+		{"Blue", 0x101, RGB{74, 149, 50}},
+		{"All", 0x1c1, RGB{55, 111, 27}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			ExpectEq(t, p.GetRGB(test.index), test.color,
+				invalidColorText)
+		})
+	}
+}
+
+func Test_Colors_WhenSizeInvalid_ReturnError(t *T) {
+	_, err := LoadPalette(newPALFile(65))
+
+	ExpectTrue(t, err != nil, invalidErrorText)
+	ExpectEq(t, err.Error(), "invalid palette size 195, "+
+		"expected 192 or 1536 bytes", invalidErrorText)
+}
+
+func Test_Colors_IgnoreIndexAbovePalette(t *T) {
+	p, _ := LoadPalette(newPALFile(512))
+
+	ExpectEq(t, p.GetRGB(0x201), p.GetRGB(0x001), invalidColorText)
+}
+
+func Test_Colors_DefaultPaletteIsCopy(t *T) {
+	p := DefaultPalette()
+	p[0x0f] = RGB{1, 2, 3}
+
+	ExpectEq(t, DefaultPalette()[0x0f], RGB{}, invalidColorText)
+}
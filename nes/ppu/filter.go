@@ -0,0 +1,144 @@
+package ppu
+
+import "math"
+
+const (
+	// PPU outputs 8 signal samples per pixel, filtered
+	// frame has one column per 4 samples
+	samplesPerPixel  = 8
+	samplesPerColumn = 4
+	lineSamples      = Width * samplesPerPixel
+	FilteredWidth    = lineSamples / samplesPerColumn
+
+	// scanline is 341*8 samples long, so phase moves
+	// by 4 on every scanline
+	linePhaseShift = dotsPerScanline * samplesPerPixel %
+		colorPhases
+	// odd frames are one dot shorter, frames start
+	// alternately in two phases
+	framePhaseShift = 4
+	// chroma has lower bandwidth than luma, colors bleed
+	// into neighbouring pixels
+	chromaWindow = 2 * colorPhases
+)
+
+type FilteredFrame [FilteredWidth * Height]RGB
+
+// FilterParams tune composite video decoding, sharpness
+// and resolution are in range from -1 to 1, artifacts
+// from 0 to 1
+type FilterParams struct {
+	NTSCParams
+	// Sharpness boosts luma edges, negative value blurs them
+	Sharpness float64
+	// Resolution is luma bandwidth, higher value keeps more
+	// detail but lets chroma leak into luma as dot crawl
+	Resolution float64
+	// Artifacts scales colors created by composite signal,
+	// 0 gives plain palette colors
+	Artifacts float64
+}
+
+var DefaultFilter = FilterParams{NTSCParams: DefaultNTSC,
+	Artifacts: 1}
+
+// Filter synthesizes composite signal from palette indices
+// and decodes it back like TV does
+type Filter interface {
+	Apply(f *Frame) *FilteredFrame
+}
+
+type filter struct {
+	params     FilterParams
+	lumaWindow int
+	colors     [IndexCount]yiq
+	levels     [IndexCount][colorPhases]float64
+	line       [lineSamples + 1]yiq
+	frame      FilteredFrame
+	framePhase int
+}
+
+func NewFilter(params FilterParams) Filter {
+	f := &filter{params: params}
+	resolution := math.Max(-1, math.Min(1, params.Resolution))
+	f.lumaWindow = int(math.Round(
+		colorPhases * (1 - resolution/2)))
+	for i := range f.colors {
+		f.colors[i] = decodeYIQ(uint16(i))
+		for phase := range f.levels[i] {
+			f.levels[i][phase] = getLevel(uint16(i), phase)
+		}
+	}
+	return f
+}
+
+// Apply filters every next frame in alternate phase,
+// which makes artifacts crawl like on hardware
+func (f *filter) Apply(frame *Frame) *FilteredFrame {
+	for y := 0; y < Height; y++ {
+		linePhase := (f.framePhase + y*linePhaseShift) %
+			colorPhases
+		indices := frame[y*Width : (y+1)*Width]
+		f.modulate(indices, linePhase)
+		f.demodulate(indices,
+			f.frame[y*FilteredWidth:(y+1)*FilteredWidth])
+	}
+	f.framePhase = framePhaseShift - f.framePhase
+	return &f.frame
+}
+
+// line keeps prefix sums of demodulated samples, so any
+// window can be averaged in constant time
+func (f *filter) modulate(indices []uint16, linePhase int) {
+	for n := 0; n < lineSamples; n++ {
+		phase := (linePhase + n) % colorPhases
+		f.line[n+1] = f.line[n]
+		index := indices[n/samplesPerPixel] & indexMax
+		f.line[n+1].add(f.levels[index][phase], phase)
+	}
+}
+
+func (f *filter) demodulate(indices []uint16, row []RGB) {
+	for x := range row {
+		center := x*samplesPerColumn + samplesPerColumn/2
+		c := f.average(center, chromaWindow)
+		c.y = f.average(center, f.lumaWindow).y
+		wide := f.average(center, 2*f.lumaWindow).y
+		pixel := indices[x*samplesPerColumn/samplesPerPixel]
+		c = f.blend(c, f.colors[pixel&indexMax])
+		c.y += f.params.Sharpness * (c.y - wide)
+		row[x] = c.toRGB(f.params.NTSCParams)
+	}
+}
+
+// average is computed over part of window inside scanline
+func (f *filter) average(center, window int) yiq {
+	start := center - window/2
+	end := start + window
+	start, end = clampSample(start), clampSample(end)
+	return yiq{
+		f.line[end].y - f.line[start].y,
+		f.line[end].i - f.line[start].i,
+		f.line[end].q - f.line[start].q,
+	}.scale(1 / float64(end-start))
+}
+
+func clampSample(n int) int {
+	if n < 0 {
+		return 0
+	}
+	if n > lineSamples {
+		return lineSamples
+	}
+	return n
+}
+
+// blend mixes decoded color with the one of pixel under it
+func (f *filter) blend(c, clean yiq) yiq {
+	a := f.params.Artifacts
+	return yiq{
+		clean.y + a*(c.y-clean.y),
+		clean.i + a*(c.i-clean.i),
+		clean.q + a*(c.q-clean.q),
+	}
+}
@@ -0,0 +1,133 @@
+package ppu_test
+
+import (
+	. "github.com/smarkuck/nes/nes/ppu"
+	. "github.com/smarkuck/unittest"
+)
+
+const (
+	grey  = 0x10
+	black = 0x0f
+	// column far from scanline edges
+	middle = FilteredWidth / 2
+)
+
+func newFilledFrame(color uint16) *Frame {
+	f := new(Frame)
+	for i := range f {
+		f[i] = color
+	}
+	return f
+}
+
+// single pixel columns alternate between two colors
+func newStripedFrame(first, second uint16) *Frame {
+	f := newFilledFrame(first)
+	for i := 1; i < len(f); i += 2 {
+		f[i] = second
+	}
+	return f
+}
+
+func getRow(f *FilteredFrame, y int) []RGB {
+	return f[y*FilteredWidth : (y+1)*FilteredWidth]
+}
+
+func isClose(a, b RGB) bool {
+	return isNear(a.R, b.R) && isNear(a.G, b.G) && isNear(a.B, b.B)
+}
+
+func isNear(a, b byte) bool {
+	return int(a)-int(b) <= 2 && int(b)-int(a) <= 2
+}
+
+func isGrey(c RGB) bool {
+	return isClose(c, RGB{c.R, c.R, c.R})
+}
+
+// highest brightness difference between neighbouring columns
+func getContrast(row []RGB) int {
+	contrast := 0
+	for x := middle; x < middle+16; x++ {
+		diff := brightness(row[x]) - brightness(row[x+1])
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > contrast {
+			contrast = diff
+		}
+	}
+	return contrast
+}
+
+func Test_Filter_FlatColorKeepsPalette(t *T) {
+	p := GeneratePalette(DefaultNTSC)
+
+	for _, color := range []uint16{red, white, 0x12, 0x2a} {
+		f := NewFilter(DefaultFilter).Apply(newFilledFrame(color))
+		ExpectTrue(t, isClose(f[middle], p[color]), invalidColorText)
+	}
+}
+
+func Test_Filter_StripesCreateArtifactColors(t *T) {
+	f := NewFilter(DefaultFilter).Apply(newStripedFrame(white, black))
+
+	ExpectFalse(t, isGrey(f[middle]), invalidColorText)
+}
+
+func Test_Filter_NoArtifacts_UsePalette(t *T) {
+	p := GeneratePalette(DefaultNTSC)
+	params := DefaultFilter
+	params.Artifacts = 0
+	f := NewFilter(params).Apply(newStripedFrame(white, black))
+
+	ExpectEq(t, f[middle], p[white], invalidColorText)
+	ExpectEq(t, f[middle+2], p[black], invalidColorText)
+}
+
+func Test_Filter_AlternatePhaseEveryFrame(t *T) {
+	filter := NewFilter(DefaultFilter)
+	frame := newStripedFrame(white, black)
+
+	first := *filter.Apply(frame)
+	second := *filter.Apply(frame)
+	third := *filter.Apply(frame)
+
+	ExpectTrue(t, first != second, invalidColorText)
+	ExpectTrue(t, first == third, invalidColorText)
+}
+
+func Test_Filter_ShiftPhaseEveryScanline(t *T) {
+	f := NewFilter(DefaultFilter).Apply(newStripedFrame(white, black))
+
+	ExpectTrue(t, f[middle] != getRow(f, 1)[middle],
+		invalidColorText)
+	ExpectEq(t, f[middle], getRow(f, 3)[middle], invalidColorText)
+}
+
+func Test_Filter_Resolution(t *T) {
+	low, high := DefaultFilter, DefaultFilter
+	low.Resolution, high.Resolution = -1, 1
+	frame := newStripedFrame(grey, black)
+
+	blurred := NewFilter(low).Apply(frame)
+	detailed := NewFilter(high).Apply(frame)
+
+	ExpectTrue(t, getContrast(detailed[:]) > getContrast(blurred[:]),
+		invalidColorText)
+}
+
+func Test_Filter_Sharpness(t *T) {
+	sharp := DefaultFilter
+	sharp.Sharpness = 1
+	frame := newFilledFrame(black)
+	for x := Width / 2; x < Width; x++ {
+		frame[x] = grey
+	}
+
+	plain := NewFilter(DefaultFilter).Apply(frame)
+	sharpened := NewFilter(sharp).Apply(frame)
+
+	ExpectTrue(t, getContrast(sharpened[:]) > getContrast(plain[:]),
+		invalidColorText)
+}
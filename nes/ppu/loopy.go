@@ -0,0 +1,90 @@
+package ppu
+
+// VRAM address layout: yyy NN YYYYY XXXXX
+// fine Y, nametable, coarse Y, coarse X
+const (
+	coarseXMask    = 0x001f
+	coarseYMask    = 0x03e0
+	nametableMask  = 0x0c00
+	fineYMask      = 0x7000
+	fineMask       = 0x07
+	ctrlNametables = 0x03
+	highAddrMask   = 0x3f
+
+	horizontalNametable = 0x0400
+	verticalNametable   = 0x0800
+	fineYOne            = 0x1000
+	coarseYOne          = 0x0020
+	lastRow             = 29
+	maxRow              = 31
+)
+
+func (p *ppu) writeCtrl(value byte) {
+	p.ctrl = value
+	p.T = p.T&^nametableMask |
+		uint16(value&ctrlNametables)<<10
+}
+
+func (p *ppu) writeScroll(value byte) {
+	if !p.W {
+		p.T = p.T&^coarseXMask | uint16(value>>3)
+		p.X = value & fineMask
+	} else {
+		p.T = p.T&^(fineYMask|coarseYMask) |
+			uint16(value&fineMask)<<12 |
+			uint16(value>>3)<<5
+	}
+	p.W = !p.W
+}
+
+// second write copies t to v
+func (p *ppu) writeAddress(value byte) {
+	if !p.W {
+		p.T = p.T&0x00ff | uint16(value&highAddrMask)<<8
+	} else {
+		p.T = p.T&0xff00 | uint16(value)
+		p.V = p.T
+	}
+	p.W = !p.W
+}
+
+// coarse X wraps into horizontal nametable
+func (p *ppu) incrementX() {
+	if p.V&coarseXMask == coarseXMask {
+		p.V = p.V&^coarseXMask ^ horizontalNametable
+	} else {
+		p.V++
+	}
+}
+
+// only 30 rows are visible, rows 30 and 31 hold attributes
+// and wrap without switching nametable
+func (p *ppu) incrementY() {
+	if p.V&fineYMask != fineYMask {
+		p.V += fineYOne
+		return
+	}
+	p.V &^= fineYMask
+	switch p.getCoarseY() {
+	case lastRow:
+		p.V = p.V&^coarseYMask ^ verticalNametable
+	case maxRow:
+		p.V &^= coarseYMask
+	default:
+		p.V += coarseYOne
+	}
+}
+
+func (p *ppu) getCoarseY() uint16 {
+	return p.V & coarseYMask >> 5
+}
+
+func (p *ppu) copyX() {
+	mask := uint16(coarseXMask | horizontalNametable)
+	p.V = p.V&^mask | p.T&mask
+}
+
+func (p *ppu) copyY() {
+	mask := uint16(fineYMask | coarseYMask | verticalNametable)
+	p.V = p.V&^mask | p.T&mask
+}
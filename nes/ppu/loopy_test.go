@@ -0,0 +1,48 @@
+package ppu_test
+
+import (
+	. "github.com/smarkuck/nes/nes/ppu"
+	. "github.com/smarkuck/unittest"
+)
+
+func Test_Loopy_WriteCtrl_SetNametable(t *T) {
+	p, _ := newPPU()
+	p.Write(ppuCtrl, 0xff)
+
+	ExpectEq(t, p.GetRegisters().T, 0x0c00, invalidRegisterText)
+}
+
+func Test_Loopy_WriteScroll(t *T) {
+	p, _ := newPPU()
+	p.Write(ppuScroll, 0x7d)
+	ExpectEq(t, p.GetRegisters(), Registers{T: 0x000f, X: 5, W: true},
+		invalidRegisterText)
+
+	p.Write(ppuScroll, 0x5e)
+	ExpectEq(t, p.GetRegisters(), Registers{T: 0x616f, X: 5},
+		invalidRegisterText)
+}
+
+func Test_Loopy_WriteAddress(t *T) {
+	p, _ := newPPU()
+	p.Write(ppuAddr, 0xff)
+	ExpectEq(t, p.GetRegisters(), Registers{T: 0x3f00, W: true},
+		invalidRegisterText)
+
+	p.Write(ppuAddr, 0x12)
+	ExpectEq(t, p.GetRegisters(), Registers{V: 0x3f12, T: 0x3f12},
+		invalidRegisterText)
+}
+
+// scroll and address share temporary register and toggle
+func Test_Loopy_MixScrollAndAddressWrites(t *T) {
+	p, _ := newPPU()
+	p.Write(ppuCtrl, 0x01)
+	p.Write(ppuAddr, 0x04)
+	p.Write(ppuScroll, 0x3e)
+	p.Write(ppuScroll, 0x7d)
+	p.Write(ppuAddr, 0xef)
+
+	ExpectEq(t, p.GetRegisters(), Registers{V: 0x64ef, T: 0x64ef,
+		X: 5}, invalidRegisterText)
+}
@@ -0,0 +1,141 @@
+package ppu
+
+import "math"
+
+const (
+	// signal voltages relative to sync level
+	blackLevel = 0.518
+	whiteLevel = 1.962
+	// 12 samples cover one color subcarrier period
+	colorPhases   = 12
+	phaseDuration = colorPhases / 2
+	// color 8 is in phase with color burst
+	burstPhase = 8
+	// CRT gamma of NTSC decoding versus display gamma
+	gammaCorrection = 2.2 / 1.8
+)
+
+var (
+	lowLevels  = [4]float64{0.350, 0.518, 0.962, 1.550}
+	highLevels = [4]float64{1.094, 1.506, 1.962, 1.962}
+
+	phaseCos, phaseSin = getPhaseWaves()
+)
+
+// NTSCParams are decoder settings like on TV, hue is in
+// degrees, other values are multipliers except brightness
+// which is added to luma
+type NTSCParams struct {
+	Hue        float64
+	Saturation float64
+	Contrast   float64
+	Brightness float64
+}
+
+var DefaultNTSC = NTSCParams{Saturation: 1, Contrast: 1}
+
+// GeneratePalette decodes square wave signal generated by
+// PPU for every color and emphasis combination
+func GeneratePalette(params NTSCParams) *Palette {
+	p := new(Palette)
+	for i := range p {
+		p[i] = decodeNTSC(uint16(i), params)
+	}
+	return p
+}
+
+func decodeNTSC(index uint16, params NTSCParams) RGB {
+	return decodeYIQ(index).toRGB(params)
+}
+
+// decodeYIQ averages signal over one subcarrier period
+func decodeYIQ(index uint16) yiq {
+	var c yiq
+	for phase := 0; phase < colorPhases; phase++ {
+		c.add(getLevel(index, phase), phase)
+	}
+	return c.scale(1.0 / colorPhases)
+}
+
+type yiq struct {
+	y, i, q float64
+}
+
+// add demodulates one sample of signal in given phase
+func (c *yiq) add(level float64, phase int) {
+	c.y += level
+	c.i += level * phaseCos[phase]
+	c.q += level * phaseSin[phase]
+}
+
+func (c yiq) scale(factor float64) yiq {
+	return yiq{c.y * factor, c.i * factor, c.q * factor}
+}
+
+func (c yiq) toRGB(params NTSCParams) RGB {
+	hue := params.Hue * math.Pi / 180
+	chroma := params.Saturation * params.Contrast
+	i := (c.i*math.Cos(hue) - c.q*math.Sin(hue)) * chroma
+	q := (c.i*math.Sin(hue) + c.q*math.Cos(hue)) * chroma
+	y := c.y*params.Contrast + params.Brightness
+	return RGB{
+		toChannel(y + 0.946882*i + 0.623557*q),
+		toChannel(y - 0.274788*i - 0.635691*q),
+		toChannel(y - 1.108545*i + 1.709007*q),
+	}
+}
+
+// getLevel returns signal scaled so black is 0 and white 1
+func getLevel(index uint16, phase int) float64 {
+	return (getSignal(index, phase) - blackLevel) /
+		(whiteLevel - blackLevel)
+}
+
+// colors 0 and 13-15 are flat, 14 and 15 are forced black
+func getSignal(index uint16, phase int) float64 {
+	color, level := int(index&0x0f), index>>4&0x03
+	emphasis := index >> emphasisIndexShift
+	if color > 13 {
+		level = 1
+	}
+	low, high := lowLevels[level], highLevels[level]
+	if color == 0 {
+		low = high
+	}
+	if color > 12 {
+		high = low
+	}
+	signal := low
+	if isInColorPhase(color, phase) {
+		signal = high
+	}
+	if emphasis&1 != 0 && isInColorPhase(0, phase) ||
+		emphasis&2 != 0 && isInColorPhase(4, phase) ||
+		emphasis&4 != 0 && isInColorPhase(8, phase) {
+		signal *= emphasisAttenuation
+	}
+	return signal
+}
+
+func getPhaseWaves() (cos, sin [colorPhases]float64) {
+	for phase := range cos {
+		angle := math.Pi * float64(phase-burstPhase) / phaseDuration
+		cos[phase], sin[phase] = math.Cos(angle), math.Sin(angle)
+	}
+	return cos, sin
+}
+
+func isInColorPhase(color, phase int) bool {
+	return (color+phase)%colorPhases < phaseDuration
+}
+
+func toChannel(value float64) byte {
+	if value <= 0 {
+		return 0
+	}
+	value = 255 * math.Pow(value, gammaCorrection)
+	if value >= 255 {
+		return 255
+	}
+	return byte(value)
+}
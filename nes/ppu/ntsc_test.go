@@ -0,0 +1,66 @@
+package ppu_test
+
+import (
+	. "github.com/smarkuck/nes/nes/ppu"
+	. "github.com/smarkuck/unittest"
+)
+
+func isDominant(c RGB, channel byte) bool {
+	sum := int(c.R) + int(c.G) + int(c.B)
+	return 2*int(channel) > sum-int(channel)
+}
+
+func brightness(c RGB) int {
+	return int(c.R) + int(c.G) + int(c.B)
+}
+
+func Test_NTSC_GenerateColors(t *T) {
+	p := GeneratePalette(DefaultNTSC)
+
+	ExpectEq(t, p[0x0f], RGB{}, invalidColorText)
+	ExpectEq(t, p[0x1e], RGB{}, invalidColorText)
+	ExpectTrue(t, brightness(p[0x20]) > 3*250, invalidColorText)
+	ExpectTrue(t, p[0x00].R == p[0x00].G && p[0x00].G == p[0x00].B,
+		invalidColorText)
+	ExpectTrue(t, isDominant(p[0x16], p[0x16].R), invalidColorText)
+	ExpectTrue(t, isDominant(p[0x2a], p[0x2a].G), invalidColorText)
+	ExpectTrue(t, isDominant(p[0x12], p[0x12].B), invalidColorText)
+}
+
+func Test_NTSC_BrighterLevels(t *T) {
+	p := GeneratePalette(DefaultNTSC)
+
+	for color := uint16(0); color < 0x0d; color++ {
+		for level := uint16(0); level < 0x20; level += 0x10 {
+			ExpectTrue(t, brightness(p[color+level]) <
+				brightness(p[color+level+0x10]), invalidColorText)
+		}
+	}
+}
+
+func Test_NTSC_EmphasisDarkensColors(t *T) {
+	p := GeneratePalette(DefaultNTSC)
+
+	for emphasis := uint16(1); emphasis < 8; emphasis++ {
+		ExpectTrue(t, brightness(p[emphasis<<6|0x30]) <
+			brightness(p[0x30]), invalidColorText)
+	}
+	red := p[0x40|0x30]
+	ExpectTrue(t, isDominant(red, red.R), invalidColorText)
+}
+
+func Test_NTSC_Params(t *T) {
+	grey := GeneratePalette(NTSCParams{Contrast: 1})
+	bright := GeneratePalette(NTSCParams{Saturation: 1, Contrast: 1,
+		Brightness: 0.2})
+	rotated := GeneratePalette(NTSCParams{Hue: 120, Saturation: 1,
+		Contrast: 1})
+	p := GeneratePalette(DefaultNTSC)
+
+	c := grey[0x16]
+	ExpectTrue(t, c.R == c.G && c.G == c.B, invalidColorText)
+	ExpectTrue(t, brightness(bright[0x16]) > brightness(p[0x16]),
+		invalidColorText)
+	ExpectFalse(t, isDominant(rotated[0x16], rotated[0x16].R),
+		invalidColorText)
+}
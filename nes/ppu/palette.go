@@ -0,0 +1,49 @@
+package ppu
+
+const (
+	paletteSize = 0x20
+	paletteMask = 0x3f
+	// backdrop entries of sprite palettes are shared
+	// with background ones
+	backdropMirrorMask = 0x13
+	backdropMirror     = 0x10
+
+	maskGreyscale = 0x01
+	greyscaleMask = 0x30
+	emphasisShift = 5
+	// emphasis bits extend 6-bit color into 9-bit index
+	emphasisIndexShift = 6
+)
+
+type paletteRAM [paletteSize]byte
+
+func getPaletteIndex(addr uint16) uint16 {
+	index := addr % paletteSize
+	if index&backdropMirrorMask == backdropMirror {
+		index &^= backdropMirror
+	}
+	return index
+}
+
+func (p *paletteRAM) read(addr uint16) byte {
+	return p[getPaletteIndex(addr)]
+}
+
+func (p *paletteRAM) write(addr uint16, value byte) {
+	p[getPaletteIndex(addr)] = value & paletteMask
+}
+
+// greyscale keeps only brightness of color
+func (p *ppu) readPalette(addr uint16) byte {
+	color := p.paletteRAM.read(addr)
+	if p.mask&maskGreyscale != 0 {
+		color &= greyscaleMask
+	}
+	return color
+}
+
+func (p *ppu) getColorIndex(pixel byte) uint16 {
+	emphasis := uint16(p.mask >> emphasisShift)
+	return uint16(p.readPalette(paletteAddr+uint16(pixel))) |
+		emphasis<<emphasisIndexShift
+}
@@ -0,0 +1,71 @@
+package ppu_test
+
+import (
+	. "github.com/smarkuck/unittest"
+)
+
+func Test_Palette_MirrorBackdropColors(t *T) {
+	tests := []struct {
+		name   string
+		mirror uint16
+		addr   uint16
+	}{
+		{"Sprite0", 0x3f10, 0x3f00},
+		{"Sprite1", 0x3f14, 0x3f04},
+		{"Sprite2", 0x3f18, 0x3f08},
+		{"Sprite3", 0x3f1c, 0x3f0c},
+		{"Upper", 0x3fe1, 0x3f01},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			p, _ := newPPU()
+			setAddress(p, test.mirror)
+			p.Write(ppuData, 0x2a)
+			setAddress(p, test.addr)
+			ExpectEq(t, p.Read(ppuData), 0x2a, invalidRegisterText)
+		})
+	}
+}
+
+func Test_Palette_SpriteColorsAreSeparate(t *T) {
+	p, _ := newPPU()
+	setAddress(p, 0x3f11)
+	p.Write(ppuData, 0x2a)
+	setAddress(p, 0x3f01)
+
+	ExpectEq(t, p.Read(ppuData), 0x00, invalidRegisterText)
+}
+
+func Test_Palette_Greyscale(t *T) {
+	p, _ := newPPU()
+	setAddress(p, 0x3f01)
+	p.Write(ppuData, 0x2a)
+	p.Write(ppuMask, 0x01)
+	setAddress(p, 0x3f01)
+
+	ExpectEq(t, p.Read(ppuData), 0x20, invalidRegisterText)
+}
+
+func Test_Palette_GreyscaleAndEmphasisInFrame(t *T) {
+	tests := []struct {
+		name  string
+		mask  byte
+		color uint16
+	}{
+		{"Normal", 0x00, 0x021},
+		{"Greyscale", 0x01, 0x020},
+		{"Red", 0x20, 0x061},
+		{"All", 0xe0, 0x1e1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			p, _ := newBackgroundPPU()
+			setAddress(p, 0x3f01)
+			p.Write(ppuData, 0x21)
+			f := renderFrame(p, background|leftColumn|test.mask, 0, 0)
+			ExpectEq(t, f[0], test.color, invalidPixelText)
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package ppu
+
+import "github.com/smarkuck/nes/nes"
+
+const (
+	registerCount = 8
+	oamSize       = 0x100
+	vramMask      = 0x3fff
+	paletteAddr   = 0x3f00
+	// nametables are mirrored under palette
+	nametableMirror = 0x1000
+)
+
+const (
+	ctrlRegister = iota
+	maskRegister
+	statusRegister
+	oamAddrRegister
+	oamDataRegister
+	scrollRegister
+	addrRegister
+	dataRegister
+)
+
+const (
+	ctrlIncrement32 = 0x04
+
+	statusOverflow    = 0x20
+	statusSprite0Hit  = 0x40
+	statusVBlank      = 0x80
+	statusOpenBusMask = 0x1f
+
+	// unimplemented attribute bits always read as 0
+	attributeByte = 2
+	attributeMask = 0xe3
+)
+
+type PPU interface {
+	nes.Bus
+	nes.NMISource
+	Tick()
+	GetRegisters() Registers
+	GetFrame() *Frame
+	GetFrameCount() int
+	SetSpriteLimit(isEnabled bool)
+}
+
+// Peeker reads VRAM without side effects seen by mappers
+type Peeker interface {
+	Peek(addr uint16) byte
+}
+
+type busPeeker struct {
+	nes.Bus
+}
+
+func (b busPeeker) Peek(addr uint16) byte {
+	return b.Read(addr)
+}
+
+// Registers are internal loopy registers used for
+// scrolling and VRAM access
+type Registers struct {
+	V uint16
+	T uint16
+	X byte
+	W bool
+}
+
+type ppu struct {
+	Registers
+	timing
+	vram       nes.Bus
+	paletteRAM paletteRAM
+	bg         background
+	sp         sprites
+	frame      Frame
+	oam        [oamSize]byte
+	ctrl       byte
+	mask       byte
+	status     byte
+	oamAddr    byte
+	readBuffer byte
+	ioLatch    byte
+}
+
+// NewPPU takes VRAM with pattern tables and nametables,
+// palette RAM is part of PPU
+func NewPPU(vram nes.Bus, region nes.Region) PPU {
+	p := &ppu{vram: vram}
+	p.regionTiming = regionTimings[region]
+	return p
+}
+
+// SetSpriteLimit with false draws all sprites on scanline,
+// evaluation and overflow flag still work as on hardware
+func (p *ppu) SetSpriteLimit(isEnabled bool) {
+	p.sp.isUnlimited = !isEnabled
+}
+
+func (p *ppu) GetRegisters() Registers {
+	return p.Registers
+}
+
+// write-only registers return last value put on PPU
+// data bus
+func (p *ppu) Read(addr uint16) byte {
+	switch addr % registerCount {
+	case statusRegister:
+		p.ioLatch = p.readStatus() | p.ioLatch&statusOpenBusMask
+	case oamDataRegister:
+		p.ioLatch = p.readOAM()
+	case dataRegister:
+		p.ioLatch = p.readData()
+	}
+	return p.ioLatch
+}
+
+func (p *ppu) readStatus() byte {
+	p.blockVBlank()
+	value := p.status
+	p.status &^= statusVBlank
+	p.W = false
+	return value
+}
+
+func (p *ppu) readOAM() byte {
+	if p.oamAddr%4 == attributeByte {
+		return p.oam[p.oamAddr] & attributeMask
+	}
+	return p.oam[p.oamAddr]
+}
+
+// reads are delayed by internal buffer except palette,
+// buffer gets nametable byte under the palette then
+func (p *ppu) readData() byte {
+	addr := p.V & vramMask
+	value := p.readBuffer
+	if addr >= paletteAddr {
+		value = p.readPalette(addr) | p.ioLatch&^paletteMask
+		p.readBuffer = p.vram.Read(addr - nametableMirror)
+	} else {
+		p.readBuffer = p.vram.Read(addr)
+	}
+	p.incrementAddress()
+	return value
+}
+
+func (p *ppu) Write(addr uint16, value byte) {
+	p.ioLatch = value
+	switch addr % registerCount {
+	case ctrlRegister:
+		p.writeCtrl(value)
+	case maskRegister:
+		p.mask = value
+	case oamAddrRegister:
+		p.oamAddr = value
+	case oamDataRegister:
+		p.oam[p.oamAddr] = value
+		p.oamAddr++
+	case scrollRegister:
+		p.writeScroll(value)
+	case addrRegister:
+		p.writeAddress(value)
+	case dataRegister:
+		p.writeData(value)
+	}
+}
+
+func (p *ppu) writeData(value byte) {
+	addr := p.V & vramMask
+	if addr >= paletteAddr {
+		p.paletteRAM.write(addr, value)
+	} else {
+		p.vram.Write(addr, value)
+	}
+	p.incrementAddress()
+}
+
+// during rendering address is moved by scroll increments
+func (p *ppu) incrementAddress() {
+	if p.isRenderingScanline() {
+		p.incrementX()
+		p.incrementY()
+		return
+	}
+	if p.ctrl&ctrlIncrement32 != 0 {
+		p.V += 32
+	} else {
+		p.V++
+	}
+}
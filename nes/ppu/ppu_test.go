@@ -0,0 +1,149 @@
+package ppu_test
+
+import (
+	"github.com/smarkuck/nes/nes"
+	. "github.com/smarkuck/nes/nes/ppu"
+	. "github.com/smarkuck/unittest"
+)
+
+const (
+	ppuCtrl     = 0x2000
+	ppuMask     = 0x2001
+	ppuStatus   = 0x2002
+	oamAddr     = 0x2003
+	oamData     = 0x2004
+	ppuScroll   = 0x2005
+	ppuAddr     = 0x2006
+	ppuData     = 0x2007
+	increment32 = 0x04
+
+	invalidRegisterText = "invalid register value"
+	invalidVRAMText     = "invalid VRAM"
+	invalidErrorText    = "invalid error"
+)
+
+type memoryBus [0x4000]byte
+
+func (m *memoryBus) Read(addr uint16) byte {
+	return m[addr]
+}
+
+func (m *memoryBus) Write(addr uint16, value byte) {
+	m[addr] = value
+}
+
+func newPPU() (PPU, *memoryBus) {
+	m := new(memoryBus)
+	return NewPPU(m, nes.NTSC), m
+}
+
+func setAddress(p PPU, addr uint16) {
+	p.Write(ppuAddr, byte(addr>>8))
+	p.Write(ppuAddr, byte(addr))
+}
+
+func Test_PPU_WriteData(t *T) {
+	p, m := newPPU()
+	setAddress(p, 0x2400)
+	p.Write(ppuData, 0x12)
+	p.Write(ppuData, 0x34)
+
+	ExpectEq(t, m[0x2400], 0x12, invalidVRAMText)
+	ExpectEq(t, m[0x2401], 0x34, invalidVRAMText)
+	ExpectEq(t, p.GetRegisters().V, 0x2402, invalidRegisterText)
+}
+
+func Test_PPU_IncrementAddressBy32(t *T) {
+	p, m := newPPU()
+	p.Write(ppuCtrl, increment32)
+	setAddress(p, 0x2000)
+	p.Write(ppuData, 0x12)
+	p.Write(ppuData, 0x34)
+
+	ExpectEq(t, m[0x2020], 0x34, invalidVRAMText)
+	ExpectEq(t, p.GetRegisters().V, 0x2040, invalidRegisterText)
+}
+
+func Test_PPU_ReadDataThroughBuffer(t *T) {
+	p, m := newPPU()
+	m[0x1000], m[0x1001] = 0x12, 0x34
+	setAddress(p, 0x1000)
+
+	p.Read(ppuData)
+	ExpectEq(t, p.Read(ppuData), 0x12, invalidRegisterText)
+	ExpectEq(t, p.Read(ppuData), 0x34, invalidRegisterText)
+}
+
+func Test_PPU_ReadPaletteWithoutBuffer(t *T) {
+	p, m := newPPU()
+	m[0x2f01] = 0x55
+	setAddress(p, 0x3f01)
+	p.Write(ppuData, 0x21)
+	setAddress(p, 0x3f01)
+
+	ExpectEq(t, p.Read(ppuData), 0x21, invalidRegisterText)
+	setAddress(p, 0x0000)
+	ExpectEq(t, p.Read(ppuData), 0x55, invalidRegisterText)
+}
+
+func Test_PPU_PaletteKeepsOpenBusHighBits(t *T) {
+	p, _ := newPPU()
+	setAddress(p, 0x3f00)
+	p.Write(ppuData, 0xff)
+	setAddress(p, 0x3f00)
+	p.Write(ppuMask, 0x80)
+
+	ExpectEq(t, p.Read(ppuData), 0xbf, invalidRegisterText)
+}
+
+func Test_PPU_ReadWriteOAM(t *T) {
+	p, _ := newPPU()
+	p.Write(oamAddr, 0x10)
+	for _, value := range []byte{1, 2, 0xff, 4} {
+		p.Write(oamData, value)
+	}
+
+	for i, value := range []byte{1, 2, 0xe3, 4} {
+		p.Write(oamAddr, 0x10+byte(i))
+		ExpectEq(t, p.Read(oamData), value, invalidRegisterText)
+	}
+}
+
+func Test_PPU_ReadOAMDoesNotIncrementAddress(t *T) {
+	p, _ := newPPU()
+	p.Write(oamData, 0x12)
+	p.Write(oamAddr, 0)
+	p.Read(oamData)
+
+	ExpectEq(t, p.Read(oamData), 0x12, invalidRegisterText)
+}
+
+func Test_PPU_ReadWriteOnlyRegisters_ReturnLastWrite(t *T) {
+	p, _ := newPPU()
+	p.Write(ppuMask, 0x5a)
+
+	for _, addr := range []uint16{ppuCtrl, ppuMask, oamAddr,
+		ppuScroll, ppuAddr} {
+		ExpectEq(t, p.Read(addr), 0x5a, invalidRegisterText)
+	}
+	ExpectEq(t, p.Read(ppuStatus), 0x1a, invalidRegisterText)
+}
+
+func Test_PPU_ReadStatus_ResetWriteToggle(t *T) {
+	p, _ := newPPU()
+	p.Write(ppuAddr, 0x21)
+	p.Read(ppuStatus)
+	setAddress(p, 0x2345)
+
+	ExpectEq(t, p.GetRegisters().V, 0x2345, invalidRegisterText)
+	ExpectFalse(t, p.GetRegisters().W, invalidRegisterText)
+}
+
+func Test_PPU_RegistersMirrored(t *T) {
+	p, m := newPPU()
+	p.Write(0x3ffe, 0x21)
+	p.Write(0x200e, 0x08)
+	p.Write(0x3fff, 0x77)
+
+	ExpectEq(t, m[0x2108], 0x77, invalidVRAMText)
+}
@@ -0,0 +1,89 @@
+package ppu
+
+const (
+	Width  = 256
+	Height = 240
+
+	visibleDots   = 256
+	prefetchStart = 321
+	prefetchEnd   = 336
+	copyXDot      = 257
+	copyYStart    = 280
+	copyYEnd      = 304
+	dummyFetch    = 337
+)
+
+// Frame holds 9-bit indices into system palette, color
+// from palette RAM with emphasis bits on top
+type Frame [Width * Height]uint16
+
+func (p *ppu) GetFrame() *Frame {
+	return &p.frame
+}
+
+func (p *ppu) render() {
+	isVisible := p.scanline < Height
+	if !isVisible && !p.isPreRenderScanline() {
+		return
+	}
+	if p.isRendering() {
+		p.renderBackground()
+		p.renderSprites(isVisible)
+	}
+	if isVisible && p.dot >= 1 && p.dot <= visibleDots {
+		p.renderPixel(p.dot - 1)
+	}
+}
+
+// two unused nametable fetches end the scanline, some
+// mappers use them to detect scanlines
+func (p *ppu) renderBackground() {
+	switch {
+	case p.dot >= 1 && p.dot <= visibleDots,
+		p.dot >= prefetchStart && p.dot <= prefetchEnd:
+		p.updateShifters()
+		p.fetchBackground()
+	case p.dot == copyXDot:
+		p.updateShifters()
+		p.copyX()
+	case p.dot == dummyFetch:
+		p.updateShifters()
+		p.fetchNametable()
+	case p.dot == dummyFetch+2:
+		p.fetchNametable()
+	}
+	switch {
+	case p.dot == visibleDots:
+		p.incrementY()
+	case p.isPreRenderScanline() &&
+		p.dot >= copyYStart && p.dot <= copyYEnd:
+		p.copyY()
+	}
+}
+
+// OAM address is reset while sprites are fetched
+func (p *ppu) renderSprites(isVisible bool) {
+	switch {
+	case p.dot == visibleDots && isVisible:
+		p.evaluateSprites()
+	case p.dot == visibleDots:
+		p.sp.secondary = p.sp.secondary[:0]
+		p.sp.hasSprite0 = false
+	case p.dot >= copyXDot && p.dot < copyXDot+spriteFetches:
+		p.oamAddr = 0
+		p.fetchSprite()
+	}
+}
+
+func (p *ppu) renderPixel(x int) {
+	bg := p.getBackgroundPixel(x)
+	sprite, attr, isSprite0 := p.getSpritePixel(x)
+	if bg != 0 && sprite != 0 && isSprite0 && x != lastHitX {
+		p.status |= statusSprite0Hit
+	}
+	pixel := sprite
+	if sprite == 0 || bg != 0 && attr&attrPriority != 0 {
+		pixel = bg
+	}
+	p.frame[p.scanline*Width+x] = p.getColorIndex(pixel)
+}
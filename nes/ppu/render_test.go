@@ -0,0 +1,147 @@
+package ppu_test
+
+import (
+	. "github.com/smarkuck/nes/nes/ppu"
+	. "github.com/smarkuck/unittest"
+)
+
+const (
+	backdrop   = 0x0f
+	white      = 0x30
+	red        = 0x16
+	background = 0x08
+	leftColumn = 0x02
+
+	invalidPixelText = "invalid pixel"
+)
+
+// tile 1 has left half in color 1, tile 2 is filled with it,
+// nametable rows alternate between them
+func newBackgroundPPU() (PPU, *memoryBus) {
+	p, m := newPPU()
+	for row := 0; row < 8; row++ {
+		m[0x10+row], m[0x20+row] = 0xf0, 0xff
+	}
+	for i := 0; i < 0x3c0; i++ {
+		m[0x2000+i] = byte(1 + i/32%2)
+	}
+	setAddress(p, 0x3f00)
+	for _, color := range []byte{backdrop, white} {
+		p.Write(ppuData, color)
+	}
+	setAddress(p, 0x3f09)
+	p.Write(ppuData, red)
+	return p, m
+}
+
+func renderFrame(p PPU, mask byte, scrollX, scrollY byte) *Frame {
+	p.Write(ppuScroll, scrollX)
+	p.Write(ppuScroll, scrollY)
+	p.Write(ppuCtrl, 0)
+	p.Write(ppuMask, mask)
+	tick(p, 2*frameDots)
+	return p.GetFrame()
+}
+
+func expectRow(t *T, f *Frame, y int, colors ...uint16) {
+	t.Helper()
+	for x, color := range colors {
+		ExpectEq(t, f[y*Width+x], color, invalidPixelText)
+	}
+}
+
+func repeatColor(color uint16, count int) []uint16 {
+	colors := make([]uint16, count)
+	for i := range colors {
+		colors[i] = color
+	}
+	return colors
+}
+
+func tile(left, right uint16) []uint16 {
+	return append(repeatColor(left, 4), repeatColor(right, 4)...)
+}
+
+func Test_Render_Background(t *T) {
+	p, _ := newBackgroundPPU()
+	f := renderFrame(p, background|leftColumn, 0, 0)
+
+	expectRow(t, f, 0, tile(white, backdrop)...)
+	expectRow(t, f, 7, tile(white, backdrop)...)
+	expectRow(t, f, 8, repeatColor(white, 8)...)
+	expectRow(t, f, 239, repeatColor(white, 8)...)
+}
+
+func Test_Render_BackgroundDisabled(t *T) {
+	p, _ := newBackgroundPPU()
+	f := renderFrame(p, 0, 0, 0)
+
+	for _, color := range f {
+		ExpectEq(t, color, backdrop, invalidPixelText)
+	}
+}
+
+func Test_Render_ClipLeftColumn(t *T) {
+	p, _ := newBackgroundPPU()
+	f := renderFrame(p, background, 0, 0)
+
+	expectRow(t, f, 0, append(repeatColor(backdrop, 8),
+		tile(white, backdrop)...)...)
+}
+
+func Test_Render_Attributes(t *T) {
+	p, m := newBackgroundPPU()
+	m[0x23c0] = 0x02
+	f := renderFrame(p, background|leftColumn, 0, 0)
+
+	expectRow(t, f, 0, append(append(tile(red, backdrop),
+		tile(red, backdrop)...), tile(white, backdrop)...)...)
+	expectRow(t, f, 16, tile(white, backdrop)...)
+}
+
+func Test_Render_Scroll(t *T) {
+	tests := []struct {
+		name string
+		x, y byte
+		row  []uint16
+	}{
+		{"FineX", 2, 0, []uint16{white, white, backdrop, backdrop,
+			backdrop, backdrop, white, white}},
+		{"CoarseX", 8, 0, tile(white, backdrop)},
+		{"FineY", 0, 5, []uint16{white, white, white, white,
+			backdrop, backdrop, backdrop, backdrop}},
+		{"CoarseY", 0, 8, repeatColor(white, 8)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			p, _ := newBackgroundPPU()
+			f := renderFrame(p, background|leftColumn, test.x,
+				test.y)
+			expectRow(t, f, 0, test.row...)
+		})
+	}
+}
+
+func Test_Render_WrapIntoNextNametable(t *T) {
+	p, m := newBackgroundPPU()
+	m[0x2400] = 2
+	f := renderFrame(p, background|leftColumn, 8, 0)
+
+	for x := 248; x < Width; x++ {
+		ExpectEq(t, f[x], white, invalidPixelText)
+	}
+}
+
+func Test_Render_MidFrameScrollSplit(t *T) {
+	p, _ := newBackgroundPPU()
+	renderFrame(p, background|leftColumn, 0, 0)
+	tick(p, 100*dotsPerScanline)
+	p.Write(ppuScroll, 4)
+	p.Write(ppuScroll, 0)
+	tick(p, frameDots-100*dotsPerScanline)
+	f := p.GetFrame()
+
+	expectRow(t, f, 50, tile(white, backdrop)...)
+	expectRow(t, f, 150, tile(backdrop, white)...)
+}
@@ -0,0 +1,209 @@
+package ppu
+
+const (
+	spriteCount    = 64
+	spriteLimit    = 8
+	spriteBytes    = 4
+	spriteHeight   = 8
+	tallSpriteBase = 0x01
+
+	ctrlSpriteTable = 0x08
+	ctrlTallSprites = 0x20
+	spriteTableAddr = 0x1000
+	maskLeftSprites = 0x04
+
+	attrPalette  = 0x03
+	attrPriority = 0x20
+	attrFlipX    = 0x40
+	attrFlipY    = 0x80
+
+	spritePalettes = 0x10
+	// empty slots fetch pattern of tile $FF
+	emptyTile     = 0xff
+	spriteFetches = spriteLimit * tileWidth
+	lastHitX      = 255
+)
+
+type oamEntry struct {
+	y, tile, attr, x byte
+}
+
+type spriteRow struct {
+	x, attr   byte
+	low, high byte
+}
+
+// sprites for next scanline are evaluated into secondary
+// OAM, fetched patterns are drawn on following scanline
+type sprites struct {
+	isUnlimited  bool
+	secondary    []oamEntry
+	extra        []oamEntry
+	rows         []spriteRow
+	hasSprite0   bool
+	drawsSprite0 bool
+	fetch        spriteRow
+	fetchAddr    uint16
+}
+
+func (p *ppu) evaluateSprites() {
+	height := p.getSpriteHeight()
+	p.sp.secondary = p.sp.secondary[:0]
+	p.sp.hasSprite0 = false
+	n := 0
+	for ; n < spriteCount && len(p.sp.secondary) < spriteLimit; n++ {
+		e := p.getOAMEntry(n)
+		if p.isOnScanline(e.y, height) {
+			p.sp.secondary = append(p.sp.secondary, e)
+			p.sp.hasSprite0 = p.sp.hasSprite0 || n == 0
+		}
+	}
+	p.checkOverflow(n, height)
+	p.evaluateExtraSprites(n, height)
+}
+
+// sprites over the limit are only displayed, hardware
+// evaluation is not changed
+func (p *ppu) evaluateExtraSprites(n int, height int) {
+	p.sp.extra = p.sp.extra[:0]
+	for ; p.sp.isUnlimited && n < spriteCount; n++ {
+		if e := p.getOAMEntry(n); p.isOnScanline(e.y, height) {
+			p.sp.extra = append(p.sp.extra, e)
+		}
+	}
+}
+
+func (p *ppu) getOAMEntry(n int) oamEntry {
+	i := n * spriteBytes
+	return oamEntry{p.oam[i], p.oam[i+1], p.oam[i+2], p.oam[i+3]}
+}
+
+// after finding 8 sprites hardware increments both sprite
+// and byte index, so tiles and attributes are read as Y
+func (p *ppu) checkOverflow(n int, height int) {
+	for m := 0; n < spriteCount; n++ {
+		if p.isOnScanline(p.oam[n*spriteBytes+m], height) {
+			p.status |= statusOverflow
+			return
+		}
+		m = (m + 1) % spriteBytes
+	}
+}
+
+func (p *ppu) isOnScanline(y byte, height int) bool {
+	row := p.scanline - int(y)
+	return row >= 0 && row < height
+}
+
+func (p *ppu) getSpriteHeight() int {
+	if p.ctrl&ctrlTallSprites != 0 {
+		return 2 * spriteHeight
+	}
+	return spriteHeight
+}
+
+// patterns are fetched during dots 257-320 after two unused
+// nametable fetches, mappers see the same PPU address
+// sequence as on hardware
+func (p *ppu) fetchSprite() {
+	slot := (p.dot - copyXDot) / tileWidth
+	switch (p.dot - copyXDot) % tileWidth {
+	case 0:
+		p.startSpriteFetch(slot)
+		p.fetchNametable()
+	case 2:
+		p.fetchNametable()
+	case 4:
+		p.sp.fetch.low = p.vram.Read(p.sp.fetchAddr)
+	case 6:
+		p.sp.fetch.high = p.vram.Read(p.sp.fetchAddr + planeOffset)
+	case 7:
+		if slot < len(p.sp.secondary) {
+			p.sp.rows = append(p.sp.rows, p.sp.fetch)
+		}
+		if slot == spriteLimit-1 {
+			p.fetchExtraSprites()
+		}
+	}
+}
+
+// extra patterns are peeked, so mappers counting PPU
+// address changes are not affected
+func (p *ppu) fetchExtraSprites() {
+	peeker, ok := p.vram.(Peeker)
+	if !ok {
+		peeker = busPeeker{p.vram}
+	}
+	for _, e := range p.sp.extra {
+		addr := p.getSpriteRowAddr(e)
+		p.sp.rows = append(p.sp.rows, spriteRow{e.x, e.attr,
+			peeker.Peek(addr), peeker.Peek(addr + planeOffset)})
+	}
+}
+
+func (p *ppu) startSpriteFetch(slot int) {
+	if slot == 0 {
+		p.sp.rows = p.sp.rows[:0]
+		p.sp.drawsSprite0 = p.sp.hasSprite0
+	}
+	if slot >= len(p.sp.secondary) {
+		p.sp.fetchAddr = p.getSpritePatternAddr(emptyTile, 0)
+		return
+	}
+	e := p.sp.secondary[slot]
+	p.sp.fetchAddr = p.getSpriteRowAddr(e)
+	p.sp.fetch = spriteRow{x: e.x, attr: e.attr}
+}
+
+func (p *ppu) getSpriteRowAddr(e oamEntry) uint16 {
+	row := p.scanline - int(e.y)
+	if e.attr&attrFlipY != 0 {
+		row = p.getSpriteHeight() - 1 - row
+	}
+	return p.getSpritePatternAddr(e.tile, row)
+}
+
+// 8x16 sprites take pattern table from lowest tile bit
+func (p *ppu) getSpritePatternAddr(tile byte, row int) uint16 {
+	var table uint16
+	switch {
+	case p.ctrl&ctrlTallSprites != 0:
+		table = uint16(tile&tallSpriteBase) * spriteTableAddr
+		tile &^= tallSpriteBase
+		if row >= spriteHeight {
+			tile++
+			row -= spriteHeight
+		}
+	case p.ctrl&ctrlSpriteTable != 0:
+		table = spriteTableAddr
+	}
+	return table | uint16(tile)*tileBytes | uint16(row)
+}
+
+// returns palette RAM index of first opaque sprite and
+// whether it is sprite 0
+func (p *ppu) getSpritePixel(x int) (byte, byte, bool) {
+	if p.mask&maskSprites == 0 ||
+		x < tileWidth && p.mask&maskLeftSprites == 0 {
+		return 0, 0, false
+	}
+	for i, r := range p.sp.rows {
+		column := x - int(r.x)
+		if column < 0 || column >= tileWidth {
+			continue
+		}
+		if pixel := r.getPixel(column); pixel != 0 {
+			return spritePalettes | r.attr&attrPalette<<2 | pixel,
+				r.attr, i == 0 && p.sp.drawsSprite0
+		}
+	}
+	return 0, 0, false
+}
+
+func (r spriteRow) getPixel(column int) byte {
+	shift := 7 - column
+	if r.attr&attrFlipX != 0 {
+		shift = column
+	}
+	return r.low>>shift&1 | r.high>>shift&1<<1
+}
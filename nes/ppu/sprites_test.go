@@ -0,0 +1,290 @@
+package ppu_test
+
+import (
+	"github.com/smarkuck/nes/nes"
+	. "github.com/smarkuck/nes/nes/ppu"
+	. "github.com/smarkuck/unittest"
+)
+
+const (
+	green        = 0x2a
+	sprites      = 0x10
+	leftSprites  = 0x04
+	showAll      = background | leftColumn | sprites | leftSprites
+	tallSprites  = 0x20
+	spriteTable  = 0x08
+	overflowFlag = 0x20
+	sprite0Flag  = 0x40
+
+	behind = 0x20
+	flipX  = 0x40
+	flipY  = 0x80
+)
+
+type sprite struct {
+	y, tile, attr, x byte
+}
+
+var hidden = sprite{y: 0xf0}
+
+// tile 1 has left half opaque, tile 2 is fully opaque and
+// tile 3 has only top row opaque
+func newSpritePPU(sprites ...sprite) (PPU, *memoryBus) {
+	p, m := newPPU()
+	for row := 0; row < 8; row++ {
+		m[0x10+row], m[0x20+row] = 0xf0, 0xff
+		m[0x1030+row] = 0xff
+	}
+	m[0x30] = 0xff
+	setAddress(p, 0x3f00)
+	for _, color := range []byte{backdrop, white} {
+		p.Write(ppuData, color)
+	}
+	setAddress(p, 0x3f11)
+	p.Write(ppuData, red)
+	setAddress(p, 0x3f15)
+	p.Write(ppuData, green)
+	setOAM(p, sprites...)
+	return p, m
+}
+
+func setOAM(p PPU, sprites ...sprite) {
+	p.Write(oamAddr, 0)
+	for i := 0; i < 64; i++ {
+		s := hidden
+		if i < len(sprites) {
+			s = sprites[i]
+		}
+		for _, value := range []byte{s.y, s.tile, s.attr, s.x} {
+			p.Write(oamData, value)
+		}
+	}
+}
+
+func repeatSprite(s sprite, count int) []sprite {
+	result := make([]sprite, count)
+	for i := range result {
+		result[i] = s
+		result[i].x += byte(8 * i)
+	}
+	return result
+}
+
+func renderSprites(p PPU, ctrl, mask byte) *Frame {
+	p.Write(ppuScroll, 0)
+	p.Write(ppuScroll, 0)
+	p.Write(ppuCtrl, ctrl)
+	p.Write(ppuMask, mask)
+	tick(p, 2*frameDots)
+	return p.GetFrame()
+}
+
+func getPixel(f *Frame, x, y int) uint16 {
+	return f[y*Width+x]
+}
+
+func Test_Sprites_DrawBelowOAMPosition(t *T) {
+	p, _ := newSpritePPU(sprite{9, 1, 0, 20})
+	f := renderFrame(p, showAll, 0, 0)
+
+	expectRow(t, f, 9, repeatColor(backdrop, 8)...)
+	for y := 10; y < 18; y++ {
+		ExpectEq(t, getPixel(f, 19, y), backdrop, invalidPixelText)
+		ExpectEq(t, getPixel(f, 20, y), red, invalidPixelText)
+		ExpectEq(t, getPixel(f, 24, y), backdrop, invalidPixelText)
+	}
+	ExpectEq(t, getPixel(f, 20, 18), backdrop, invalidPixelText)
+}
+
+func Test_Sprites_SelectPalette(t *T) {
+	p, _ := newSpritePPU(sprite{9, 2, 1, 20})
+	f := renderFrame(p, showAll, 0, 0)
+
+	ExpectEq(t, getPixel(f, 20, 10), green, invalidPixelText)
+}
+
+func Test_Sprites_Flip(t *T) {
+	p, _ := newSpritePPU(sprite{9, 3, flipY, 0},
+		sprite{9, 1, flipX, 8})
+	f := renderFrame(p, showAll, 0, 0)
+
+	ExpectEq(t, getPixel(f, 0, 10), backdrop, invalidPixelText)
+	ExpectEq(t, getPixel(f, 0, 17), red, invalidPixelText)
+	expectRow(t, f, 10, append(repeatColor(backdrop, 8),
+		tile(backdrop, red)...)...)
+}
+
+func Test_Sprites_UsePatternTable(t *T) {
+	p, _ := newSpritePPU(sprite{9, 3, 0, 0})
+	f := renderSprites(p, spriteTable, showAll)
+
+	ExpectEq(t, getPixel(f, 0, 10), red, invalidPixelText)
+	ExpectEq(t, getPixel(f, 0, 11), red, invalidPixelText)
+}
+
+// odd tile takes both halves from second pattern table
+func Test_Sprites_Tall(t *T) {
+	p, m := newSpritePPU(sprite{9, 3, 0, 0},
+		sprite{9, 2, flipY, 8})
+	for row := 0; row < 8; row++ {
+		m[0x1020+row], m[0x1031+row] = 0xff, 0
+	}
+	f := renderSprites(p, tallSprites, showAll)
+
+	tests := []struct {
+		x, y  int
+		color uint16
+	}{
+		{0, 10, red}, {0, 17, red}, {0, 18, red}, {0, 19, backdrop},
+		{8, 10, backdrop}, {8, 16, backdrop}, {8, 17, red},
+		{8, 18, red}, {8, 25, red}, {8, 26, backdrop},
+	}
+	for _, test := range tests {
+		ExpectEq(t, getPixel(f, test.x, test.y), test.color,
+			invalidPixelText)
+	}
+}
+
+func Test_Sprites_Priority(t *T) {
+	p, m := newSpritePPU(sprite{9, 2, 0, 0}, sprite{9, 2, behind, 8},
+		sprite{9, 1, 1, 8}, sprite{9, 2, 1, 16})
+	m[0x2020] = 1
+	m[0x2021] = 1
+	f := renderSprites(p, 0, showAll)
+
+	expectRow(t, f, 10, append(repeatColor(red, 8),
+		tile(white, red)...)...)
+	ExpectEq(t, getPixel(f, 16, 10), green, invalidPixelText)
+}
+
+func Test_Sprites_DrawOnlyEightPerScanline(t *T) {
+	p, _ := newSpritePPU(repeatSprite(sprite{9, 2, 0, 0}, 9)...)
+	f := renderSprites(p, 0, showAll)
+
+	ExpectEq(t, getPixel(f, 63, 10), red, invalidPixelText)
+	ExpectEq(t, getPixel(f, 64, 10), backdrop, invalidPixelText)
+}
+
+func Test_Sprites_Overflow(t *T) {
+	eight := repeatSprite(sprite{9, 2, 0, 0}, 8)
+	below := sprite{100, 0, 0, 0}
+	tests := []struct {
+		name       string
+		sprites    []sprite
+		isOverflow bool
+	}{
+		{"Eight", eight, false},
+		{"Nine", append(eight, sprite{9, 0, 0, 0}), true},
+		// second sprite after eight is checked by tile number
+		{"FalsePositive", append(eight, below,
+			sprite{200, 9, 0, 0}), true},
+		{"FalseNegative", append(eight, below,
+			sprite{9, 100, 0, 0}), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			p, _ := newSpritePPU(test.sprites...)
+			renderSprites(p, 0, showAll)
+			tick(p, 20*dotsPerScanline)
+			ExpectEq(t, p.Read(ppuStatus)&overflowFlag != 0,
+				test.isOverflow, invalidStatusText)
+		})
+	}
+}
+
+func Test_Sprites_Sprite0Hit(t *T) {
+	tests := []struct {
+		name  string
+		x     byte
+		mask  byte
+		isHit bool
+	}{
+		{"Hit", 20, showAll, true},
+		{"LastColumn", 255, showAll, false},
+		{"BackgroundClip", 0, showAll &^ leftColumn, false},
+		{"SpritesClip", 0, showAll &^ leftSprites, false},
+		{"ClipEdge", 1, showAll &^ leftColumn, true},
+		{"NoBackground", 20, sprites | leftSprites, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			p, m := newSpritePPU(sprite{9, 2, 0, test.x})
+			for i := 0; i < 32; i++ {
+				m[0x2020+i] = 2
+			}
+			renderSprites(p, 0, test.mask)
+			tick(p, 20*dotsPerScanline)
+			ExpectEq(t, p.Read(ppuStatus)&sprite0Flag != 0,
+				test.isHit, invalidStatusText)
+		})
+	}
+}
+
+func Test_Sprites_Sprite0HitNeedsSprite0(t *T) {
+	p, m := newSpritePPU(hidden, sprite{9, 2, 0, 20})
+	m[0x2022] = 2
+	renderSprites(p, 0, showAll)
+	tick(p, 20*dotsPerScanline)
+
+	ExpectEq(t, p.Read(ppuStatus)&sprite0Flag, 0, invalidStatusText)
+}
+
+func Test_Sprites_ClearFlagsOnPreRenderScanline(t *T) {
+	p, m := newSpritePPU(repeatSprite(sprite{9, 2, 0, 0}, 9)...)
+	m[0x2020] = 2
+	renderSprites(p, 0, showAll)
+	tick(p, 20*dotsPerScanline)
+	ExpectEq(t, p.Read(ppuStatus)&(sprite0Flag|overflowFlag),
+		sprite0Flag|overflowFlag, invalidStatusText)
+
+	setOAM(p)
+	tick(p, 245*dotsPerScanline)
+	ExpectEq(t, p.Read(ppuStatus)&(sprite0Flag|overflowFlag),
+		0, invalidStatusText)
+}
+
+type peekBus struct {
+	memoryBus
+	reads int
+	peeks int
+}
+
+func (p *peekBus) Read(addr uint16) byte {
+	p.reads++
+	return p.memoryBus.Read(addr)
+}
+
+func (p *peekBus) Peek(addr uint16) byte {
+	p.peeks++
+	return p.memoryBus.Read(addr)
+}
+
+func Test_Sprites_WithoutLimit_DrawAllSprites(t *T) {
+	p, _ := newSpritePPU(repeatSprite(sprite{9, 2, 0, 0}, 12)...)
+	p.SetSpriteLimit(false)
+	f := renderSprites(p, 0, showAll)
+
+	ExpectEq(t, getPixel(f, 95, 10), red, invalidPixelText)
+	ExpectEq(t, getPixel(f, 96, 10), backdrop, invalidPixelText)
+	tick(p, 20*dotsPerScanline)
+	ExpectEq(t, p.Read(ppuStatus)&overflowFlag, overflowFlag,
+		invalidStatusText)
+}
+
+func Test_Sprites_WithoutLimit_KeepBusAccesses(t *T) {
+	oam := repeatSprite(sprite{9, 2, 0, 0}, 12)
+	var reads [2]int
+	for i, isLimited := range []bool{true, false} {
+		b := new(peekBus)
+		p := NewPPU(b, nes.NTSC)
+		setOAM(p, oam...)
+		p.SetSpriteLimit(isLimited)
+		renderSprites(p, 0, showAll)
+		reads[i] = b.reads
+		ExpectEq(t, b.peeks > 0, !isLimited, invalidVRAMText)
+	}
+
+	ExpectEq(t, reads[0], reads[1], invalidVRAMText)
+}
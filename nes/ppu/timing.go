@@ -0,0 +1,104 @@
+package ppu
+
+import "github.com/smarkuck/nes/nes"
+
+const (
+	dotsPerScanline = 341
+	// flags change on second dot of scanline
+	flagDot = 1
+	// odd frames with rendering enabled are one dot shorter
+	skippedDot = dotsPerScanline - 2
+
+	ctrlNMI        = 0x80
+	maskBackground = 0x08
+	maskSprites    = 0x10
+)
+
+type regionTiming struct {
+	scanlines      int
+	vblankScanline int
+	hasSkippedDot  bool
+}
+
+// Dendy keeps NTSC vblank length by starting it
+// 50 scanlines later
+var regionTimings = map[nes.Region]regionTiming{
+	nes.NTSC:  {262, 241, true},
+	nes.PAL:   {312, 241, false},
+	nes.Dendy: {312, 291, false},
+}
+
+type timing struct {
+	regionTiming
+	scanline        int
+	dot             int
+	frameCount      int
+	isOddFrame      bool
+	isVBlankBlocked bool
+}
+
+func (p *ppu) Tick() {
+	p.render()
+	switch {
+	case p.scanline == p.vblankScanline && p.dot == flagDot:
+		p.setVBlank()
+	case p.isPreRenderScanline() && p.dot == flagDot:
+		p.status &^= statusVBlank | statusSprite0Hit |
+			statusOverflow
+	}
+	p.nextDot()
+}
+
+func (p *ppu) setVBlank() {
+	if !p.isVBlankBlocked {
+		p.status |= statusVBlank
+	}
+	p.isVBlankBlocked = false
+}
+
+func (p *ppu) nextDot() {
+	if p.hasSkippedDot && p.isPreRenderScanline() &&
+		p.dot == skippedDot && p.isOddFrame && p.isRendering() {
+		p.dot++
+	}
+	if p.dot++; p.dot < dotsPerScanline {
+		return
+	}
+	p.dot = 0
+	if p.scanline++; p.scanline == p.scanlines {
+		p.scanline = 0
+		p.frameCount++
+		p.isOddFrame = !p.isOddFrame
+	}
+}
+
+func (p *ppu) isRendering() bool {
+	return p.mask&(maskBackground|maskSprites) != 0
+}
+
+func (p *ppu) isRenderingScanline() bool {
+	return p.isRendering() && (p.scanline < Height ||
+		p.isPreRenderScanline())
+}
+
+func (p *ppu) isPreRenderScanline() bool {
+	return p.scanline == p.scanlines-1
+}
+
+// reading status one dot before vblank returns it clear
+// and blocks the flag with NMI for whole frame
+func (p *ppu) blockVBlank() {
+	if p.scanline == p.vblankScanline && p.dot == flagDot {
+		p.isVBlankBlocked = true
+	}
+}
+
+// GetFrameCount is increased after pre-render scanline,
+// frame buffer is complete then
+func (p *ppu) GetFrameCount() int {
+	return p.frameCount
+}
+
+func (p *ppu) IsNMI() bool {
+	return p.status&statusVBlank != 0 && p.ctrl&ctrlNMI != 0
+}
@@ -0,0 +1,181 @@
+package ppu_test
+
+import (
+	"github.com/smarkuck/nes/nes"
+	. "github.com/smarkuck/nes/nes/ppu"
+	. "github.com/smarkuck/unittest"
+)
+
+const (
+	dotsPerScanline = 341
+	frameDots       = 262 * dotsPerScanline
+	// vblank is set while processing dot 1 of scanline 241
+	vblankDots    = 241*dotsPerScanline + 2
+	preRenderDots = 261*dotsPerScanline + 2
+
+	ctrlNMI       = 0x80
+	maskRendering = 0x18
+
+	invalidStatusText = "invalid status"
+	invalidNMIText    = "invalid NMI"
+	invalidFrameText  = "invalid frame length"
+)
+
+func tick(p PPU, dots int) {
+	for i := 0; i < dots; i++ {
+		p.Tick()
+	}
+}
+
+func isVBlank(p PPU) bool {
+	return p.Read(ppuStatus)&0x80 != 0
+}
+
+func Test_Timing_SetVBlank(t *T) {
+	p, _ := newPPU()
+	tick(p, vblankDots-1)
+	ExpectFalse(t, isVBlank(p), invalidStatusText)
+
+	p, _ = newPPU()
+	tick(p, vblankDots)
+	ExpectTrue(t, isVBlank(p), invalidStatusText)
+	ExpectFalse(t, isVBlank(p), invalidStatusText)
+}
+
+func Test_Timing_ClearVBlankOnPreRenderScanline(t *T) {
+	p, _ := newPPU()
+	tick(p, preRenderDots-1)
+	ExpectTrue(t, isVBlank(p), invalidStatusText)
+
+	p, _ = newPPU()
+	tick(p, preRenderDots)
+	ExpectFalse(t, isVBlank(p), invalidStatusText)
+}
+
+func Test_Timing_GenerateNMI(t *T) {
+	p, _ := newPPU()
+	p.Write(ppuCtrl, ctrlNMI)
+	tick(p, vblankDots-1)
+	ExpectFalse(t, p.IsNMI(), invalidNMIText)
+
+	p.Tick()
+	ExpectTrue(t, p.IsNMI(), invalidNMIText)
+	tick(p, preRenderDots-vblankDots)
+	ExpectFalse(t, p.IsNMI(), invalidNMIText)
+}
+
+func Test_Timing_WhenNMIDisabled_NoNMI(t *T) {
+	p, _ := newPPU()
+	tick(p, vblankDots)
+
+	ExpectFalse(t, p.IsNMI(), invalidNMIText)
+}
+
+// CPU sees another rising edge on NMI line
+func Test_Timing_EnableNMIDuringVBlank_RaiseNMIAgain(t *T) {
+	p, _ := newPPU()
+	p.Write(ppuCtrl, ctrlNMI)
+	tick(p, vblankDots)
+	p.Write(ppuCtrl, 0)
+	ExpectFalse(t, p.IsNMI(), invalidNMIText)
+
+	p.Write(ppuCtrl, ctrlNMI)
+	ExpectTrue(t, p.IsNMI(), invalidNMIText)
+}
+
+func Test_Timing_ReadStatus_ClearNMI(t *T) {
+	p, _ := newPPU()
+	p.Write(ppuCtrl, ctrlNMI)
+	tick(p, vblankDots)
+	p.Read(ppuStatus)
+
+	ExpectFalse(t, p.IsNMI(), invalidNMIText)
+}
+
+func Test_Timing_ReadStatusBeforeVBlank_SuppressFrame(t *T) {
+	p, _ := newPPU()
+	p.Write(ppuCtrl, ctrlNMI)
+	tick(p, vblankDots-1)
+	ExpectFalse(t, isVBlank(p), invalidStatusText)
+
+	p.Tick()
+	ExpectFalse(t, p.IsNMI(), invalidNMIText)
+	ExpectFalse(t, isVBlank(p), invalidStatusText)
+	tick(p, frameDots)
+	ExpectTrue(t, p.IsNMI(), invalidNMIText)
+}
+
+func Test_Timing_FrameLength(t *T) {
+	tests := []struct {
+		name   string
+		mask   byte
+		frames []int
+	}{
+		{"RenderingDisabled", 0, []int{frameDots, frameDots}},
+		{"RenderingEnabled", maskRendering,
+			[]int{frameDots, frameDots - 1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			p, _ := newPPU()
+			p.Write(ppuCtrl, ctrlNMI)
+			tick(p, vblankDots)
+			p.Write(ppuMask, test.mask)
+			for _, frame := range test.frames {
+				ExpectEq(t, countFrameDots(p), frame,
+					invalidFrameText)
+			}
+		})
+	}
+}
+
+func Test_Timing_Region(t *T) {
+	tests := []struct {
+		region nes.Region
+		vblank int
+		frames []int
+	}{
+		{nes.NTSC, vblankDots, []int{frameDots, frameDots - 1}},
+		{nes.PAL, vblankDots, []int{312 * dotsPerScanline,
+			312 * dotsPerScanline}},
+		{nes.Dendy, 291*dotsPerScanline + 2,
+			[]int{312 * dotsPerScanline, 312 * dotsPerScanline}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.region.String(), func(t *T) {
+			p := NewPPU(new(memoryBus), test.region)
+			p.Write(ppuCtrl, ctrlNMI)
+			tick(p, test.vblank-1)
+			ExpectFalse(t, p.IsNMI(), invalidNMIText)
+			p.Tick()
+			ExpectTrue(t, p.IsNMI(), invalidNMIText)
+
+			p.Write(ppuMask, maskRendering)
+			for _, frame := range test.frames {
+				ExpectEq(t, countFrameDots(p), frame,
+					invalidFrameText)
+			}
+		})
+	}
+}
+
+func Test_Timing_CountFrames(t *T) {
+	p, _ := newPPU()
+	tick(p, frameDots-1)
+	ExpectEq(t, p.GetFrameCount(), 0, invalidFrameText)
+
+	p.Tick()
+	ExpectEq(t, p.GetFrameCount(), 1, invalidFrameText)
+}
+
+// counts dots to next rising edge of NMI line
+func countFrameDots(p PPU) int {
+	dots := 0
+	for wasNMI := true; wasNMI || !p.IsNMI(); dots++ {
+		wasNMI = p.IsNMI()
+		p.Tick()
+	}
+	return dots
+}
@@ -0,0 +1,18 @@
+package nes
+
+type Region uint8
+
+const (
+	NTSC Region = iota
+	PAL
+	Dendy
+)
+
+var regionNames = [...]string{"NTSC", "PAL", "Dendy"}
+
+func (r Region) String() string {
+	if int(r) < len(regionNames) {
+		return regionNames[r]
+	}
+	return "unknown"
+}
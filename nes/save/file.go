@@ -0,0 +1,71 @@
+package save
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const (
+	fileExtension = ".sav"
+	tempPattern   = ".*.tmp"
+	filePerm      = 0o644
+)
+
+type fileStorage struct {
+	dir       string
+	extension string
+}
+
+// save files contain raw memory like in other emulators
+func NewFileStorage(dir string) Storage {
+	return NewFileStorageWithExtension(dir, fileExtension)
+}
+
+// other data kept next to ROM, like disk diffs, needs
+// its own extension to not collide with save files
+func NewFileStorageWithExtension(dir,
+	extension string) Storage {
+	return &fileStorage{dir, extension}
+}
+
+func (f *fileStorage) Load(name string) ([]byte, error) {
+	data, err := os.ReadFile(f.getPath(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// data is written to temporary file and renamed,
+// so crash never leaves partially written save
+func (f *fileStorage) Store(name string, data []byte) error {
+	tmp, err := os.CreateTemp(f.dir, name+tempPattern)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := writeFile(tmp, data); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.getPath(name))
+}
+
+func writeFile(file *os.File, data []byte) error {
+	_, err := file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(file.Name(), filePerm)
+	}
+	return err
+}
+
+func (f *fileStorage) getPath(name string) string {
+	return filepath.Join(f.dir, name+f.extension)
+}
@@ -0,0 +1,59 @@
+package save_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/smarkuck/nes/nes/save"
+	. "github.com/smarkuck/unittest"
+)
+
+func Test_FileStorage_StoreRawSaveFile(t *T) {
+	dir := t.TempDir()
+	s := NewFileStorage(dir)
+
+	ExpectTrue(t, s.Store(saveName, []byte{1, 2, 3}) == nil, invalidErrorText)
+
+	data, err := os.ReadFile(filepath.Join(dir, "game.sav"))
+	ExpectTrue(t, err == nil, invalidErrorText)
+	ExpectDeepEq(t, data, []byte{1, 2, 3}, invalidSaveText)
+}
+
+func Test_FileStorage_StoreWithExtension(t *T) {
+	dir := t.TempDir()
+	s := NewFileStorageWithExtension(dir, ".ips")
+
+	ExpectTrue(t, s.Store(saveName, []byte{1}) == nil, invalidErrorText)
+
+	data, err := os.ReadFile(filepath.Join(dir, "game.ips"))
+	ExpectTrue(t, err == nil, invalidErrorText)
+	ExpectDeepEq(t, data, []byte{1}, invalidSaveText)
+}
+
+func Test_FileStorage_ReplaceSaveWithoutLeftovers(t *T) {
+	dir := t.TempDir()
+	s := NewFileStorage(dir)
+	s.Store(saveName, []byte{1, 2, 3})
+
+	s.Store(saveName, []byte{4})
+
+	data, _ := s.Load(saveName)
+	ExpectDeepEq(t, data, []byte{4}, invalidSaveText)
+	entries, _ := os.ReadDir(dir)
+	ExpectEq(t, len(entries), 1)
+}
+
+func Test_FileStorage_WhenFileMissing_LoadNothing(t *T) {
+	data, err := NewFileStorage(t.TempDir()).Load(saveName)
+
+	ExpectTrue(t, err == nil, invalidErrorText)
+	ExpectDeepEq(t, data, []byte(nil), invalidSaveText)
+}
+
+func Test_FileStorage_WhenDirMissing_ReturnError(t *T) {
+	dir := filepath.Join(t.TempDir(), "missing")
+
+	err := NewFileStorage(dir).Store(saveName, []byte{1})
+
+	ExpectTrue(t, os.IsNotExist(err), invalidErrorText)
+}
@@ -0,0 +1,84 @@
+package save
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+const (
+	loadErrorFormat  = "cannot load save RAM %q: %w"
+	storeErrorFormat = "cannot store save RAM %q: %w"
+)
+
+type Manager interface {
+	Update(elapsed time.Duration) error
+	Flush() error
+	Close() error
+}
+
+type manager struct {
+	storage  Storage
+	name     string
+	ram      []byte
+	saved    []byte
+	interval time.Duration
+	elapsed  time.Duration
+}
+
+// Open loads save into RAM, zero interval disables autosave
+func Open(s Storage, name string, ram []byte,
+	interval time.Duration) (Manager, error) {
+	m := &manager{
+		storage:  s,
+		name:     name,
+		ram:      ram,
+		interval: interval,
+	}
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// files of different size are loaded partially
+func (m *manager) load() error {
+	if len(m.ram) == 0 {
+		return nil
+	}
+	data, err := m.storage.Load(m.name)
+	if err != nil {
+		return fmt.Errorf(loadErrorFormat, m.name, err)
+	}
+	copy(m.ram, data)
+	m.saved = append([]byte(nil), m.ram...)
+	return nil
+}
+
+func (m *manager) Update(elapsed time.Duration) error {
+	if m.interval == 0 {
+		return nil
+	}
+	m.elapsed += elapsed
+	if m.elapsed < m.interval {
+		return nil
+	}
+	m.elapsed = 0
+	return m.Flush()
+}
+
+// Flush writes RAM only when it changed since last save
+func (m *manager) Flush() error {
+	if len(m.ram) == 0 || bytes.Equal(m.ram, m.saved) {
+		return nil
+	}
+	if err := m.storage.Store(m.name, m.ram); err != nil {
+		return fmt.Errorf(storeErrorFormat, m.name, err)
+	}
+	m.saved = append(m.saved[:0], m.ram...)
+	return nil
+}
+
+func (m *manager) Close() error {
+	return m.Flush()
+}
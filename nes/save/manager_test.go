@@ -0,0 +1,147 @@
+package save_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/smarkuck/nes/nes/save"
+	. "github.com/smarkuck/unittest"
+)
+
+const (
+	saveName = "game"
+
+	invalidRAMText   = "invalid RAM content"
+	invalidSaveText  = "invalid saved content"
+	invalidErrorText = "invalid error message"
+)
+
+var errStorage = errors.New("storage failure")
+
+type failingStorage struct{}
+
+func (failingStorage) Load(string) ([]byte, error) {
+	return nil, errStorage
+}
+
+func (failingStorage) Store(string, []byte) error {
+	return errStorage
+}
+
+func openManager(t *T, s Storage, ram []byte,
+	interval time.Duration) Manager {
+	m, err := Open(s, saveName, ram, interval)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func loadSave(t *T, s Storage) []byte {
+	data, _ := s.Load(saveName)
+	return data
+}
+
+func Test_Manager_OnOpen_LoadSave(t *T) {
+	tests := []struct {
+		name string
+		save []byte
+		ram  []byte
+	}{
+		{"SameSize", []byte{1, 2, 3}, []byte{1, 2, 3}},
+		{"Shorter", []byte{1, 2}, []byte{1, 2, 0}},
+		{"Longer", []byte{1, 2, 3, 4}, []byte{1, 2, 3}},
+		{"Missing", nil, []byte{0, 0, 0}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *T) {
+			s := NewMemoryStorage()
+			if test.save != nil {
+				s.Store(saveName, test.save)
+			}
+			ram := make([]byte, 3)
+			openManager(t, s, ram, 0)
+			ExpectDeepEq(t, ram, test.ram, invalidRAMText)
+		})
+	}
+}
+
+func Test_Manager_OnClose_StoreChangedRAM(t *T) {
+	s := NewMemoryStorage()
+	ram := make([]byte, 3)
+	m := openManager(t, s, ram, 0)
+
+	ExpectTrue(t, m.Close() == nil, invalidErrorText)
+	ExpectDeepEq(t, loadSave(t, s), []byte(nil), invalidSaveText)
+
+	ram[1] = 0x42
+	ExpectTrue(t, m.Close() == nil, invalidErrorText)
+	ExpectDeepEq(t, loadSave(t, s), []byte{0, 0x42, 0},
+		invalidSaveText)
+}
+
+func Test_Manager_Autosave(t *T) {
+	s := NewMemoryStorage()
+	ram := make([]byte, 1)
+	m := openManager(t, s, ram, time.Second)
+	ram[0] = 0x42
+
+	m.Update(600 * time.Millisecond)
+	ExpectDeepEq(t, loadSave(t, s), []byte(nil), invalidSaveText)
+	m.Update(400 * time.Millisecond)
+	ExpectDeepEq(t, loadSave(t, s), []byte{0x42}, invalidSaveText)
+}
+
+func Test_Manager_WithoutInterval_DoNotAutosave(t *T) {
+	s := NewMemoryStorage()
+	ram := make([]byte, 1)
+	m := openManager(t, s, ram, 0)
+	ram[0] = 0x42
+
+	m.Update(time.Hour)
+
+	ExpectDeepEq(t, loadSave(t, s), []byte(nil), invalidSaveText)
+}
+
+func Test_Manager_WithoutRAM_DoNothing(t *T) {
+	m := openManager(t, failingStorage{}, nil, time.Second)
+
+	ExpectTrue(t, m.Update(time.Second) == nil, invalidErrorText)
+	ExpectTrue(t, m.Close() == nil, invalidErrorText)
+}
+
+func Test_Manager_ReportStorageErrors(t *T) {
+	_, err := Open(failingStorage{}, saveName, make([]byte, 1), 0)
+	ExpectEq(t, err.Error(),
+		`cannot load save RAM "game": storage failure`,
+		invalidErrorText)
+	ExpectTrue(t, errors.Is(err, errStorage), invalidErrorText)
+}
+
+func Test_Manager_OnFlushError_ReportIt(t *T) {
+	s := &flakyStorage{Storage: NewMemoryStorage()}
+	ram := make([]byte, 1)
+	m := openManager(t, s, ram, 0)
+	ram[0] = 0x42
+
+	s.isFailing = true
+	ExpectEq(t, m.Flush().Error(),
+		`cannot store save RAM "game": storage failure`,
+		invalidErrorText)
+	s.isFailing = false
+	ExpectTrue(t, m.Flush() == nil, invalidErrorText)
+	ExpectDeepEq(t, loadSave(t, s), []byte{0x42}, invalidSaveText)
+}
+
+type flakyStorage struct {
+	Storage
+	isFailing bool
+}
+
+func (f *flakyStorage) Store(name string, data []byte) error {
+	if f.isFailing {
+		return errStorage
+	}
+	return f.Storage.Store(name, data)
+}
@@ -0,0 +1,21 @@
+package save
+
+type Storage interface {
+	Load(name string) ([]byte, error)
+	Store(name string, data []byte) error
+}
+
+type memoryStorage map[string][]byte
+
+func NewMemoryStorage() Storage {
+	return memoryStorage{}
+}
+
+func (m memoryStorage) Load(name string) ([]byte, error) {
+	return m[name], nil
+}
+
+func (m memoryStorage) Store(name string, data []byte) error {
+	m[name] = append([]byte(nil), data...)
+	return nil
+}
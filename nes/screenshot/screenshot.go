@@ -0,0 +1,142 @@
+package screenshot
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/smarkuck/nes/nes/ppu"
+)
+
+const (
+	// most TVs hide 8 lines at the top and the bottom
+	overscanLines = 8
+	maxColor      = 255
+	// filtered frame has twice as many columns
+	filteredRowScale = 2
+
+	invalidScaleFormat  = "invalid scale %d"
+	unknownFormatFormat = "unknown screenshot format %q"
+)
+
+type Format uint8
+
+const (
+	PNG Format = iota
+	PPM
+)
+
+var formatExtensions = map[string]Format{
+	".png": PNG,
+	".ppm": PPM,
+}
+
+// GetFormat picks format by file extension
+func GetFormat(name string) (Format, error) {
+	ext := strings.ToLower(filepath.Ext(name))
+	if f, ok := formatExtensions[ext]; ok {
+		return f, nil
+	}
+	return 0, fmt.Errorf(unknownFormatFormat, ext)
+}
+
+// Options scale enlarges every pixel to square of given
+// size, crop removes overscan lines
+type Options struct {
+	Format
+	Scale int
+	Crop  bool
+}
+
+func DefaultOptions() Options {
+	return Options{PNG, 1, false}
+}
+
+func NewImage(f *ppu.Frame, p *ppu.Palette,
+	o Options) (*image.RGBA, error) {
+	return newImage(ppu.Width, 1, o, func(x, y int) ppu.RGB {
+		return p.GetRGB(f[y*ppu.Width+x])
+	})
+}
+
+// NewFilteredImage doubles rows of filtered frame,
+// so picture keeps aspect of unfiltered one
+func NewFilteredImage(f *ppu.FilteredFrame,
+	o Options) (*image.RGBA, error) {
+	return newImage(ppu.FilteredWidth, filteredRowScale, o,
+		func(x, y int) ppu.RGB {
+			return f[y*ppu.FilteredWidth+x]
+		})
+}
+
+func newImage(width, rowScale int, o Options,
+	getRGB func(x, y int) ppu.RGB) (*image.RGBA, error) {
+	if o.Scale < 1 {
+		return nil, fmt.Errorf(invalidScaleFormat, o.Scale)
+	}
+	top, bottom := 0, ppu.Height
+	if o.Crop {
+		top, bottom = overscanLines, ppu.Height-overscanLines
+	}
+	height := rowScale * o.Scale
+	img := image.NewRGBA(image.Rect(0, 0,
+		width*o.Scale, (bottom-top)*height))
+	for y := top; y < bottom; y++ {
+		for x := 0; x < width; x++ {
+			c := getRGB(x, y)
+			fill(img, image.Rect(x*o.Scale, (y-top)*height,
+				(x+1)*o.Scale, (y-top+1)*height),
+				color.RGBA{c.R, c.G, c.B, maxColor})
+		}
+	}
+	return img, nil
+}
+
+func fill(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+func Write(w io.Writer, f *ppu.Frame, p *ppu.Palette,
+	o Options) error {
+	img, err := NewImage(f, p, o)
+	if err != nil {
+		return err
+	}
+	return encode(w, img, o.Format)
+}
+
+func WriteFiltered(w io.Writer, f *ppu.FilteredFrame,
+	o Options) error {
+	img, err := NewFilteredImage(f, o)
+	if err != nil {
+		return err
+	}
+	return encode(w, img, o.Format)
+}
+
+func encode(w io.Writer, img *image.RGBA, f Format) error {
+	if f == PPM {
+		return writePPM(w, img)
+	}
+	return png.Encode(w, img)
+}
+
+// writePPM uses binary P6 variant
+func writePPM(w io.Writer, img *image.RGBA) error {
+	bw := bufio.NewWriter(w)
+	size := img.Bounds().Size()
+	fmt.Fprintf(bw, "P6\n%d %d\n%d\n", size.X, size.Y, maxColor)
+	for i := 0; i < len(img.Pix); i += 4 {
+		bw.Write(img.Pix[i : i+3])
+	}
+	return bw.Flush()
+}
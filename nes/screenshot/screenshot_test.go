@@ -0,0 +1,155 @@
+package screenshot_test
+
+import (
+	"bytes"
+	"image/png"
+
+	"github.com/smarkuck/nes/nes/ppu"
+	. "github.com/smarkuck/nes/nes/screenshot"
+	. "github.com/smarkuck/unittest"
+)
+
+const (
+	invalidImageText  = "invalid image"
+	invalidFormatText = "invalid format"
+	invalidErrorText  = "invalid error"
+)
+
+var (
+	red   = ppu.RGB{R: 0xff}
+	green = ppu.RGB{G: 0xff}
+	blue  = ppu.RGB{B: 0xff}
+)
+
+// first row and column are red, last row is blue
+func newFrame() (*ppu.Frame, *ppu.Palette) {
+	p := new(ppu.Palette)
+	p[1], p[2], p[3] = red, green, blue
+	f := new(ppu.Frame)
+	for i := range f {
+		f[i] = 2
+	}
+	for x := 0; x < ppu.Width; x++ {
+		f[x], f[(ppu.Height-1)*ppu.Width+x] = 1, 3
+	}
+	for y := 0; y < ppu.Height; y++ {
+		f[y*ppu.Width] = 1
+	}
+	return f, p
+}
+
+func write(t *T, o Options) []byte {
+	f, p := newFrame()
+	var b bytes.Buffer
+	ExpectTrue(t, Write(&b, f, p, o) == nil, invalidErrorText)
+	return b.Bytes()
+}
+
+func Test_Screenshot_WritePNG(t *T) {
+	data := write(t, DefaultOptions())
+	img, err := png.Decode(bytes.NewReader(data))
+
+	ExpectTrue(t, err == nil, invalidErrorText)
+	ExpectEq(t, img.Bounds().Dx(), 256, invalidImageText)
+	ExpectEq(t, img.Bounds().Dy(), 240, invalidImageText)
+	r, g, b, _ := img.At(1, 1).RGBA()
+	ExpectEq(t, [3]uint32{r, g, b}, [3]uint32{0, 0xffff, 0},
+		invalidImageText)
+}
+
+func Test_Screenshot_WritePPM(t *T) {
+	o := DefaultOptions()
+	o.Format = PPM
+	data := write(t, o)
+	header := "P6\n256 240\n255\n"
+
+	ExpectEq(t, string(data[:len(header)]), header, invalidImageText)
+	ExpectEq(t, len(data), len(header)+256*240*3, invalidImageText)
+	ExpectDeepEq(t, data[len(header):len(header)+6],
+		[]byte{0xff, 0, 0, 0xff, 0, 0}, invalidImageText)
+}
+
+func Test_Screenshot_Scale(t *T) {
+	f, p := newFrame()
+	img, _ := NewImage(f, p, Options{Scale: 3})
+
+	ExpectEq(t, img.Bounds().Dx(), 3*256, invalidImageText)
+	ExpectEq(t, img.Bounds().Dy(), 3*240, invalidImageText)
+	ExpectEq(t, img.RGBAAt(2, 2).R, 0xff, invalidImageText)
+	ExpectEq(t, img.RGBAAt(3, 3).G, 0xff, invalidImageText)
+}
+
+func Test_Screenshot_CropOverscan(t *T) {
+	f, p := newFrame()
+	img, _ := NewImage(f, p, Options{Scale: 1, Crop: true})
+
+	ExpectEq(t, img.Bounds().Dy(), 224, invalidImageText)
+	ExpectEq(t, img.RGBAAt(1, 0).G, 0xff, invalidImageText)
+	ExpectEq(t, img.RGBAAt(1, 223).G, 0xff, invalidImageText)
+}
+
+// first filtered row is red
+func newFilteredFrame() *ppu.FilteredFrame {
+	f := new(ppu.FilteredFrame)
+	for i := range f {
+		f[i] = green
+	}
+	for x := 0; x < ppu.FilteredWidth; x++ {
+		f[x] = red
+	}
+	return f
+}
+
+func Test_Screenshot_FilteredImage_DoubleRows(t *T) {
+	img, err := NewFilteredImage(newFilteredFrame(),
+		Options{Scale: 2})
+
+	ExpectTrue(t, err == nil, invalidErrorText)
+	ExpectEq(t, img.Bounds().Dx(), 2*512, invalidImageText)
+	ExpectEq(t, img.Bounds().Dy(), 4*240, invalidImageText)
+	ExpectEq(t, img.RGBAAt(1023, 3).R, 0xff, invalidImageText)
+	ExpectEq(t, img.RGBAAt(1023, 4).G, 0xff, invalidImageText)
+}
+
+func Test_Screenshot_WriteFilteredPPM(t *T) {
+	o := DefaultOptions()
+	o.Format, o.Crop = PPM, true
+	var b bytes.Buffer
+
+	err := WriteFiltered(&b, newFilteredFrame(), o)
+
+	ExpectTrue(t, err == nil, invalidErrorText)
+	ExpectTrue(t, bytes.HasPrefix(b.Bytes(),
+		[]byte("P6\n512 448\n255\n")), invalidImageText)
+}
+
+func Test_Screenshot_WhenScaleInvalid_ReturnError(t *T) {
+	f, p := newFrame()
+	_, err := NewImage(f, p, Options{})
+
+	ExpectTrue(t, err != nil, invalidErrorText)
+	ExpectEq(t, err.Error(), "invalid scale 0", invalidErrorText)
+}
+
+func Test_GetFormat(t *T) {
+	tests := map[string]Format{
+		"shot.png":     PNG,
+		"dir/shot.PPM": PPM,
+	}
+
+	for name, format := range tests {
+		t.Run(name, func(t *T) {
+			f, err := GetFormat(name)
+			ExpectTrue(t, err == nil, invalidErrorText)
+			ExpectEq(t, f, format, invalidFormatText)
+		})
+	}
+}
+
+func Test_GetFormat_WhenUnknown_ReturnError(t *T) {
+	_, err := GetFormat("shot.bmp")
+
+	ExpectTrue(t, err != nil, invalidErrorText)
+	ExpectEq(t, err.Error(), `unknown screenshot format ".bmp"`,
+		invalidErrorText)
+}